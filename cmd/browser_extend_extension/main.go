@@ -1,33 +1,36 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/osquery/osquery-go"
-	"github.com/osquery/osquery-go/plugin/table"
 
 	"osquery-extension-browsers/internal/browsers/chromium"
+	"osquery-extension-browsers/internal/browsers/common"
 	"osquery-extension-browsers/internal/browsers/firefox"
+	"osquery-extension-browsers/internal/config"
+	"osquery-extension-browsers/internal/events"
+	"osquery-extension-browsers/internal/logging"
+	"osquery-extension-browsers/internal/redaction"
+	"osquery-extension-browsers/internal/tables"
 )
 
-var debugMode bool
-
 func main() {
-	// Setup logging to both stdout and file
-	logFile, err := os.OpenFile("/tmp/browser_extend_extension.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err == nil {
-		defer logFile.Close()
-		log.SetOutput(io.MultiWriter(os.Stdout, logFile))
-	}
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	defer closeLogOutput()
 
 	socket := flag.String("socket", "", "Path to osquery socket file")
 	timeout := flag.Int("timeout", 60, "Seconds to wait for autoloaded extensions")
@@ -36,23 +39,54 @@ func main() {
 	retryDelay := flag.Int("retry-delay", 2, "Delay in seconds between retry attempts")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging (osquery compatibility)")
 	debug := flag.Bool("debug", false, "Enable debug logging")
+	configPath := flag.String("config", "", "Path to a YAML or JSON configuration file, reloaded on SIGHUP")
+	stateDir := flag.String("state-dir", defaultStateDir(), "Directory for persistent state such as history cursors")
+	enableEvents := flag.Bool("enable-events", false, "Watch history databases and buffer new visits for browser_history_events")
+	eventsExpiry := flag.Int("events-expiry", 3600, "Seconds to keep buffered history events")
+	eventsMax := flag.Int("events-max", 50000, "Maximum number of buffered history events")
+	eventsPoll := flag.Int("events-poll", 10, "Seconds between polls when native file watching is unavailable")
+	readFile := flag.String("read-file", "", "Copy a file to stdout; used internally when dropping privileges")
 	flag.Parse()
 
-	debugMode = *debug
+	// The extension re-executes itself as another user to read that user's files
+	if *readFile != "" {
+		os.Exit(common.RunReadHelper(*readFile, os.Stdout, os.Stderr))
+	}
 
-	if debugMode {
-		log.Println("=== Extension Starting (Debug Mode) ===")
-		log.Printf("Configuration: socket=%s, timeout=%d, interval=%d, retry=%d, retry-delay=%d, verbose=%v, debug=%v",
-			*socket, *timeout, *interval, *retryAttempts, *retryDelay, *verbose, *debug)
+	// Flags provide the values for settings a configuration file leaves unset
+	defaults := config.Config{
+		StateDir: *stateDir,
+		Events: config.Events{
+			Enabled:      *enableEvents,
+			Expiry:       time.Duration(*eventsExpiry) * time.Second,
+			MaxEvents:    *eventsMax,
+			PollInterval: time.Duration(*eventsPoll) * time.Second,
+		},
+	}
+	cfg, err := loadConfig(*configPath, defaults)
+	if err != nil {
+		fatal("Failed to load configuration", "error", err)
+	}
+	if err := applyConfig(cfg, *debug); err != nil {
+		fatal("Failed to apply configuration", "error", err)
 	}
 
+	slog.Debug("Extension starting",
+		"socket", *socket, "timeout", *timeout, "interval", *interval, "retry", *retryAttempts,
+		"retry_delay", *retryDelay, "verbose", *verbose, "debug", *debug, "config", *configPath,
+		"state_dir", cfg.StateDir)
+
 	if *socket == "" {
-		log.Fatalf("Missing required --socket argument")
+		fatal("Missing required --socket argument")
+	}
+
+	if runtime.GOOS != "windows" && os.Geteuid() != 0 {
+		slog.Info("Running without root; profiles of other users are reported as permission_denied in browser_extension_status")
 	}
 
 	// Wait for socket to be available with retry logic
 	if err := waitForSocket(*socket, *retryAttempts, *retryDelay); err != nil {
-		log.Fatalf("Socket not available after retries: %v", err)
+		fatal("Socket not available after retries", "error", err)
 	}
 
 	serverTimeout := osquery.ServerTimeout(time.Duration(*timeout) * time.Second)
@@ -61,7 +95,7 @@ func main() {
 	// Retry extension server creation
 	var server *osquery.ExtensionManagerServer
 	for attempt := 1; attempt <= *retryAttempts; attempt++ {
-		debugLog("Attempt %d/%d: Creating extension manager server...", attempt, *retryAttempts)
+		slog.Debug("Creating extension manager server", "attempt", attempt, "max_attempts", *retryAttempts)
 		server, err = osquery.NewExtensionManagerServer(
 			"browser_extend_extension",
 			*socket,
@@ -70,137 +104,291 @@ func main() {
 			osquery.ExtensionVersion("1.0.0"),
 		)
 		if err == nil {
-			debugLog("✓ Extension manager server created successfully")
+			slog.Debug("Extension manager server created")
 			break
 		}
-		log.Printf("Failed to create extension (attempt %d/%d): %v", attempt, *retryAttempts, err)
+		slog.Warn("Failed to create extension", "attempt", attempt, "max_attempts", *retryAttempts, "error", err)
 		if attempt < *retryAttempts {
 			time.Sleep(time.Duration(*retryDelay) * time.Second)
 		}
 	}
 	if err != nil {
-		log.Fatalf("Failed to create extension after %d attempts: %v", *retryAttempts, err)
+		fatal("Failed to create extension", "attempts", *retryAttempts, "error", err)
+	}
+
+	// History cursors are optional; without a state directory only the
+	// differential queries fail
+	cursors, err := common.NewCursorStore(cfg.StateDir)
+	if err != nil {
+		slog.Warn("History cursors disabled", "error", err)
 	}
 
-	debugLog("Registering browser history table plugin...")
-	browserHistoryTable := browserHistoryTablePlugin()
-	server.RegisterPlugin(browserHistoryTable)
-	debugLog("✓ Plugin registered successfully")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buffer := events.NewBuffer(cfg.Events.Expiry, cfg.Events.MaxEvents)
+	if cfg.Events.Enabled {
+		slog.Debug("Starting history event collector")
+		watcher := events.NewWatcher(cfg.Events.PollInterval)
+		collector := events.NewCollector(tables.HistorySources(), buffer, watcher, events.DefaultOptions())
+		go collector.Run(ctx)
+	}
+
+	slog.Debug("Registering browser table plugins")
+	for _, plugin := range tables.Plugins(tables.Dependencies{Cursors: cursors, Events: buffer}) {
+		server.RegisterPlugin(plugin)
+	}
+	slog.Debug("Plugins registered")
 
 	// Setup signal handling
 	sigc := make(chan os.Signal, 1)
-	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		<-sigc
-		log.Println("Received shutdown signal, cleaning up...")
-		server.Shutdown(context.Background())
-		os.Exit(0)
+		for sig := range sigc {
+			if sig == syscall.SIGHUP {
+				reloadConfig(*configPath, defaults, cfg, *debug)
+				continue
+			}
+			slog.Info("Received shutdown signal, cleaning up")
+			cancel()
+			server.Shutdown(context.Background())
+			os.Exit(0)
+		}
 	}()
 
-	debugLog("Starting extension server (this will block)...")
+	slog.Debug("Starting extension server")
 	if err := server.Run(); err != nil {
-		log.Fatalf("Failed to run extension: %v", err)
+		fatal("Failed to run extension", "error", err)
 	}
-	debugLog("Extension server stopped")
+	slog.Debug("Extension server stopped")
 }
 
-// debugLog logs a message only when debug mode is enabled
-func debugLog(format string, v ...interface{}) {
-	if debugMode {
-		log.Printf(format, v...)
+// loadConfig reads the configuration file, if any, and fills settings it
+// leaves unset from defaults
+func loadConfig(path string, defaults config.Config) (*config.Config, error) {
+	cfg := &config.Config{}
+	if path != "" {
+		loaded, err := config.Load(path, tables.Names())
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	if cfg.StateDir == "" {
+		cfg.StateDir = defaults.StateDir
+	}
+	if cfg.IOCDir == "" && path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			cfg.IOCDir = filepath.Join(filepath.Dir(abs), "ioc")
+		}
+	}
+	if !cfg.Events.Enabled {
+		cfg.Events.Enabled = defaults.Events.Enabled
+	}
+	if cfg.Events.Expiry == 0 {
+		cfg.Events.Expiry = defaults.Events.Expiry
 	}
+	if cfg.Events.MaxEvents == 0 {
+		cfg.Events.MaxEvents = defaults.Events.MaxEvents
+	}
+	if cfg.Events.PollInterval == 0 {
+		cfg.Events.PollInterval = defaults.Events.PollInterval
+	}
+
+	return cfg, nil
 }
 
-// waitForSocket waits for the osquery socket to be available
-func waitForSocket(socketPath string, maxAttempts, delaySeconds int) error {
-	debugLog("Waiting for socket: %s", socketPath)
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		// Check if socket file exists
-		if _, err := os.Stat(socketPath); err == nil {
-			debugLog("✓ Socket found on attempt %d/%d", attempt, maxAttempts)
-			return nil
+// applyConfig applies the settings that can change while the extension runs.
+// Every setting is validated before any is applied, so an error leaves the
+// running configuration untouched.
+func applyConfig(cfg *config.Config, debugFlag bool) error {
+	logConfig := logging.Config{
+		Destination: cfg.Log.Destination,
+		Format:      cfg.Log.Format,
+		Level:       cfg.Log.Level,
+		MaxSizeMB:   cfg.Log.MaxSizeMB,
+		MaxBackups:  cfg.Log.MaxBackups,
+	}
+	if logConfig.Level == "" && debugFlag {
+		logConfig.Level = "debug"
+	}
+
+	policy := redaction.Policy{
+		Mode:         cfg.Redaction.Mode,
+		DropTitles:   cfg.Redaction.DropTitles,
+		AllowDomains: cfg.Redaction.AllowDomains,
+		DenyDomains:  cfg.Redaction.DenyDomains,
+		Values:       cfg.Redaction.FormValues,
+	}
+	if cfg.Redaction.KeyFile != "" {
+		key, err := os.ReadFile(cfg.Redaction.KeyFile)
+		if err != nil {
+			return fmt.Errorf("reading redaction key: %w", err)
 		}
-		debugLog("Socket not found (attempt %d/%d), waiting %d seconds...", attempt, maxAttempts, delaySeconds)
-		if attempt < maxAttempts {
-			time.Sleep(time.Duration(delaySeconds) * time.Second)
+		policy.Key = bytes.TrimSpace(key)
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	displayLocation, err := time.LoadLocation(cfg.DisplayTimezone)
+	if err != nil {
+		return fmt.Errorf("display_timezone: %w", err)
+	}
+
+	access := common.AccessOptions{
+		SnapshotDir:    cfg.Access.SnapshotDir,
+		DropPrivileges: cfg.Access.DropPrivileges,
+		AllowedPaths:   cfg.Access.AllowedPaths,
+	}
+	if access.DropPrivileges {
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locating executable for privilege dropping: %w", err)
+		}
+		access.Helper = []string{executable, "--read-file"}
+	}
+
+	var chromiumPaths, firefoxPaths []common.CustomPath
+	for _, custom := range cfg.CustomPaths {
+		path := common.CustomPath{Path: custom.Path, Browser: custom.Browser}
+		switch custom.Engine {
+		case "chromium":
+			chromiumPaths = append(chromiumPaths, path)
+		case "firefox":
+			firefoxPaths = append(firefoxPaths, path)
 		}
 	}
-	return fmt.Errorf("socket %s not found after %d attempts", socketPath, maxAttempts)
-}
 
-// browserHistoryTablePlugin creates a table plugin for browser history
-func browserHistoryTablePlugin() *table.Plugin {
-	columns := []table.ColumnDefinition{
-		table.TextColumn("time"),
-		table.TextColumn("title"),
-		table.TextColumn("url"),
-		table.TextColumn("profile"),
-		table.TextColumn("browser_type"),
+	// Logging is the last setting that can fail, so it is switched first
+	if err := configureLogging(logConfig); err != nil {
+		return err
 	}
 
-	return table.NewPlugin("browser_history", columns, generateBrowserHistory)
+	tables.Configure(tables.Settings{
+		Tables:            cfg.Tables,
+		Browsers:          cfg.Browsers,
+		MaxRows:           cfg.MaxRows,
+		CacheTTL:          cfg.CacheTTL,
+		Redaction:         policy,
+		IOCDir:            cfg.IOCDir,
+		SessionGap:        cfg.Sessions.InactivityGap,
+		AutofillAddresses: cfg.Autofill.Addresses,
+		Carving:           cfg.Carving.Enabled,
+		DisplayLocation:   displayLocation,
+	})
+
+	common.SetUserFilter(common.UserFilter{
+		Include:       cfg.Users.Include,
+		Exclude:       cfg.Users.Exclude,
+		MinUID:        cfg.Users.MinUID,
+		MaxUID:        cfg.Users.MaxUID,
+		SkipLoginDefs: cfg.Users.LoginDefs != nil && !*cfg.Users.LoginDefs,
+		Sources:       cfg.Users.Sources,
+		HomeParents:   cfg.Users.HomeParents,
+	})
+
+	chromium.SetCustomPaths(chromiumPaths)
+	firefox.SetCustomPaths(firefoxPaths)
+	common.SetAccessOptions(access)
+
+	return nil
 }
 
-// generateBrowserHistory generates the browser history data for the table
-func generateBrowserHistory(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	var results []map[string]string
+// reloadConfig re-reads the configuration file and applies it. An invalid
+// file is reported and the running configuration is kept.
+func reloadConfig(path string, defaults config.Config, running *config.Config, debugFlag bool) {
+	if path == "" {
+		slog.Info("Received SIGHUP but no --config file is set; nothing to reload")
+		return
+	}
 
-	// Find Chromium profiles
-	chromiumProfiles, err := chromium.FindProfiles()
+	cfg, err := loadConfig(path, defaults)
 	if err != nil {
-		log.Printf("Failed to find Chromium profiles: %v", err)
-	} else {
-		// Get history for each Chromium profile
-		for _, profile := range chromiumProfiles {
-			historyEntries, err := chromium.FindHistory(profile)
-			if err != nil {
-				log.Printf("Failed to find Chromium history for profile %s: %v", profile.ID, err)
-				continue
-			}
+		slog.Error("Configuration reload failed, keeping previous configuration", "error", err)
+		return
+	}
+	if err := applyConfig(cfg, debugFlag); err != nil {
+		slog.Error("Configuration reload failed, keeping previous configuration", "error", err)
+		return
+	}
 
-			// Add history entries to results
-			for _, entry := range historyEntries {
-				results = append(results, map[string]string{
-					"time":            entry.VisitTime.Format("2006-01-02 15:04:05"),
-					"url":             entry.URL,
-					"title":           entry.Title,
-					"visit_count":     string(rune(entry.VisitCount)),
-					"profile":         entry.ProfileID,
-					"browser_type":    entry.BrowserType,
-					"browser_variant": entry.BrowserVariant,
-				})
-			}
-		}
+	// state_dir and events keep their running values until a restart
+	if cfg.StateDir != running.StateDir || !reflect.DeepEqual(cfg.Events, running.Events) {
+		slog.Warn("state_dir and events changes take effect after a restart")
 	}
+	cfg.StateDir, cfg.Events = running.StateDir, running.Events
+	*running = *cfg
+	slog.Info("Configuration reloaded", "config", path)
+}
 
-	// Find Firefox profiles
-	firefoxProfiles, err := firefox.FindProfiles()
+var (
+	logMu     sync.Mutex
+	logOutput io.Closer
+)
+
+// configureLogging installs the logger for cfg and releases the output of
+// the previous one
+func configureLogging(cfg logging.Config) error {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	closer, err := logging.Configure(cfg)
 	if err != nil {
-		log.Printf("Failed to find Firefox profiles: %v", err)
-	} else {
-		// Get history for each Firefox profile
-		for _, profile := range firefoxProfiles {
-			historyEntries, err := firefox.FindHistory(profile)
-			if err != nil {
-				log.Printf("Failed to find Firefox history for profile %s: %v", profile.ID, err)
-				continue
-			}
+		return fmt.Errorf("configuring logging: %w", err)
+	}
 
-			// Add history entries to results
-			for _, entry := range historyEntries {
-				results = append(results, map[string]string{
-					"time":            entry.VisitTime.Format("2006-01-02 15:04:05"),
-					"url":             entry.URL,
-					"title":           entry.Title,
-					"visit_count":     string(rune(entry.VisitCount)),
-					"profile":         entry.ProfileID,
-					"browser_type":    entry.BrowserType,
-					"browser_variant": entry.BrowserVariant,
-				})
-			}
+	if logOutput != nil {
+		logOutput.Close()
+	}
+	logOutput = closer
+	return nil
+}
+
+// closeLogOutput closes the current log output, if the logger owns it
+func closeLogOutput() {
+	logMu.Lock()
+	defer logMu.Unlock()
+	if logOutput != nil {
+		logOutput.Close()
+		logOutput = nil
+	}
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	closeLogOutput()
+	os.Exit(1)
+}
+
+// waitForSocket waits for the osquery socket to be available
+func waitForSocket(socketPath string, maxAttempts, delaySeconds int) error {
+	slog.Debug("Waiting for socket", "socket", socketPath)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// Check if socket file exists
+		if _, err := os.Stat(socketPath); err == nil {
+			slog.Debug("Socket found", "attempt", attempt, "max_attempts", maxAttempts)
+			return nil
+		}
+		slog.Debug("Socket not found, waiting", "attempt", attempt, "max_attempts", maxAttempts, "delay_seconds", delaySeconds)
+		if attempt < maxAttempts {
+			time.Sleep(time.Duration(delaySeconds) * time.Second)
 		}
 	}
+	return fmt.Errorf("socket %s not found after %d attempts", socketPath, maxAttempts)
+}
 
-	return results, nil
+// defaultStateDir returns the platform default directory for persistent state
+func defaultStateDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("ProgramData"), "browser_extend_extension")
+	case "darwin":
+		return filepath.Join("/Library", "Application Support", "browser_extend_extension")
+	default:
+		return filepath.Join("/var", "lib", "browser_extend_extension")
+	}
 }
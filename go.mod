@@ -5,6 +5,8 @@ go 1.22
 require (
 	github.com/osquery/osquery-go v0.0.0-20250131154556-629f995b6947
 	github.com/shirou/gopsutil/v3 v3.24.5
+	golang.org/x/net v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,5 +27,5 @@ require (
 	go.opentelemetry.io/otel v1.16.0 // indirect
 	go.opentelemetry.io/otel/metric v1.16.0 // indirect
 	go.opentelemetry.io/otel/trace v1.16.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
+	golang.org/x/sys v0.25.0
 )
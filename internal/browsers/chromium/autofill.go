@@ -0,0 +1,221 @@
+package chromium
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// webDataFile is the profile database holding autofill data
+const webDataFile = "Web Data"
+
+// addressTables are the tables holding saved addresses, newest schema first
+var addressTables = []string{"local_addresses", "autofill_profiles"}
+
+// webDataTables are the Web Data tables autofill entries are read from
+var webDataTables = append([]string{"autofill", "credit_cards", "masked_credit_cards"}, addressTables...)
+
+// FindAutofill reads the form history, saved card metadata and, if
+// requested, saved address metadata of a profile. A profile without a Web
+// Data database has no autofill entries. Columns a version lacks are read as
+// empty, like the history query's optional columns.
+func FindAutofill(profile common.Profile, opts common.AutofillOptions) ([]common.AutofillEntry, error) {
+	webDataPath := filepath.Join(profile.Path, webDataFile)
+	if _, err := os.Stat(webDataPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(webDataPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Web Data keeps its version in a meta table like History
+	schema, err := common.ReadSchema(db, historySchemaQuery, webDataTables...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := formEntries(db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := cardEntries(db, schema)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, cards...)
+
+	if opts.Addresses {
+		addresses, err := addressEntries(db, schema)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, addresses...)
+	}
+	for i := range entries {
+		entries[i].Source = webDataFile
+	}
+	return entries, nil
+}
+
+// formEntries reads the values typed into form fields. Chromium stores
+// their times in seconds since the Unix epoch; versions before 2014 kept
+// them in a separate autofill_dates table and are read without times.
+func formEntries(db *common.Database, schema common.Schema) ([]common.AutofillEntry, error) {
+	if schema.Tables["autofill"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("autofill.name", "autofill.value"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT name, value,
+			` + schema.ColumnOr("autofill", "date_created", "COALESCE(date_created, 0)", "0") + `,
+			` + schema.ColumnOr("autofill", "date_last_used", "COALESCE(date_last_used, 0)", "0") + `,
+			` + schema.ColumnOr("autofill", "count", "COALESCE(count, 0)", "0") + `
+		FROM autofill
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []common.AutofillEntry
+	for rows.Next() {
+		var name, value string
+		var created, lastUsed int64
+		var count int
+		if err := rows.Scan(&name, &value, &created, &lastUsed, &count); err != nil {
+			return nil, err
+		}
+		entries = append(entries, common.AutofillEntry{
+			Kind:      common.AutofillForm,
+			FieldName: name,
+			Value:     value,
+			Created:   common.FromUnixSeconds(created),
+			LastUsed:  common.FromUnixSeconds(lastUsed),
+			TimesUsed: count,
+		})
+	}
+	return entries, rows.Err()
+}
+
+// cardEntries reads the existence and expiry of saved cards. Locally saved
+// cards do not record their network; cards synced from the payments server
+// do. Card numbers, including their last digits, are never read.
+func cardEntries(db *common.Database, schema common.Schema) ([]common.AutofillEntry, error) {
+	var entries []common.AutofillEntry
+
+	if schema.Tables["credit_cards"] != nil {
+		rows, err := db.Query(`
+			SELECT ` + schema.ColumnOr("credit_cards", "expiration_month", "COALESCE(expiration_month, 0)", "0") + `,
+				` + schema.ColumnOr("credit_cards", "expiration_year", "COALESCE(expiration_year, 0)", "0") + `,
+				` + schema.ColumnOr("credit_cards", "use_count", "COALESCE(use_count, 0)", "0") + `,
+				` + schema.ColumnOr("credit_cards", "use_date", "COALESCE(use_date, 0)", "0") + `
+			FROM credit_cards
+		`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var month, year, useCount int
+			var useDate int64
+			if err := rows.Scan(&month, &year, &useCount, &useDate); err != nil {
+				return nil, err
+			}
+			entries = append(entries, common.AutofillEntry{
+				Kind:           common.AutofillCreditCard,
+				LastUsed:       common.FromUnixSeconds(useDate),
+				TimesUsed:      useCount,
+				CardExpiration: cardExpiration(month, year),
+			})
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if schema.Tables["masked_credit_cards"] != nil {
+		rows, err := db.Query(`
+			SELECT ` + schema.ColumnOr("masked_credit_cards", "network", "COALESCE(network, '')", "''") + `,
+				` + schema.ColumnOr("masked_credit_cards", "exp_month", "COALESCE(exp_month, 0)", "0") + `,
+				` + schema.ColumnOr("masked_credit_cards", "exp_year", "COALESCE(exp_year, 0)", "0") + `
+			FROM masked_credit_cards
+		`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var network string
+			var month, year int
+			if err := rows.Scan(&network, &month, &year); err != nil {
+				return nil, err
+			}
+			entries = append(entries, common.AutofillEntry{
+				Kind:           common.AutofillCreditCard,
+				CardNetwork:    strings.ToLower(network),
+				CardExpiration: cardExpiration(month, year),
+			})
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// addressEntries reads how often and when saved addresses were used, without
+// any part of the address itself
+func addressEntries(db *common.Database, schema common.Schema) ([]common.AutofillEntry, error) {
+	for _, table := range addressTables {
+		if schema.Tables[table] == nil {
+			continue
+		}
+
+		rows, err := db.Query(`
+			SELECT ` + schema.ColumnOr(table, "use_count", "COALESCE(use_count, 0)", "0") + `,
+				` + schema.ColumnOr(table, "use_date", "COALESCE(use_date, 0)", "0") + `
+			FROM ` + table)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var entries []common.AutofillEntry
+		for rows.Next() {
+			var useCount int
+			var useDate int64
+			if err := rows.Scan(&useCount, &useDate); err != nil {
+				return nil, err
+			}
+			entries = append(entries, common.AutofillEntry{
+				Kind:      common.AutofillAddress,
+				LastUsed:  common.FromUnixSeconds(useDate),
+				TimesUsed: useCount,
+			})
+		}
+		return entries, rows.Err()
+	}
+	return nil, nil
+}
+
+// cardExpiration formats a card's expiry as YYYY-MM, or returns the empty
+// string if it is not recorded
+func cardExpiration(month, year int) string {
+	if month == 0 || year == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d", year, month)
+}
@@ -0,0 +1,111 @@
+package chromium
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// createWebDataDB creates a Chromium Web Data database in dir with one form
+// value, one local and one server card, and one address
+func createWebDataDB(t *testing.T, dir string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, webDataFile))
+	if err != nil {
+		t.Fatalf("Failed to create Web Data database: %v", err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE autofill (name VARCHAR, value VARCHAR, value_lower VARCHAR, date_created INTEGER DEFAULT 0,
+			date_last_used INTEGER DEFAULT 0, count INTEGER DEFAULT 1, PRIMARY KEY (name, value))`,
+		`CREATE TABLE credit_cards (guid VARCHAR PRIMARY KEY, name_on_card VARCHAR, expiration_month INTEGER,
+			expiration_year INTEGER, card_number_encrypted BLOB, date_modified INTEGER NOT NULL DEFAULT 0,
+			use_count INTEGER NOT NULL DEFAULT 0, use_date INTEGER NOT NULL DEFAULT 0)`,
+		`CREATE TABLE masked_credit_cards (id VARCHAR, status VARCHAR, name_on_card VARCHAR, network VARCHAR,
+			last_four VARCHAR, exp_month INTEGER DEFAULT 0, exp_year INTEGER DEFAULT 0)`,
+		`CREATE TABLE local_addresses (guid VARCHAR PRIMARY KEY, use_count INTEGER NOT NULL DEFAULT 0,
+			use_date INTEGER NOT NULL DEFAULT 0, date_modified INTEGER NOT NULL DEFAULT 0)`,
+		`INSERT INTO autofill VALUES ('employee_id', 'E12345', 'e12345', 1700000000, 1700003600, 4)`,
+		`INSERT INTO credit_cards VALUES ('a', 'Alice', 4, 2027, x'00', 0, 2, 1700000000)`,
+		`INSERT INTO masked_credit_cards VALUES ('b', 'FULL', 'Alice', 'VISA', '4242', 12, 2026)`,
+		`INSERT INTO local_addresses VALUES ('c', 7, 1700000000, 0)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up Web Data database: %v", err)
+		}
+	}
+}
+
+func TestFindAutofill(t *testing.T) {
+	dir := t.TempDir()
+	createWebDataDB(t, dir)
+	profile := common.Profile{ID: "Default", Path: dir, BrowserVariant: "chrome"}
+
+	t.Run("forms_and_cards", func(t *testing.T) {
+		entries, err := FindAutofill(profile, common.AutofillOptions{})
+		if err != nil {
+			t.Fatalf("FindAutofill() returned error: %v", err)
+		}
+		if len(entries) != 3 {
+			t.Fatalf("Expected a form value and two cards, got %+v", entries)
+		}
+
+		form := entries[0]
+		if form.Kind != common.AutofillForm || form.FieldName != "employee_id" || form.Value != "E12345" || form.TimesUsed != 4 {
+			t.Errorf("Unexpected form entry: %+v", form)
+		}
+		if form.Created.Unix() != 1700000000 || form.LastUsed.Unix() != 1700003600 {
+			t.Errorf("Unexpected form times: %v, %v", form.Created, form.LastUsed)
+		}
+
+		local, server := entries[1], entries[2]
+		if local.Kind != common.AutofillCreditCard || local.CardExpiration != "2027-04" || local.CardNetwork != "" || local.TimesUsed != 2 {
+			t.Errorf("Unexpected local card: %+v", local)
+		}
+		if server.CardNetwork != "visa" || server.CardExpiration != "2026-12" || server.Value != "" {
+			t.Errorf("Unexpected server card: %+v", server)
+		}
+	})
+
+	t.Run("addresses", func(t *testing.T) {
+		entries, err := FindAutofill(profile, common.AutofillOptions{Addresses: true})
+		if err != nil {
+			t.Fatalf("FindAutofill() returned error: %v", err)
+		}
+		address := entries[len(entries)-1]
+		if address.Kind != common.AutofillAddress || address.TimesUsed != 7 || address.Value != "" {
+			t.Errorf("Unexpected address entry: %+v", address)
+		}
+	})
+
+	t.Run("missing_database", func(t *testing.T) {
+		entries, err := FindAutofill(common.Profile{Path: t.TempDir()}, common.AutofillOptions{})
+		if err != nil || len(entries) != 0 {
+			t.Errorf("Expected no entries and no error, got %v, %v", entries, err)
+		}
+	})
+
+	t.Run("without_dates", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, webDataFile,
+			`CREATE TABLE autofill (name VARCHAR, value VARCHAR, value_lower VARCHAR, pair_id INTEGER PRIMARY KEY, count INTEGER DEFAULT 1)`,
+			`CREATE TABLE credit_cards (guid VARCHAR PRIMARY KEY, expiration_month INTEGER, expiration_year INTEGER)`,
+			`INSERT INTO autofill VALUES ('q', 'report', 'report', 1, 2)`,
+			`INSERT INTO credit_cards VALUES ('a', 4, 2027)`,
+		)
+
+		entries, err := FindAutofill(common.Profile{Path: dir}, common.AutofillOptions{})
+		if err != nil {
+			t.Fatalf("FindAutofill() returned error: %v", err)
+		}
+		if len(entries) != 2 || entries[0].FieldName != "q" || entries[0].TimesUsed != 2 || !entries[0].LastUsed.IsZero() ||
+			entries[1].CardExpiration != "2027-04" || entries[1].TimesUsed != 0 {
+			t.Errorf("FindAutofill() = %+v, want a form value and a card without use times", entries)
+		}
+	})
+}
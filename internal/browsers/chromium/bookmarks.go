@@ -0,0 +1,82 @@
+package chromium
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// bookmarksFile is the JSON file holding a profile's bookmarks
+const bookmarksFile = "Bookmarks"
+
+// bookmarkNode is a bookmark or folder in the Bookmarks file
+type bookmarkNode struct {
+	Type      string         `json:"type"`
+	Name      string         `json:"name"`
+	URL       string         `json:"url"`
+	DateAdded string         `json:"date_added"`
+	Children  []bookmarkNode `json:"children"`
+}
+
+// FindBookmarks reads the bookmarks of every root folder of the Bookmarks
+// file. A profile without the file has no bookmarks.
+func FindBookmarks(profile common.Profile) ([]common.Bookmark, error) {
+	path := filepath.Join(profile.Path, bookmarksFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := common.ReadProfileFile(path, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Roots map[string]json.RawMessage `json:"roots"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	// Roots also holds non-folder entries such as sync_transaction_version
+	names := make([]string, 0, len(file.Roots))
+	for name := range file.Roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var bookmarks []common.Bookmark
+	for _, name := range names {
+		var root bookmarkNode
+		if err := json.Unmarshal(file.Roots[name], &root); err != nil || root.Type != "folder" {
+			continue
+		}
+		bookmarks = root.collect("", bookmarks)
+	}
+	return bookmarks, nil
+}
+
+// collect appends the bookmarks within the node, whose parent folders are
+// named by folder
+func (n bookmarkNode) collect(folder string, bookmarks []common.Bookmark) []common.Bookmark {
+	if n.Type == "url" {
+		return append(bookmarks, common.Bookmark{
+			URL:    n.URL,
+			Title:  n.Name,
+			Folder: folder,
+			Added:  webKitString(n.DateAdded),
+			Source: bookmarksFile,
+		})
+	}
+
+	if folder != "" {
+		folder += "/"
+	}
+	folder += n.Name
+	for _, child := range n.Children {
+		bookmarks = child.collect(folder, bookmarks)
+	}
+	return bookmarks
+}
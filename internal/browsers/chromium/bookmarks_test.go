@@ -0,0 +1,54 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	data := `{
+		"roots": {
+			"bookmark_bar": {"type": "folder", "name": "Bookmarks bar", "children": [
+				{"type": "url", "name": "Mail", "url": "https://mail.example.com/", "date_added": "13345000000000000"},
+				{"type": "folder", "name": "Work", "children": [
+					{"type": "url", "name": "Payroll", "url": "https://payroll.example.com/", "date_added": "13346000000000000"}
+				]}
+			]},
+			"other": {"type": "folder", "name": "Other bookmarks", "children": []},
+			"sync_transaction_version": "7"
+		}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, bookmarksFile), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := FindBookmarks(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindBookmarks() returned error: %v", err)
+	}
+	want := []common.Bookmark{
+		{URL: "https://mail.example.com/", Title: "Mail", Folder: "Bookmarks bar",
+			Added: common.FromWebKit(13345000000000000), Source: bookmarksFile},
+		{URL: "https://payroll.example.com/", Title: "Payroll", Folder: "Bookmarks bar/Work",
+			Added: common.FromWebKit(13346000000000000), Source: bookmarksFile},
+	}
+	if len(bookmarks) != len(want) {
+		t.Fatalf("FindBookmarks() = %+v, want %+v", bookmarks, want)
+	}
+	for i := range want {
+		if bookmarks[i] != want[i] {
+			t.Errorf("bookmarks[%d] = %+v, want %+v", i, bookmarks[i], want[i])
+		}
+	}
+
+	t.Run("missing_file", func(t *testing.T) {
+		bookmarks, err := FindBookmarks(common.Profile{Path: t.TempDir()})
+		if err != nil || len(bookmarks) != 0 {
+			t.Errorf("FindBookmarks() = %+v, %v; want no bookmarks", bookmarks, err)
+		}
+	})
+}
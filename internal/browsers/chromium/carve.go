@@ -0,0 +1,35 @@
+package chromium
+
+import (
+	"os"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// historyURLs locates the columns of the History urls table
+var historyURLs = common.URLTable{
+	Table:      "urls",
+	URL:        "url",
+	Title:      "title",
+	VisitCount: "visit_count",
+	LastVisit:  "last_visit_time",
+	Time:       common.FromWebKit,
+}
+
+// CarveHistory recovers deleted urls records from the freelist pages,
+// freeblocks and unallocated space of a profile's History database. Pages
+// still held in History-wal are not carved. A profile without History has
+// nothing to recover.
+func CarveHistory(profile common.Profile) ([]common.CarvedEntry, error) {
+	historyDBPath := getHistoryDBPath(profile.Path)
+	if _, err := os.Stat(historyDBPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(historyDBPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return common.CarveURLs(db, historyURLs)
+}
@@ -0,0 +1,58 @@
+package chromium
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestCarveHistory(t *testing.T) {
+	dir := t.TempDir()
+	statements := []string{
+		`PRAGMA secure_delete = OFF`,
+		`CREATE TABLE urls (id INTEGER PRIMARY KEY AUTOINCREMENT, url LONGVARCHAR, title LONGVARCHAR,
+			visit_count INTEGER DEFAULT 0 NOT NULL, typed_count INTEGER DEFAULT 0 NOT NULL,
+			last_visit_time INTEGER NOT NULL, hidden INTEGER DEFAULT 0 NOT NULL)`,
+	}
+	for i := 1; i <= 120; i++ {
+		statements = append(statements, fmt.Sprintf(
+			`INSERT INTO urls (url, title, visit_count, last_visit_time) VALUES ('https://host%d.example.com/%s', 'Title %d', 2, 13345000000000000)`,
+			i, strings.Repeat("p", 60), i))
+	}
+	statements = append(statements, `DELETE FROM urls WHERE id > 3`)
+	createProfileDB(t, dir, "History", statements...)
+
+	entries, err := CarveHistory(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("CarveHistory() returned error: %v", err)
+	}
+	if len(entries) < 100 {
+		t.Fatalf("Recovered %d of 117 deleted urls", len(entries))
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.URL == "https://host1.example.com/"+strings.Repeat("p", 60) {
+			t.Errorf("Carved a URL still in history: %+v", entry)
+		}
+		if seen[entry.URL] {
+			t.Errorf("Carved %s more than once", entry.URL)
+		}
+		seen[entry.URL] = true
+		if !strings.HasPrefix(entry.Title, "Title ") || entry.VisitCount != 2 || !entry.LastVisit.Equal(common.FromWebKit(13345000000000000)) {
+			t.Errorf("Unexpected carved entry: %+v", entry)
+		}
+		if entry.Confidence <= 0 || entry.Confidence > 1 || entry.Region == "" || entry.Page == 0 {
+			t.Errorf("Unexpected carve location: %+v", entry)
+		}
+	}
+
+	t.Run("missing_history", func(t *testing.T) {
+		entries, err := CarveHistory(common.Profile{Path: t.TempDir()})
+		if err != nil || len(entries) != 0 {
+			t.Errorf("CarveHistory() = %+v, %v; want nothing", entries, err)
+		}
+	})
+}
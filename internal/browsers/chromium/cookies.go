@@ -0,0 +1,71 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// cookiesFiles are the profile databases holding cookies, newest location
+// first
+var cookiesFiles = []string{filepath.Join("Network", "Cookies"), "Cookies"}
+
+// FindCookies reads where and when the cookies of a profile were set. Cookie
+// values are never read. A profile without a Cookies database has no
+// cookies.
+func FindCookies(profile common.Profile) ([]common.Cookie, error) {
+	for _, file := range cookiesFiles {
+		path := filepath.Join(profile.Path, file)
+		if _, err := os.Stat(path); err == nil {
+			return readCookies(path, file, profile.UID)
+		}
+	}
+	return nil, nil
+}
+
+// readCookies reads the cookies table of the database at path. The Cookies
+// database keeps its version in a meta table like History.
+func readCookies(path, file, uid string) ([]common.Cookie, error) {
+	db, err := common.OpenDatabase(path, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "cookies")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["cookies"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("cookies.creation_utc", "cookies.host_key", "cookies.name"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT host_key, name, ` + schema.ColumnOr("cookies", "path", "COALESCE(path, '')", "''") + `,
+			creation_utc, ` + schema.ColumnOr("cookies", "last_access_utc", "COALESCE(last_access_utc, 0)", "0") + `
+		FROM cookies
+		ORDER BY creation_utc
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cookies []common.Cookie
+	for rows.Next() {
+		var cookie common.Cookie
+		var created, accessed int64
+		if err := rows.Scan(&cookie.Host, &cookie.Name, &cookie.Path, &created, &accessed); err != nil {
+			return nil, err
+		}
+		cookie.Created = common.FromWebKit(created)
+		cookie.LastAccessed = common.FromWebKit(accessed)
+		cookie.Source = file
+		cookies = append(cookies, cookie)
+	}
+	return cookies, rows.Err()
+}
@@ -0,0 +1,49 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindCookies(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "Network"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	createProfileDB(t, dir, cookiesFiles[0],
+		`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+		`INSERT INTO meta VALUES ('version', '21')`,
+		`CREATE TABLE cookies (creation_utc INTEGER NOT NULL, host_key TEXT NOT NULL, name TEXT NOT NULL,
+			value TEXT NOT NULL, path TEXT NOT NULL, expires_utc INTEGER NOT NULL, last_access_utc INTEGER NOT NULL,
+			encrypted_value BLOB DEFAULT '')`,
+		`INSERT INTO cookies VALUES (13345000000000000, '.example.com', 'sid', 'secret', '/', 0, 13345000001000000, X'00')`,
+	)
+	// A stale database at the old location is ignored
+	createProfileDB(t, dir, "Cookies", `CREATE TABLE cookies (creation_utc INTEGER, host_key TEXT, name TEXT)`)
+
+	cookies, err := FindCookies(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindCookies() returned error: %v", err)
+	}
+	want := common.Cookie{
+		Host:         ".example.com",
+		Name:         "sid",
+		Path:         "/",
+		Created:      common.FromWebKit(13345000000000000),
+		LastAccessed: common.FromWebKit(13345000001000000),
+		Source:       cookiesFiles[0],
+	}
+	if len(cookies) != 1 || cookies[0] != want {
+		t.Errorf("FindCookies() = %+v, want [%+v]", cookies, want)
+	}
+
+	t.Run("missing_database", func(t *testing.T) {
+		cookies, err := FindCookies(common.Profile{Path: t.TempDir()})
+		if err != nil || len(cookies) != 0 {
+			t.Errorf("FindCookies() = %+v, %v; want no cookies", cookies, err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package chromium
+
+import (
+	"encoding/hex"
+	"os"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// sha256Size is the length of the file digest Chromium stores in
+// downloads.hash
+const sha256Size = 32
+
+// FindDownloads reads the downloads table of the History database. Versions
+// before 24 kept the path in full_path, the URL in the downloads table and
+// times in seconds since the Unix epoch; later versions use target_path, a
+// separate URL chain and WebKit times. A profile without a History database
+// has no downloads.
+func FindDownloads(profile common.Profile) ([]common.Download, error) {
+	historyDBPath := getHistoryDBPath(profile.Path)
+	if _, err := os.Stat(historyDBPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(historyDBPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "downloads", "downloads_url_chains")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["downloads"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("downloads.start_time"); err != nil {
+		return nil, err
+	}
+
+	// The last URL of the chain is the one the file was fetched from
+	url := schema.ColumnOr("downloads", "url", "COALESCE(d.url, '')", "''")
+	if schema.Has("downloads_url_chains", "url") {
+		url = `COALESCE((SELECT c.url FROM downloads_url_chains c WHERE c.id = d.id ORDER BY c.chain_index DESC LIMIT 1), '')`
+	}
+	targetPath := schema.ColumnOr("downloads", "target_path", "d.target_path",
+		schema.ColumnOr("downloads", "full_path", "d.full_path", "''"))
+	webKitTimes := schema.Has("downloads", "target_path")
+
+	rows, err := db.Query(`
+		SELECT ` + url + `, COALESCE(` + targetPath + `, ''),
+			` + schema.ColumnOr("downloads", "referrer", "COALESCE(d.referrer, '')", "''") + `,
+			` + schema.ColumnOr("downloads", "mime_type", "COALESCE(d.mime_type, '')", "''") + `,
+			` + schema.ColumnOr("downloads", "total_bytes", "COALESCE(d.total_bytes, 0)", "0") + `,
+			d.start_time, ` + schema.ColumnOr("downloads", "end_time", "COALESCE(d.end_time, 0)", "0") + `,
+			` + schema.ColumnOr("downloads", "hash", "d.hash", "NULL") + `
+		FROM downloads d
+		ORDER BY d.start_time
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var downloads []common.Download
+	for rows.Next() {
+		var download common.Download
+		var start, end int64
+		var hash []byte
+		if err := rows.Scan(&download.URL, &download.TargetPath, &download.Referrer, &download.MimeType,
+			&download.TotalBytes, &start, &end, &hash); err != nil {
+			return nil, err
+		}
+		if webKitTimes {
+			download.StartTime, download.EndTime = common.FromWebKit(start), common.FromWebKit(end)
+		} else {
+			download.StartTime, download.EndTime = common.FromUnixSeconds(start), common.FromUnixSeconds(end)
+		}
+		if len(hash) == sha256Size {
+			download.SHA256 = hex.EncodeToString(hash)
+		}
+		download.Source = historyFile
+		downloads = append(downloads, download)
+	}
+	return downloads, rows.Err()
+}
@@ -0,0 +1,75 @@
+package chromium
+
+import (
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindDownloads(t *testing.T) {
+	dir := t.TempDir()
+	createProfileDB(t, dir, historyFile,
+		`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+		`INSERT INTO meta VALUES ('version', '56')`,
+		`CREATE TABLE downloads (id INTEGER PRIMARY KEY, current_path LONGVARCHAR, target_path LONGVARCHAR,
+			start_time INTEGER, end_time INTEGER, total_bytes INTEGER, referrer VARCHAR, mime_type VARCHAR, hash BLOB)`,
+		`CREATE TABLE downloads_url_chains (id INTEGER, chain_index INTEGER, url LONGVARCHAR, PRIMARY KEY (id, chain_index))`,
+		`INSERT INTO downloads VALUES (1, '/home/alice/Downloads/tool.crdownload', '/home/alice/Downloads/tool.sh',
+			13345000000000000, 13345000005000000, 2048, 'https://example.com/', 'text/x-sh',
+			X'e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855')`,
+		`INSERT INTO downloads_url_chains VALUES (1, 0, 'https://example.com/get'), (1, 1, 'https://cdn.example.org/tool.sh')`,
+	)
+
+	downloads, err := FindDownloads(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindDownloads() returned error: %v", err)
+	}
+	want := common.Download{
+		URL:        "https://cdn.example.org/tool.sh",
+		Referrer:   "https://example.com/",
+		TargetPath: "/home/alice/Downloads/tool.sh",
+		MimeType:   "text/x-sh",
+		TotalBytes: 2048,
+		StartTime:  common.FromWebKit(13345000000000000),
+		EndTime:    common.FromWebKit(13345000005000000),
+		SHA256:     "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		Source:     historyFile,
+	}
+	if len(downloads) != 1 || downloads[0] != want {
+		t.Errorf("FindDownloads() = %+v, want [%+v]", downloads, want)
+	}
+
+	t.Run("old_schema", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, historyFile,
+			`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+			`INSERT INTO meta VALUES ('version', '20')`,
+			`CREATE TABLE downloads (id INTEGER PRIMARY KEY, full_path LONGVARCHAR, url LONGVARCHAR,
+				start_time INTEGER, received_bytes INTEGER, total_bytes INTEGER, state INTEGER)`,
+			`INSERT INTO downloads VALUES (1, '/tmp/setup.exe', 'http://example.net/setup.exe', 1300000000, 10, 10, 1)`,
+		)
+
+		downloads, err := FindDownloads(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindDownloads() returned error: %v", err)
+		}
+		want := common.Download{
+			URL:        "http://example.net/setup.exe",
+			TargetPath: "/tmp/setup.exe",
+			TotalBytes: 10,
+			StartTime:  common.FromUnixSeconds(1300000000),
+			EndTime:    common.FromUnixSeconds(0),
+			Source:     historyFile,
+		}
+		if len(downloads) != 1 || downloads[0] != want {
+			t.Errorf("FindDownloads() = %+v, want [%+v]", downloads, want)
+		}
+	})
+
+	t.Run("missing_history", func(t *testing.T) {
+		downloads, err := FindDownloads(common.Profile{Path: t.TempDir()})
+		if err != nil || len(downloads) != 0 {
+			t.Errorf("FindDownloads() = %+v, %v; want no downloads", downloads, err)
+		}
+	})
+}
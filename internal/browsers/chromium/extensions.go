@@ -0,0 +1,111 @@
+package chromium
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// extensionsDir is the profile directory holding installed extensions
+const extensionsDir = "Extensions"
+
+// extensionStateEnabled is the state of an enabled extension in versions
+// that record one
+const extensionStateEnabled = "1"
+
+// extensionManifest is the part of an extension's manifest describing it
+type extensionManifest struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	UpdateURL string `json:"update_url"`
+}
+
+// FindExtensions reads the extensions registered under extensions.settings
+// in Secure Preferences and Preferences. Versions that no longer copy the
+// manifest into the preferences have it read from the extension's
+// directory.
+func FindExtensions(profile common.Profile) ([]common.Extension, error) {
+	found := make(map[string]common.Extension)
+	for _, file := range []string{securePreferencesFile, preferencesFile} {
+		prefs, err := readPrefsFile(filepath.Join(profile.Path, file), profile.UID)
+		if err != nil {
+			return nil, err
+		}
+		node, _ := lookupPref(prefs, "extensions.settings")
+		settings, _ := node.(map[string]interface{})
+		for id, value := range settings {
+			entry, _ := value.(map[string]interface{})
+			if entry == nil {
+				continue
+			}
+			if _, ok := found[id]; ok {
+				continue
+			}
+			found[id] = readExtension(profile, id, entry, file)
+		}
+	}
+
+	extensions := make([]common.Extension, 0, len(found))
+	for _, extension := range found {
+		extensions = append(extensions, extension)
+	}
+	sort.Slice(extensions, func(i, j int) bool {
+		return extensions[i].ID < extensions[j].ID
+	})
+	return extensions, nil
+}
+
+// readExtension converts an extensions.settings entry read from file
+func readExtension(profile common.Profile, id string, entry map[string]interface{}, file string) common.Extension {
+	extension := common.Extension{
+		ID:        id,
+		Path:      prefText(entry["path"]),
+		Installed: webKitString(prefText(entry["install_time"])),
+		Source:    file,
+	}
+	if extension.Path != "" && !filepath.IsAbs(extension.Path) {
+		extension.Path = filepath.Join(profile.Path, extensionsDir, extension.Path)
+	}
+
+	// Newer versions drop state and record only why an extension is
+	// disabled, first as a bit mask and later as a list
+	if state, ok := entry["state"]; ok {
+		extension.Enabled = prefText(state) == extensionStateEnabled
+	} else if reasons, ok := entry["disable_reasons"].([]interface{}); ok {
+		extension.Enabled = len(reasons) == 0
+	} else {
+		mask := prefText(entry["disable_reasons"])
+		extension.Enabled = mask == "" || mask == "0"
+	}
+
+	var manifest extensionManifest
+	if raw, ok := entry["manifest"].(map[string]interface{}); ok {
+		manifest.Name = prefText(raw["name"])
+		manifest.Version = prefText(raw["version"])
+		manifest.UpdateURL = prefText(raw["update_url"])
+	} else if extension.Path != "" {
+		manifest = readManifest(filepath.Join(extension.Path, "manifest.json"), profile.UID)
+	}
+	extension.Name, extension.Version, extension.URL = manifest.Name, manifest.Version, manifest.UpdateURL
+	return extension
+}
+
+// readManifest reads an extension's manifest.json; an unreadable manifest
+// leaves the description empty
+func readManifest(path, uid string) extensionManifest {
+	var manifest extensionManifest
+	if _, err := os.Stat(path); err != nil {
+		return manifest
+	}
+	data, err := common.ReadProfileFile(path, uid)
+	if err != nil {
+		return manifest
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return extensionManifest{}
+	}
+	return manifest
+}
@@ -0,0 +1,55 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindExtensions(t *testing.T) {
+	dir := t.TempDir()
+	secure := `{"extensions": {"settings": {
+		"aaaa": {"path": "aaaa/1.0_0", "install_time": "13345000000000000", "state": 1,
+			"manifest": {"name": "Helper", "version": "1.0", "update_url": "https://clients2.google.com/service/update2/crx"}},
+		"bbbb": {"path": "bbbb/2.1_0", "install_time": "13346000000000000", "disable_reasons": [1]}
+	}}}`
+	prefs := `{"extensions": {"settings": {
+		"aaaa": {"path": "elsewhere", "state": 0}
+	}}}`
+	manifest := `{"name": "Tracker", "version": "2.1", "update_url": "https://ext.example.org/update"}`
+	manifestDir := filepath.Join(dir, extensionsDir, "bbbb", "2.1_0")
+	if err := os.MkdirAll(manifestDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for path, data := range map[string]string{
+		filepath.Join(dir, securePreferencesFile):   secure,
+		filepath.Join(dir, preferencesFile):         prefs,
+		filepath.Join(manifestDir, "manifest.json"): manifest,
+	} {
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extensions, err := FindExtensions(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindExtensions() returned error: %v", err)
+	}
+	want := []common.Extension{
+		{ID: "aaaa", Name: "Helper", Version: "1.0", URL: "https://clients2.google.com/service/update2/crx",
+			Path: filepath.Join(dir, extensionsDir, "aaaa/1.0_0"), Installed: common.FromWebKit(13345000000000000),
+			Enabled: true, Source: securePreferencesFile},
+		{ID: "bbbb", Name: "Tracker", Version: "2.1", URL: "https://ext.example.org/update",
+			Path: manifestDir, Installed: common.FromWebKit(13346000000000000), Source: securePreferencesFile},
+	}
+	if len(extensions) != len(want) {
+		t.Fatalf("FindExtensions() = %+v, want %+v", extensions, want)
+	}
+	for i := range want {
+		if extensions[i] != want[i] {
+			t.Errorf("extensions[%d] = %+v, want %+v", i, extensions[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// faviconsFile is the profile database caching page icons
+const faviconsFile = "Favicons"
+
+// FindFaviconPages reads the pages mapped to icons in the Favicons database,
+// with when each icon was last fetched, and checks each page against the
+// urls table of History. A profile without Favicons has no pages; one
+// without History has none of its pages in history. Versions without
+// per-size bitmaps kept the fetch time in the favicons table.
+func FindFaviconPages(profile common.Profile) ([]common.FaviconPage, error) {
+	faviconsPath := filepath.Join(profile.Path, faviconsFile)
+	if _, err := os.Stat(faviconsPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	history, err := common.ReadStringSet(getHistoryDBPath(profile.Path), profile.UID, `SELECT url FROM urls`)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := common.OpenDatabase(faviconsPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "icon_mapping", "favicons", "favicon_bitmaps")
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Require("icon_mapping.page_url", "icon_mapping.icon_id", "favicons.id", "favicons.url"); err != nil {
+		return nil, err
+	}
+
+	// An icon has a bitmap per size, each with its own fetch time
+	lastUpdated := schema.ColumnOr("favicons", "last_updated", "COALESCE(MAX(f.last_updated), 0)", "0")
+	bitmaps := ""
+	if schema.Has("favicon_bitmaps", "icon_id") && schema.Has("favicon_bitmaps", "last_updated") {
+		lastUpdated = "COALESCE(MAX(b.last_updated), 0)"
+		bitmaps = "LEFT JOIN favicon_bitmaps b ON b.icon_id = f.id"
+	}
+	rows, err := db.Query(`
+		SELECT m.page_url, f.url, ` + lastUpdated + `
+		FROM icon_mapping m
+		JOIN favicons f ON f.id = m.icon_id
+		` + bitmaps + `
+		GROUP BY m.page_url, f.url
+		ORDER BY m.page_url, f.url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []common.FaviconPage
+	for rows.Next() {
+		var page common.FaviconPage
+		var lastUpdated int64
+		if err := rows.Scan(&page.PageURL, &page.IconURL, &lastUpdated); err != nil {
+			return nil, err
+		}
+		page.LastUpdated = common.FromWebKit(lastUpdated)
+		page.InHistory = history[page.PageURL]
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
@@ -0,0 +1,71 @@
+package chromium
+
+import (
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindFaviconPages(t *testing.T) {
+	dir := t.TempDir()
+	createProfileDB(t, dir, faviconsFile,
+		`CREATE TABLE favicons (id INTEGER PRIMARY KEY, url LONGVARCHAR NOT NULL, icon_type INTEGER DEFAULT 1)`,
+		`CREATE TABLE icon_mapping (id INTEGER PRIMARY KEY, page_url LONGVARCHAR NOT NULL, icon_id INTEGER)`,
+		`CREATE TABLE favicon_bitmaps (id INTEGER PRIMARY KEY, icon_id INTEGER NOT NULL, last_updated INTEGER DEFAULT 0,
+			image_data BLOB, width INTEGER DEFAULT 0, height INTEGER DEFAULT 0, last_requested INTEGER DEFAULT 0)`,
+		`INSERT INTO favicons VALUES (1, 'https://mail.example.com/favicon.ico', 1), (2, 'https://leak.example.net/icon.png', 1)`,
+		`INSERT INTO icon_mapping VALUES (1, 'https://mail.example.com/inbox', 1), (2, 'https://leak.example.net/upload', 2)`,
+		`INSERT INTO favicon_bitmaps VALUES (1, 1, 13345000000000000, x'00', 16, 16, 0),
+			(2, 1, 13346000000000000, x'00', 32, 32, 0)`,
+	)
+	createProfileDB(t, dir, "History",
+		`CREATE TABLE urls (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR)`,
+		`INSERT INTO urls VALUES (1, 'https://mail.example.com/inbox', 'Inbox')`,
+	)
+
+	pages, err := FindFaviconPages(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindFaviconPages() returned error: %v", err)
+	}
+	want := []common.FaviconPage{
+		{PageURL: "https://leak.example.net/upload", IconURL: "https://leak.example.net/icon.png"},
+		{PageURL: "https://mail.example.com/inbox", IconURL: "https://mail.example.com/favicon.ico",
+			LastUpdated: common.FromWebKit(13346000000000000), InHistory: true},
+	}
+	if len(pages) != len(want) {
+		t.Fatalf("FindFaviconPages() = %+v, want %+v", pages, want)
+	}
+	for i := range want {
+		if pages[i] != want[i] {
+			t.Errorf("pages[%d] = %+v, want %+v", i, pages[i], want[i])
+		}
+	}
+
+	t.Run("missing_favicons", func(t *testing.T) {
+		pages, err := FindFaviconPages(common.Profile{Path: t.TempDir()})
+		if err != nil || len(pages) != 0 {
+			t.Errorf("FindFaviconPages() = %+v, %v; want no pages", pages, err)
+		}
+	})
+
+	t.Run("without_bitmaps", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, faviconsFile,
+			`CREATE TABLE favicons (id INTEGER PRIMARY KEY, url LONGVARCHAR NOT NULL, last_updated INTEGER DEFAULT 0,
+				image_data BLOB, icon_type INTEGER DEFAULT 1)`,
+			`CREATE TABLE icon_mapping (id INTEGER PRIMARY KEY, page_url LONGVARCHAR NOT NULL, icon_id INTEGER)`,
+			`INSERT INTO favicons VALUES (1, 'https://mail.example.com/favicon.ico', 13346000000000000, NULL, 1)`,
+			`INSERT INTO icon_mapping VALUES (1, 'https://mail.example.com/inbox', 1)`,
+		)
+
+		pages, err := FindFaviconPages(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindFaviconPages() returned error: %v", err)
+		}
+		want := common.FaviconPage{PageURL: "https://mail.example.com/inbox", IconURL: "https://mail.example.com/favicon.ico",
+			LastUpdated: common.FromWebKit(13346000000000000)}
+		if len(pages) != 1 || pages[0] != want {
+			t.Errorf("FindFaviconPages() = %+v, want [%+v]", pages, want)
+		}
+	})
+}
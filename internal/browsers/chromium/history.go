@@ -2,7 +2,6 @@ package chromium
 
 import (
 	"database/sql"
-	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,48 +11,105 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// HistorySource reads history from Chromium-based browser profiles
+var HistorySource = common.HistorySource{
+	Engine:               "Chromium",
+	FindProfiles:         FindUserProfiles,
+	FindHistorySince:     FindHistorySince,
+	FindHistoryMatching:  FindHistoryMatching,
+	DatabaseFiles:        []string{"History", "History-journal", "History-wal"},
+	FindAutofill:         FindAutofill,
+	FindPermissions:      FindPermissions,
+	FindSecuritySettings: FindSecuritySettings,
+	FindSearchEngines:    FindSearchEngines,
+	FindTypedInput:       FindTypedInput,
+	FindFaviconPages:     FindFaviconPages,
+	CarveHistory:         CarveHistory,
+	FindDownloads:        FindDownloads,
+	FindBookmarks:        FindBookmarks,
+	FindExtensions:       FindExtensions,
+	FindCookies:          FindCookies,
+	FindLogins:           FindLogins,
+}
+
+// historyFile is the profile database holding history and downloads
+const historyFile = "History"
+
 // getHistoryDBPath returns the path to the history database for a given profile
 func getHistoryDBPath(profilePath string) string {
-	return filepath.Join(profilePath, "History")
+	return filepath.Join(profilePath, historyFile)
 }
 
-// parseChromeTime converts Chrome's timestamp format to time.Time
-// Chrome's timestamp is in microseconds since Windows epoch (1601-01-01 00:00:00 UTC)
-func parseChromeTime(chromeTime int64) time.Time {
-	// Windows epoch starts at 1601-01-01 00:00:00 UTC
-	// Unix epoch starts at 1970-01-01 00:00:00 UTC
-	// Difference is 11644473600 seconds
-	const windowsEpochOffset = 11644473600 * 1000000 // in microseconds
+// historySchemaQuery reads the History schema version from the meta table
+const historySchemaQuery = `SELECT CAST(value AS INTEGER) FROM meta WHERE key = 'version'`
 
-	if chromeTime == 0 {
-		return time.Time{}
-	}
+// historySchemaVersions are the History schema versions the history query
+// was tested against
+var historySchemaVersions = common.SchemaVersions{Min: 16, Max: 70}
 
-	// Convert microseconds to nanoseconds for time.Unix
-	unixMicroseconds := chromeTime - windowsEpochOffset
-	return time.Unix(0, unixMicroseconds*1000)
-}
+// historyRequiredColumns are the History columns the history query cannot
+// do without
+var historyRequiredColumns = []string{"urls.id", "urls.url", "visits.id", "visits.url", "visits.visit_time"}
+
+// historyOptionalColumns are the History columns the history query reads
+// when a version has them, and fills in as empty otherwise. Versions before
+// 20 lack visit_duration.
+var historyOptionalColumns = []string{"urls.title", "urls.visit_count", "visits.from_visit", "visits.visit_duration"}
 
 // FindHistory discovers history entries for a specific profile
 func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
+	return FindHistorySince(profile, common.Cursor{})
+}
+
+// FindHistorySince discovers history entries recorded after the given cursor.
+// A zero cursor returns the full history.
+func FindHistorySince(profile common.Profile, cursor common.Cursor) ([]common.HistoryEntry, error) {
+	return FindHistoryMatching(profile, cursor, common.URLFilter{})
+}
+
+// FindHistoryMatching discovers history entries recorded after the given
+// cursor whose URLs may match the filter. The filter is applied in SQL and
+// can admit extra rows, which callers remove with URLFilter.Matches.
+func FindHistoryMatching(profile common.Profile, cursor common.Cursor, urlFilter common.URLFilter) ([]common.HistoryEntry, error) {
 	historyDBPath := getHistoryDBPath(profile.Path)
 
-	// Open the SQLite database
-	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&immutable=1", historyDBPath))
+	// Open a private snapshot of the SQLite database
+	db, err := common.OpenDatabase(historyDBPath, profile.UID)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	// Query the history entries
-	// We're using a simple query to get the most recent visits
+	schema, err := common.ReadSchema(db, historySchemaQuery, "urls", "visits")
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Require(historyRequiredColumns...); err != nil {
+		return nil, err
+	}
+	common.ReportSchema(profile, historyDBPath, schema, historySchemaVersions, historyOptionalColumns...)
+
+	filter, args, err := cursorFilter(db.DB, cursor)
+	if err != nil {
+		return nil, err
+	}
+	urlCondition, urlArgs := urlFilterCondition(urlFilter)
+	args = append(args, urlArgs...)
+
+	// Query the individual visits joined with their URLs, most recent first,
+	// selecting the optional columns this version has
 	query := `
-		SELECT id, url, title, last_visit_time, visit_count
-		FROM urls
-		ORDER BY last_visit_time DESC
+		SELECT v.id, ` + schema.ColumnOr("visits", "from_visit", "COALESCE(v.from_visit, 0)", "0") + `, u.id, u.url,
+			` + schema.ColumnOr("urls", "title", "COALESCE(u.title, '')", "''") + `, v.visit_time,
+			` + schema.ColumnOr("urls", "visit_count", "COALESCE(u.visit_count, 0)", "0") + `,
+			` + schema.ColumnOr("visits", "visit_duration", "COALESCE(v.visit_duration, 0)", "0") + `
+		FROM visits v
+		JOIN urls u ON v.url = u.id
+		` + common.Where(filter, urlCondition) + `
+		ORDER BY v.visit_time DESC
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,25 +118,30 @@ func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
 	var historyEntries []common.HistoryEntry
 
 	for rows.Next() {
-		var id int64
+		var visitID, fromVisitID, id int64
 		var url, title string
-		var lastVisitTime int64
+		var visitTime, visitDuration int64
 		var visitCount int
 
-		err := rows.Scan(&id, &url, &title, &lastVisitTime, &visitCount)
+		err := rows.Scan(&visitID, &fromVisitID, &id, &url, &title, &visitTime, &visitCount, &visitDuration)
 		if err != nil {
 			return nil, err
 		}
 
 		historyEntry := common.HistoryEntry{
 			ID:             id,
+			VisitID:        visitID,
+			FromVisitID:    fromVisitID,
 			URL:            url,
 			Title:          title,
-			VisitTime:      parseChromeTime(lastVisitTime),
+			VisitTime:      common.FromWebKit(visitTime),
 			VisitCount:     visitCount,
+			VisitDuration:  time.Duration(visitDuration) * time.Microsecond,
 			ProfileID:      profile.ID,
 			BrowserType:    strings.ToLower(profile.BrowserVariant),
 			BrowserVariant: profile.BrowserVariant,
+			Username:       profile.Username,
+			UID:            profile.UID,
 		}
 
 		historyEntries = append(historyEntries, historyEntry)
@@ -92,3 +153,61 @@ func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
 
 	return historyEntries, nil
 }
+
+// cursorFilter returns the condition selecting visits after the cursor.
+// Visit IDs are reused once Chrome's history is cleared, so the ID is only
+// trusted while the visit it points at still carries the recorded time;
+// otherwise visits are selected by time instead.
+func cursorFilter(db *sql.DB, cursor common.Cursor) (string, []interface{}, error) {
+	if cursor.IsZero() {
+		return "", nil, nil
+	}
+
+	chromeTime := common.ToWebKit(cursor.VisitTime)
+
+	var visitTime int64
+	err := db.QueryRow(`SELECT visit_time FROM visits WHERE id = ?`, cursor.VisitID).Scan(&visitTime)
+	switch {
+	case err == nil && visitTime == chromeTime:
+		return "v.id > ?", []interface{}{cursor.VisitID}, nil
+	case err != nil && err != sql.ErrNoRows:
+		return "", nil, err
+	}
+
+	return "v.visit_time > ?", []interface{}{chromeTime}, nil
+}
+
+// urlFilterCondition returns a condition preselecting URLs that may match the
+// filter. Chromium does not store hosts separately, so hosts and domains are
+// matched as substrings of the URL.
+func urlFilterCondition(filter common.URLFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	like := func(patterns []string) {
+		var alternatives []string
+		for _, pattern := range patterns {
+			alternatives = append(alternatives, `u.url LIKE ? ESCAPE '\'`)
+			args = append(args, pattern)
+		}
+		if condition := common.AnyOf(alternatives); condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	var schemes, hosts, domains []string
+	for _, scheme := range filter.Schemes {
+		schemes = append(schemes, common.LikePattern(scheme)+":%")
+	}
+	for _, host := range filter.Hosts {
+		hosts = append(hosts, "%"+common.LikePattern(host)+"%")
+	}
+	for _, domain := range filter.Domains {
+		domains = append(domains, "%"+common.LikePattern(domain)+"%")
+	}
+	like(schemes)
+	like(hosts)
+	like(domains)
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,282 @@
+package chromium
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/status"
+)
+
+// createHistoryDB creates a minimal Chromium History database in dir
+func createHistoryDB(t *testing.T, dir string, visits [][2]int64) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "History"))
+	if err != nil {
+		t.Fatalf("Failed to create History database: %v", err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE urls (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR,
+			visit_count INTEGER DEFAULT 0 NOT NULL, last_visit_time INTEGER NOT NULL)`,
+		`CREATE TABLE visits (id INTEGER PRIMARY KEY, url INTEGER NOT NULL, visit_time INTEGER NOT NULL,
+			from_visit INTEGER DEFAULT 0)`,
+		`INSERT INTO urls (id, url, title, visit_count, last_visit_time)
+			VALUES (1, 'https://example.com/', 'Example', 1, 0)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up History database: %v", err)
+		}
+	}
+
+	for _, visit := range visits {
+		if _, err := db.Exec(`INSERT INTO visits (id, url, visit_time) VALUES (?, 1, ?)`, visit[0], visit[1]); err != nil {
+			t.Fatalf("Failed to insert visit: %v", err)
+		}
+	}
+}
+
+func TestFindHistorySince(t *testing.T) {
+	const base = int64(13285468800000000) // 2022-01-01 00:00:00 UTC in Chrome time
+
+	t.Run("returns_visits_after_cursor", func(t *testing.T) {
+		dir := t.TempDir()
+		createHistoryDB(t, dir, [][2]int64{{1, base}, {2, base + 1000}, {3, base + 2000}})
+		profile := common.Profile{ID: "Default", Path: dir, BrowserVariant: "chrome"}
+
+		all, err := FindHistorySince(profile, common.Cursor{})
+		if err != nil {
+			t.Fatalf("FindHistorySince() returned error: %v", err)
+		}
+		if len(all) != 3 {
+			t.Fatalf("Expected 3 visits, got %d", len(all))
+		}
+
+		cursor := common.Cursor{VisitID: 2, VisitTime: common.FromWebKit(base + 1000)}
+		entries, err := FindHistorySince(profile, cursor)
+		if err != nil {
+			t.Fatalf("FindHistorySince() returned error: %v", err)
+		}
+		if len(entries) != 1 || entries[0].VisitID != 3 {
+			t.Errorf("Expected only visit 3, got %+v", entries)
+		}
+	})
+
+	t.Run("falls_back_to_time_after_history_cleared", func(t *testing.T) {
+		dir := t.TempDir()
+		// Visit IDs restarted from 1 after the history was cleared
+		createHistoryDB(t, dir, [][2]int64{{1, base + 5000}, {2, base + 6000}})
+		profile := common.Profile{ID: "Default", Path: dir, BrowserVariant: "chrome"}
+
+		cursor := common.Cursor{VisitID: 40, VisitTime: common.FromWebKit(base + 4000)}
+		entries, err := FindHistorySince(profile, cursor)
+		if err != nil {
+			t.Fatalf("FindHistorySince() returned error: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("Expected both visits after reset, got %d", len(entries))
+		}
+	})
+}
+
+func TestFindHistoryMatching(t *testing.T) {
+	const base = int64(13285468800000000)
+
+	dir := t.TempDir()
+	createHistoryDB(t, dir, [][2]int64{{1, base}})
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "History"))
+	if err != nil {
+		t.Fatalf("Failed to open History database: %v", err)
+	}
+	statements := []string{
+		`INSERT INTO urls (id, url, title, visit_count, last_visit_time) VALUES
+			(2, 'https://mail.example.com/inbox', 'Mail', 1, 0),
+			(3, 'http://example.org/100%_real', 'Other', 1, 0),
+			(4, 'chrome://settings/', 'Settings', 1, 0)`,
+		`INSERT INTO visits (id, url, visit_time) VALUES (2, 2, ?), (3, 3, ?), (4, 4, ?)`,
+	}
+	if _, err := db.Exec(statements[0]); err != nil {
+		t.Fatalf("Failed to insert urls: %v", err)
+	}
+	if _, err := db.Exec(statements[1], base+1000, base+2000, base+3000); err != nil {
+		t.Fatalf("Failed to insert visits: %v", err)
+	}
+	db.Close()
+
+	profile := common.Profile{ID: "Default", Path: dir, BrowserVariant: "chrome"}
+
+	tests := []struct {
+		name   string
+		filter common.URLFilter
+		want   map[int64]bool
+	}{
+		{"domain", common.URLFilter{Domains: []string{"example.com"}}, map[int64]bool{1: true, 2: true}},
+		{"scheme", common.URLFilter{Schemes: []string{"chrome"}}, map[int64]bool{4: true}},
+		{"scheme_and_host", common.URLFilter{Hosts: []string{"example.org"}, Schemes: []string{"http"}}, map[int64]bool{3: true}},
+		{"no_match", common.URLFilter{Domains: []string{"example.net"}}, map[int64]bool{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := FindHistoryMatching(profile, common.Cursor{}, tt.filter)
+			if err != nil {
+				t.Fatalf("FindHistoryMatching() returned error: %v", err)
+			}
+			got := make(map[int64]bool)
+			for _, entry := range entries {
+				got[entry.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FindHistoryMatching() urls = %v, want %v", got, tt.want)
+			}
+			for id := range tt.want {
+				if !got[id] {
+					t.Errorf("FindHistoryMatching() urls = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// schemaNotice returns the schema notice recorded for path, if any
+func schemaNotice(path string) (status.SchemaNotice, bool) {
+	for _, n := range status.SchemaNotices() {
+		if n.Path == path {
+			return n, true
+		}
+	}
+	return status.SchemaNotice{}, false
+}
+
+func TestFindHistorySchemaVersions(t *testing.T) {
+	const base = int64(13285468800000000)
+
+	tests := []struct {
+		name       string
+		statements []string
+		wantErr    bool
+		wantNotice string
+		wantTitle  string
+		wantCount  int
+		wantTime   time.Duration
+	}{
+		{
+			name: "current_version_with_visit_duration",
+			statements: []string{
+				`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+				`INSERT INTO meta VALUES ('version', '66'), ('last_compatible_version', '16')`,
+				`CREATE TABLE urls (id INTEGER PRIMARY KEY AUTOINCREMENT, url LONGVARCHAR, title LONGVARCHAR,
+					visit_count INTEGER DEFAULT 0 NOT NULL, typed_count INTEGER DEFAULT 0 NOT NULL,
+					last_visit_time INTEGER NOT NULL, hidden INTEGER DEFAULT 0 NOT NULL)`,
+				`CREATE TABLE visits (id INTEGER PRIMARY KEY AUTOINCREMENT, url INTEGER NOT NULL, visit_time INTEGER NOT NULL,
+					from_visit INTEGER, transition INTEGER DEFAULT 0 NOT NULL, segment_id INTEGER,
+					visit_duration INTEGER DEFAULT 0 NOT NULL, incremented_omnibox_typed_score BOOLEAN DEFAULT FALSE NOT NULL)`,
+				`INSERT INTO urls (id, url, title, visit_count, last_visit_time) VALUES (1, 'https://example.com/', 'Example', 3, 0)`,
+				`INSERT INTO visits (id, url, visit_time, from_visit, visit_duration) VALUES (1, 1, 13285468800000000, 0, 2500000)`,
+			},
+			wantTitle: "Example",
+			wantCount: 3,
+			wantTime:  2500 * time.Millisecond,
+		},
+		{
+			name: "old_version_without_visit_duration",
+			statements: []string{
+				`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+				`INSERT INTO meta VALUES ('version', '16')`,
+				`CREATE TABLE urls (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR,
+					visit_count INTEGER DEFAULT 0 NOT NULL, last_visit_time INTEGER NOT NULL)`,
+				`CREATE TABLE visits (id INTEGER PRIMARY KEY, url INTEGER NOT NULL, visit_time INTEGER NOT NULL, from_visit INTEGER)`,
+				`INSERT INTO urls VALUES (1, 'https://example.com/', 'Example', 2, 0)`,
+				`INSERT INTO visits VALUES (1, 1, 13285468800000000, 0)`,
+			},
+			wantTitle: "Example",
+			wantCount: 2,
+		},
+		{
+			name: "unknown_old_version_missing_optional_columns",
+			statements: []string{
+				`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+				`INSERT INTO meta VALUES ('version', '12')`,
+				`CREATE TABLE urls (id INTEGER PRIMARY KEY, url LONGVARCHAR, last_visit_time INTEGER NOT NULL)`,
+				`CREATE TABLE visits (id INTEGER PRIMARY KEY, url INTEGER NOT NULL, visit_time INTEGER NOT NULL)`,
+				`INSERT INTO urls VALUES (1, 'https://example.com/', 0)`,
+				`INSERT INTO visits VALUES (1, 1, 13285468800000000)`,
+			},
+			wantNotice: "schema version 12 is outside the tested versions 16-70; missing optional columns: " +
+				"urls.title, urls.visit_count, visits.from_visit, visits.visit_duration",
+		},
+		{
+			name: "unknown_future_version",
+			statements: []string{
+				`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+				`INSERT INTO meta VALUES ('version', '99')`,
+				`CREATE TABLE urls (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR,
+					visit_count INTEGER DEFAULT 0 NOT NULL, last_visit_time INTEGER NOT NULL, new_column INTEGER)`,
+				`CREATE TABLE visits (id INTEGER PRIMARY KEY, url INTEGER NOT NULL, visit_time INTEGER NOT NULL,
+					from_visit INTEGER, visit_duration INTEGER DEFAULT 0 NOT NULL)`,
+				`INSERT INTO urls (id, url, title, visit_count, last_visit_time) VALUES (1, 'https://example.com/', 'Example', 1, 0)`,
+				`INSERT INTO visits VALUES (1, 1, 13285468800000000, 0, 0)`,
+			},
+			wantNotice: "schema version 99 is outside the tested versions 16-70",
+			wantTitle:  "Example",
+			wantCount:  1,
+		},
+		{
+			name: "missing_required_column",
+			statements: []string{
+				`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+				`INSERT INTO meta VALUES ('version', '99')`,
+				`CREATE TABLE urls (id INTEGER PRIMARY KEY, url LONGVARCHAR)`,
+				`CREATE TABLE visits (id INTEGER PRIMARY KEY, url INTEGER NOT NULL, visited_at INTEGER NOT NULL)`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			createProfileDB(t, dir, "History", tt.statements...)
+			profile := common.Profile{ID: "Default", Path: dir, BrowserType: "chrome", BrowserVariant: "chrome"}
+
+			entries, err := FindHistoryMatching(profile, common.Cursor{}, common.URLFilter{})
+			if tt.wantErr {
+				if err == nil || status.Classify(err) != status.ClassSchemaMismatch {
+					t.Fatalf("FindHistoryMatching() error = %v, want a schema mismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindHistoryMatching() returned error: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("FindHistoryMatching() returned %d entries, want 1", len(entries))
+			}
+			entry := entries[0]
+			if entry.URL != "https://example.com/" || !entry.VisitTime.Equal(common.FromWebKit(base)) {
+				t.Errorf("entry = %+v, want the example.com visit", entry)
+			}
+			if entry.Title != tt.wantTitle || entry.VisitCount != tt.wantCount || entry.VisitDuration != tt.wantTime {
+				t.Errorf("entry title, count, duration = %q, %d, %v, want %q, %d, %v",
+					entry.Title, entry.VisitCount, entry.VisitDuration, tt.wantTitle, tt.wantCount, tt.wantTime)
+			}
+
+			notice, ok := schemaNotice(filepath.Join(dir, "History"))
+			if tt.wantNotice == "" {
+				if ok {
+					t.Errorf("unexpected schema notice %q", notice.Message)
+				}
+				return
+			}
+			if !ok || notice.Message != tt.wantNotice {
+				t.Errorf("schema notice = %q, want %q", notice.Message, tt.wantNotice)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// loginDataFile is the profile database holding saved passwords
+const loginDataFile = "Login Data"
+
+// FindLogins reads the sites a profile saved passwords for and when they
+// were used. Usernames and passwords are never read. Versions before 2020
+// do not record the last use. A profile without a Login Data database has
+// no logins.
+func FindLogins(profile common.Profile) ([]common.Login, error) {
+	loginDataPath := filepath.Join(profile.Path, loginDataFile)
+	if _, err := os.Stat(loginDataPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(loginDataPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "logins")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["logins"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("logins.origin_url", "logins.date_created"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT origin_url, date_created,
+			` + schema.ColumnOr("logins", "date_last_used", "COALESCE(date_last_used, 0)", "0") + `,
+			` + schema.ColumnOr("logins", "times_used", "COALESCE(times_used, 0)", "0") + `
+		FROM logins
+		ORDER BY date_created
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logins []common.Login
+	for rows.Next() {
+		var login common.Login
+		var created, lastUsed int64
+		if err := rows.Scan(&login.URL, &created, &lastUsed, &login.TimesUsed); err != nil {
+			return nil, err
+		}
+		login.Created = common.FromWebKit(created)
+		login.LastUsed = common.FromWebKit(lastUsed)
+		login.Source = loginDataFile
+		logins = append(logins, login)
+	}
+	return logins, rows.Err()
+}
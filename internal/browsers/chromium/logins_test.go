@@ -0,0 +1,49 @@
+package chromium
+
+import (
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindLogins(t *testing.T) {
+	dir := t.TempDir()
+	createProfileDB(t, dir, loginDataFile,
+		`CREATE TABLE meta (key LONGVARCHAR NOT NULL UNIQUE PRIMARY KEY, value LONGVARCHAR)`,
+		`INSERT INTO meta VALUES ('version', '40')`,
+		`CREATE TABLE logins (origin_url VARCHAR NOT NULL, action_url VARCHAR, username_value VARCHAR,
+			password_value BLOB, date_created INTEGER NOT NULL, times_used INTEGER, date_last_used INTEGER NOT NULL DEFAULT 0)`,
+		`INSERT INTO logins VALUES ('https://mail.example.com/', '', 'alice', X'00', 13345000000000000, 4, 13346000000000000)`,
+	)
+
+	logins, err := FindLogins(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindLogins() returned error: %v", err)
+	}
+	want := common.Login{
+		URL:       "https://mail.example.com/",
+		Created:   common.FromWebKit(13345000000000000),
+		LastUsed:  common.FromWebKit(13346000000000000),
+		TimesUsed: 4,
+		Source:    loginDataFile,
+	}
+	if len(logins) != 1 || logins[0] != want {
+		t.Errorf("FindLogins() = %+v, want [%+v]", logins, want)
+	}
+
+	t.Run("without_last_use", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, loginDataFile,
+			`CREATE TABLE logins (origin_url VARCHAR NOT NULL, date_created INTEGER NOT NULL)`,
+			`INSERT INTO logins VALUES ('https://example.org/', 13345000000000000)`,
+		)
+
+		logins, err := FindLogins(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindLogins() returned error: %v", err)
+		}
+		if len(logins) != 1 || !logins[0].LastUsed.IsZero() || logins[0].URL != "https://example.org/" {
+			t.Errorf("FindLogins() = %+v, want one login without a last use", logins)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package chromium
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// contentSettingsPreferences is the part of the Preferences file holding
+// per-site content settings
+type contentSettingsPreferences struct {
+	Profile struct {
+		ContentSettings struct {
+			Exceptions map[string]map[string]contentSettingException `json:"exceptions"`
+		} `json:"content_settings"`
+	} `json:"profile"`
+}
+
+// contentSettingException is a per-site content setting. Times are WebKit
+// timestamps stored as strings. Settings that are not permissions, such as
+// site engagement, hold an object instead of a number.
+type contentSettingException struct {
+	Setting      json.RawMessage `json:"setting"`
+	LastModified string          `json:"last_modified"`
+	Expiration   string          `json:"expiration"`
+}
+
+// permissionTypes maps Chromium content setting types to shared names
+var permissionTypes = map[string]string{
+	"media_stream_camera": common.PermissionCamera,
+	"media_stream_mic":    common.PermissionMicrophone,
+	"notifications":       common.PermissionNotifications,
+	"geolocation":         common.PermissionGeolocation,
+	"clipboard":           common.PermissionClipboard,
+}
+
+// contentSettings maps Chromium's ContentSetting values to shared names
+var contentSettings = map[int]string{
+	1: common.SettingAllow,
+	2: common.SettingBlock,
+	3: common.SettingAsk,
+	4: common.SettingSessionOnly,
+}
+
+// FindPermissions reads the per-site content settings of a profile from its
+// Preferences file. Exceptions whose setting is not a plain value are not
+// permissions and are skipped. A profile without Preferences has none.
+func FindPermissions(profile common.Profile) ([]common.SitePermission, error) {
+	preferencesPath := filepath.Join(profile.Path, "Preferences")
+	if _, err := os.Stat(preferencesPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := common.ReadProfileFile(preferencesPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefs contentSettingsPreferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, err
+	}
+
+	var permissions []common.SitePermission
+	for contentType, exceptions := range prefs.Profile.ContentSettings.Exceptions {
+		permission, ok := permissionTypes[contentType]
+		if !ok {
+			permission = contentType
+		}
+
+		for pattern, exception := range exceptions {
+			var value int
+			if err := json.Unmarshal(exception.Setting, &value); err != nil {
+				continue
+			}
+			setting, ok := contentSettings[value]
+			if !ok {
+				setting = strconv.Itoa(value)
+			}
+
+			// Keys pair the site with the embedding site, usually "*"
+			origin, _, _ := strings.Cut(pattern, ",")
+			permissions = append(permissions, common.SitePermission{
+				Origin:       origin,
+				Permission:   permission,
+				Setting:      setting,
+				LastModified: webKitString(exception.LastModified),
+				Expiration:   webKitString(exception.Expiration),
+			})
+		}
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		if permissions[i].Permission != permissions[j].Permission {
+			return permissions[i].Permission < permissions[j].Permission
+		}
+		return permissions[i].Origin < permissions[j].Origin
+	})
+	return permissions, nil
+}
+
+// webKitString converts a WebKit timestamp stored as a decimal string
+func webKitString(value string) time.Time {
+	microseconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return common.FromWebKit(microseconds)
+}
@@ -0,0 +1,44 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindPermissions(t *testing.T) {
+	dir := t.TempDir()
+	preferences := `{"profile": {"content_settings": {"exceptions": {
+		"media_stream_camera": {"https://meet.example.com:443,*": {"setting": 1, "last_modified": "13300000000000000"}},
+		"notifications": {"https://push.example.net:443,*": {"setting": 2, "last_modified": "13300000000000000", "expiration": "0"}},
+		"site_engagement": {"https://example.com:443,*": {"setting": {"rawScore": 4.5}}},
+		"popups": {"[*.]example.org,*": {"setting": 4}}
+	}}}}`
+	if err := os.WriteFile(filepath.Join(dir, "Preferences"), []byte(preferences), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	permissions, err := FindPermissions(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindPermissions() returned error: %v", err)
+	}
+	want := []common.SitePermission{
+		{Origin: "https://meet.example.com:443", Permission: common.PermissionCamera, Setting: common.SettingAllow, LastModified: common.FromWebKit(13300000000000000)},
+		{Origin: "https://push.example.net:443", Permission: common.PermissionNotifications, Setting: common.SettingBlock, LastModified: common.FromWebKit(13300000000000000)},
+		{Origin: "[*.]example.org", Permission: "popups", Setting: common.SettingSessionOnly},
+	}
+	if len(permissions) != len(want) {
+		t.Fatalf("FindPermissions() = %+v, want %+v", permissions, want)
+	}
+	for i := range want {
+		if permissions[i] != want[i] {
+			t.Errorf("permission %d = %+v, want %+v", i, permissions[i], want[i])
+		}
+	}
+
+	if permissions, err := FindPermissions(common.Profile{Path: t.TempDir()}); err != nil || len(permissions) != 0 {
+		t.Errorf("Expected no permissions without Preferences, got %v, %v", permissions, err)
+	}
+}
@@ -0,0 +1,113 @@
+package chromium
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// linuxPolicyDirs are the policy directories of Chromium-based browsers on
+// Linux, each holding managed and recommended subdirectories
+var linuxPolicyDirs = []struct {
+	browser string
+	dir     string
+}{
+	{"chrome", "/etc/opt/chrome/policies"},
+	{"chromium", "/etc/chromium/policies"},
+	{"chromium", "/etc/chromium-browser/policies"},
+	{"edge", "/etc/opt/edge/policies"},
+	{"brave", "/etc/brave/policies"},
+}
+
+// PolicyDirectory reads the JSON policy files of a Chromium policy
+// directory. Files in the managed and recommended subdirectories are read in
+// name order, so a later file overrides an earlier one as in the browser.
+type PolicyDirectory struct {
+	// BrowserType is the browser the directory configures
+	BrowserType string
+
+	// Dir is the policy directory
+	Dir string
+}
+
+// Browser returns the browser the directory configures
+func (d PolicyDirectory) Browser() string {
+	return d.BrowserType
+}
+
+// Location returns the policy directory
+func (d PolicyDirectory) Location() string {
+	return d.Dir
+}
+
+// Policies reads the policies of every JSON file in the directory
+func (d PolicyDirectory) Policies() ([]common.Policy, error) {
+	var policies []common.Policy
+	for _, scope := range []string{common.PolicyManaged, common.PolicyRecommended} {
+		files, err := filepath.Glob(filepath.Join(d.Dir, scope, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			values, err := decodePolicies(data)
+			if err != nil {
+				return nil, &os.PathError{Op: "parse", Path: file, Err: err}
+			}
+			for _, name := range sortedKeys(values) {
+				policies = append(policies, common.Policy{
+					Browser: d.BrowserType,
+					Name:    name,
+					Value:   prefText(values[name]),
+					Scope:   scope,
+					Source:  file,
+				})
+			}
+		}
+	}
+	return policies, nil
+}
+
+// PolicySources returns the policy sources of Chromium-based browsers on this
+// platform. Only Linux policy directories are read so far.
+func PolicySources() []common.PolicySource {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	var sources []common.PolicySource
+	for _, d := range linuxPolicyDirs {
+		sources = append(sources, PolicyDirectory{BrowserType: d.browser, Dir: d.dir})
+	}
+	return sources
+}
+
+// decodePolicies parses a JSON object of policies, keeping numbers as written
+func decodePolicies(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// sortedKeys returns the keys of a JSON object in order
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,60 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestPolicyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"managed/10-base.json":      `{"SafeBrowsingProtectionLevel": 2, "ExtensionInstallBlocklist": ["*"]}`,
+		"managed/20-proxy.json":     `{"ProxyMode": "direct"}`,
+		"recommended/homepage.json": `{"HomepageLocation": "https://intranet.example.com/"}`,
+		"recommended/ignored.txt":   `{"NotAPolicy": true}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policies, err := PolicyDirectory{BrowserType: "chrome", Dir: dir}.Policies()
+	if err != nil {
+		t.Fatalf("Policies() returned error: %v", err)
+	}
+	base, proxy, home := filepath.Join(dir, "managed/10-base.json"), filepath.Join(dir, "managed/20-proxy.json"), filepath.Join(dir, "recommended/homepage.json")
+	want := []common.Policy{
+		{Browser: "chrome", Name: "ExtensionInstallBlocklist", Value: `["*"]`, Scope: common.PolicyManaged, Source: base},
+		{Browser: "chrome", Name: "SafeBrowsingProtectionLevel", Value: "2", Scope: common.PolicyManaged, Source: base},
+		{Browser: "chrome", Name: "ProxyMode", Value: "direct", Scope: common.PolicyManaged, Source: proxy},
+		{Browser: "chrome", Name: "HomepageLocation", Value: "https://intranet.example.com/", Scope: common.PolicyRecommended, Source: home},
+	}
+	if !reflect.DeepEqual(policies, want) {
+		t.Errorf("Policies() = %+v, want %+v", policies, want)
+	}
+
+	t.Run("missing_directory", func(t *testing.T) {
+		policies, err := PolicyDirectory{BrowserType: "edge", Dir: filepath.Join(dir, "missing")}.Policies()
+		if err != nil || len(policies) != 0 {
+			t.Errorf("Expected no policies, got %v, %v", policies, err)
+		}
+	})
+
+	t.Run("invalid_file", func(t *testing.T) {
+		bad := t.TempDir()
+		os.MkdirAll(filepath.Join(bad, "managed"), 0755)
+		os.WriteFile(filepath.Join(bad, "managed", "broken.json"), []byte(`{"ProxyMode":`), 0644)
+		if _, err := (PolicyDirectory{BrowserType: "brave", Dir: bad}).Policies(); err == nil {
+			t.Error("Expected an error for an invalid policy file")
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package chromium
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MAC statuses of tracked preferences
+const (
+	// MACValid means the stored MAC matches the preference value
+	MACValid = "valid"
+
+	// MACInvalid means the value was changed without updating its MAC,
+	// typically by a tool editing the file while the browser was closed
+	MACInvalid = "invalid"
+
+	// MACUnverifiable means no known seed and device ID reproduced any MAC
+	// of the profile, so its MACs cannot be checked
+	MACUnverifiable = "unverifiable"
+)
+
+// macSeeds are the HMAC keys browsers use for tracked preferences. Chromium
+// and most forks use an empty seed; Google Chrome ships a fixed seed in its
+// resources.
+var macSeeds = [][]byte{
+	nil,
+	mustDecodeHex("e748f336d85ea5f9dcdf25d8f347a65b4cdf667600f02df6724a2af18a212d26" +
+		"b788a25086910cf3a90313696871f3dc05823730c91df8ba5c4fd9c884b505a8"),
+}
+
+// macDeviceIDs are the machine identifiers mixed into MACs. Chromium has no
+// device ID on Linux; Windows and macOS MACs use a machine-specific ID that
+// is not derived here, which leaves them unverifiable.
+var macDeviceIDs = []string{""}
+
+// mustDecodeHex decodes a hex constant
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// trackedMACs flattens a protection.macs tree into preference paths and
+// their hex MACs. Split preferences such as extensions.settings have one MAC
+// per entry, which is keyed by the entry's full path.
+func trackedMACs(macs map[string]interface{}) map[string]string {
+	flat := make(map[string]string)
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for key, value := range node {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			switch v := value.(type) {
+			case string:
+				flat[path] = v
+			case map[string]interface{}:
+				walk(path, v)
+			}
+		}
+	}
+	walk("", macs)
+	return flat
+}
+
+// verifyMACs checks each tracked preference's MAC against its value in
+// prefs. The seed and device ID are those that reproduce at least one MAC of
+// the profile; if none does, every MAC is unverifiable.
+func verifyMACs(macs map[string]string, prefs map[string]interface{}) map[string]string {
+	messages := make(map[string]string, len(macs))
+	for path := range macs {
+		value, _ := lookupPref(prefs, path)
+		messages[path] = path + prefHashValue(value)
+	}
+
+	statuses := make(map[string]string, len(macs))
+	for _, seed := range macSeeds {
+		for _, deviceID := range macDeviceIDs {
+			matched := 0
+			for path, mac := range macs {
+				if prefMAC(seed, deviceID+messages[path]) == strings.ToUpper(mac) {
+					statuses[path] = MACValid
+					matched++
+				} else {
+					statuses[path] = MACInvalid
+				}
+			}
+			if matched > 0 {
+				return statuses
+			}
+		}
+	}
+
+	for path := range macs {
+		statuses[path] = MACUnverifiable
+	}
+	return statuses
+}
+
+// prefMAC returns the upper-case hex HMAC-SHA256 of message
+func prefMAC(seed []byte, message string) string {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte(message))
+	return strings.ToUpper(hex.EncodeToString(mac.Sum(nil)))
+}
+
+// prefHashValue serializes a preference value the way Chromium does before
+// hashing it: absent values hash as the empty string, and empty dictionaries
+// and lists inside dictionaries are dropped.
+func prefHashValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	var b strings.Builder
+	writePrefJSON(&b, removeEmptyEntries(value))
+	return b.String()
+}
+
+// removeEmptyEntries drops empty dictionaries and lists from dictionaries,
+// recursively
+func removeEmptyEntries(value interface{}) interface{} {
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	cleaned := make(map[string]interface{}, len(dict))
+	for key, child := range dict {
+		child = removeEmptyEntries(child)
+		switch v := child.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		}
+		cleaned[key] = child
+	}
+	return cleaned
+}
+
+// writePrefJSON writes value as Chromium's JSONWriter does: dictionary keys
+// sorted, no whitespace and Chromium's string escaping. Numbers are decoded
+// with json.Number and written as stored.
+func writePrefJSON(b *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		if v {
+			b.WriteString("true")
+		} else {
+			b.WriteString("false")
+		}
+	case json.Number:
+		b.WriteString(v.String())
+	case string:
+		writePrefString(b, v)
+	case []interface{}:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writePrefJSON(b, item)
+		}
+		b.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writePrefString(b, key)
+			b.WriteByte(':')
+			writePrefJSON(b, v[key])
+		}
+		b.WriteByte('}')
+	}
+}
+
+// writePrefString writes a quoted string with Chromium's JSON escaping, which
+// escapes '<' and the Unicode line separators in addition to control
+// characters
+func writePrefString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '<', '\u2028', '\u2029':
+			fmt.Fprintf(b, `\u%04X`, r)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
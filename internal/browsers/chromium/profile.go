@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/status"
 )
 
 // ProfileInfo represents the structure of the Preferences file
@@ -43,8 +44,9 @@ func findProfileDirectories(userDataDir string) ([]string, error) {
 	return profileDirs, nil
 }
 
-// readProfileInfo reads profile information from the Preferences file
-func readProfileInfo(profileDir string) (common.Profile, error) {
+// readProfileInfo reads profile information from the Preferences file, as
+// the profile owner with the given UID and without following symlinks
+func readProfileInfo(profileDir, uid string) (common.Profile, error) {
 	profile := common.Profile{
 		Path: profileDir,
 	}
@@ -67,7 +69,7 @@ func readProfileInfo(profileDir string) (common.Profile, error) {
 		return profile, nil
 	}
 
-	data, err := ioutil.ReadFile(preferencesPath)
+	data, err := common.ReadProfileFile(preferencesPath, uid)
 	if err != nil {
 		return profile, err
 	}
@@ -87,31 +89,64 @@ func readProfileInfo(profileDir string) (common.Profile, error) {
 
 // FindProfiles discovers all profiles for Chromium-based browsers
 func FindProfiles() ([]common.Profile, error) {
+	return FindUserProfiles(common.UserSelection{})
+}
+
+// FindUserProfiles discovers the Chromium-based browser profiles of the selected users
+func FindUserProfiles(selection common.UserSelection) ([]common.Profile, error) {
 	var profiles []common.Profile
 
 	// Get the paths to Chromium-based browser data directories
-	chromiumPaths := FindChromiumPaths()
+	chromiumPaths := FindChromiumUserPaths(selection)
+
+	for _, userPath := range chromiumPaths {
+		userDataDir := userPath.Path
+		browserVariant := getBrowserVariant(userDataDir)
 
-	for _, userDataDir := range chromiumPaths {
 		// Find profile directories within each user data directory
 		profileDirs, err := findProfileDirectories(userDataDir)
 		if err != nil {
-			// If we can't read a directory, continue with the next one
+			// Most candidate directories belong to browsers that are not
+			// installed; anything else is reported and skipped
+			if !os.IsNotExist(err) {
+				status.RecordProfileError("", strings.ToLower(browserVariant), userPath.User.Username, userPath.User.UID, userDataDir, err)
+			}
 			continue
 		}
+		status.ClearProfileError("", userDataDir)
 
 		// Read profile information for each profile directory
 		for _, profileDir := range profileDirs {
-			profile, err := readProfileInfo(profileDir)
+			// Refuse profiles that resolve outside the owning user's home
+			resolvedDir, ok := common.SafeProfilePath(strings.ToLower(browserVariant), profileDir, userPath.User)
+			if !ok {
+				continue
+			}
+
+			preferencesPath := filepath.Join(profileDir, "Preferences")
+			profile, err := readProfileInfo(resolvedDir, userPath.User.UID)
 			if err != nil {
-				// If we can't read a profile, continue with the next one
+				// If we can't read a profile, report it and continue with the next one
+				status.RecordProfileError("", strings.ToLower(browserVariant), userPath.User.Username, userPath.User.UID, preferencesPath, err)
 				continue
 			}
+			status.ClearProfileError("", preferencesPath)
+
+			// Identify the profile by its directory name, not its link target
+			profile.ID = filepath.Base(profileDir)
+			if profile.Name == filepath.Base(resolvedDir) {
+				profile.Name = profile.ID
+			}
 
 			// Set browser type and variant
-			profile.BrowserVariant = getBrowserVariant(userDataDir)
+			profile.BrowserVariant = browserVariant
 			profile.BrowserType = strings.ToLower(profile.BrowserVariant)
 
+			// Set the owning user
+			profile.Username = userPath.User.Username
+			profile.UID = userPath.User.UID
+			profile.HomeDir = userPath.User.HomeDir
+
 			profiles = append(profiles, profile)
 		}
 	}
@@ -121,6 +156,10 @@ func FindProfiles() ([]common.Profile, error) {
 
 // getBrowserVariant determines the browser variant based on the user data directory path
 func getBrowserVariant(userDataDir string) string {
+	if browser := customPaths.BrowserFor(userDataDir); browser != "" {
+		return browser
+	}
+
 	switch {
 	case strings.Contains(strings.ToLower(userDataDir), "chrome"):
 		return "chrome"
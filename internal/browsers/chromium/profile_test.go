@@ -0,0 +1,42 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadProfileInfo(t *testing.T) {
+	t.Run("reads_name_from_preferences", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "Profile 1")
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Preferences"), []byte(`{"name": "Work"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		profile, err := readProfileInfo(dir, "")
+		if err != nil {
+			t.Fatalf("readProfileInfo() returned error: %v", err)
+		}
+		if profile.ID != "Profile 1" || profile.Name != "Work" {
+			t.Errorf("readProfileInfo() = %+v, want Profile 1 named Work", profile)
+		}
+	})
+
+	t.Run("refuses_symlinked_preferences", func(t *testing.T) {
+		dir := t.TempDir()
+		target := filepath.Join(t.TempDir(), "shadow")
+		if err := os.WriteFile(target, []byte(`{"name": "secret"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(dir, "Preferences")); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+
+		if profile, err := readProfileInfo(dir, ""); err == nil {
+			t.Errorf("readProfileInfo() followed a symlinked Preferences, got %+v", profile)
+		}
+	})
+}
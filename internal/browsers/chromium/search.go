@@ -0,0 +1,142 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// defaultSearchPath is the Preferences entry holding a copy of the default
+// search engine, which may not be in the keywords table
+const defaultSearchPath = "default_search_provider_data.template_url_data"
+
+// maxUnixSeconds separates keyword times in seconds, written by older
+// Chromium versions, from WebKit microseconds
+const maxUnixSeconds = 1 << 40
+
+// keyword is a row of the Web Data keywords table
+type keyword struct {
+	engine        common.SearchEngine
+	guid          string
+	prepopulateID int64
+}
+
+// FindSearchEngines reads the search engines of a profile from the keywords
+// table of Web Data and marks the default named by Preferences. A default
+// engine that is only in Preferences is reported with Preferences as its
+// source.
+func FindSearchEngines(profile common.Profile) ([]common.SearchEngine, error) {
+	keywords, err := readKeywords(filepath.Join(profile.Path, webDataFile), profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := readPrefsFile(filepath.Join(profile.Path, preferencesFile), profile.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultData, _ := lookupPref(prefs, defaultSearchPath)
+	data, _ := defaultData.(map[string]interface{})
+	defaultGUID := prefText(data["synced_guid"])
+	if guid, ok := lookupPref(prefs, "default_search_provider.guid"); ok && defaultGUID == "" {
+		defaultGUID = prefText(guid)
+	}
+
+	engines := make([]common.SearchEngine, 0, len(keywords)+1)
+	foundDefault := false
+	for _, k := range keywords {
+		engine := k.engine
+		if defaultGUID != "" && k.guid == defaultGUID {
+			engine.Default = true
+			foundDefault = true
+			if searchURLHijacked(k.prepopulateID, engine.URL) {
+				engine.Risk = common.RiskSearchHijack
+			}
+		}
+		engines = append(engines, engine)
+	}
+
+	if data != nil && !foundDefault {
+		engine := common.SearchEngine{
+			Name:       prefText(data["short_name"]),
+			Keyword:    prefText(data["keyword"]),
+			URL:        prefText(data["url"]),
+			FaviconURL: prefText(data["favicon_url"]),
+			Default:    true,
+			Source:     preferencesFile,
+		}
+		if searchHijacked(data) {
+			engine.Risk = common.RiskSearchHijack
+		}
+		engines = append(engines, engine)
+	}
+	return engines, nil
+}
+
+// readKeywords reads the keywords table of a Web Data database, reading
+// columns a version lacks as empty. A missing database has no keywords.
+func readKeywords(path, uid string) ([]keyword, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(path, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "keywords")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["keywords"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("keywords.id", "keywords.url"); err != nil {
+		return nil, err
+	}
+	text := func(column string) string {
+		return schema.ColumnOr("keywords", column, "COALESCE("+column+", '')", "''")
+	}
+	number := func(column string) string {
+		return schema.ColumnOr("keywords", column, "COALESCE("+column+", 0)", "0")
+	}
+
+	rows, err := db.Query(`
+		SELECT ` + text("short_name") + `, ` + text("keyword") + `, COALESCE(url, ''), ` + text("favicon_url") + `,
+			` + number("date_created") + `, ` + number("last_modified") + `, ` + text("sync_guid") + `, ` + number("prepopulate_id") + `
+		FROM keywords
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keywords []keyword
+	for rows.Next() {
+		var k keyword
+		var created, modified int64
+		if err := rows.Scan(&k.engine.Name, &k.engine.Keyword, &k.engine.URL, &k.engine.FaviconURL,
+			&created, &modified, &k.guid, &k.prepopulateID); err != nil {
+			return nil, err
+		}
+		k.engine.Created = keywordTime(created)
+		k.engine.LastModified = keywordTime(modified)
+		k.engine.Source = webDataFile
+		keywords = append(keywords, k)
+	}
+	return keywords, rows.Err()
+}
+
+// keywordTime converts a keyword timestamp, which Chromium stored in seconds
+// since the Unix epoch before moving to WebKit microseconds
+func keywordTime(value int64) time.Time {
+	if value < maxUnixSeconds {
+		return common.FromUnixSeconds(value)
+	}
+	return common.FromWebKit(value)
+}
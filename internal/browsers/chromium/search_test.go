@@ -0,0 +1,120 @@
+package chromium
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// createKeywordsDB creates a Web Data database in dir with a prepopulated
+// engine and a user-added engine, the latter dated in seconds as older
+// Chromium versions wrote it
+func createKeywordsDB(t *testing.T, dir string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, webDataFile))
+	if err != nil {
+		t.Fatalf("Failed to create Web Data database: %v", err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE keywords (id INTEGER PRIMARY KEY, short_name VARCHAR NOT NULL, keyword VARCHAR NOT NULL,
+			favicon_url VARCHAR NOT NULL, url VARCHAR NOT NULL, date_created INTEGER DEFAULT 0,
+			last_modified INTEGER DEFAULT 0, prepopulate_id INTEGER DEFAULT 0, sync_guid VARCHAR)`,
+		`INSERT INTO keywords VALUES (1, 'Google', 'google.com', 'https://www.google.com/favicon.ico',
+			'{google:baseURL}search?q={searchTerms}', 13345000000000000, 13345000000000000, 1, 'guid-google')`,
+		`INSERT INTO keywords VALUES (2, 'Search', 'sx', '', 'https://search.example.biz/?q={searchTerms}',
+			1700000000, 1700003600, 0, 'guid-search')`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up Web Data database: %v", err)
+		}
+	}
+}
+
+func TestFindSearchEngines(t *testing.T) {
+	t.Run("default_in_keywords", func(t *testing.T) {
+		dir := t.TempDir()
+		createKeywordsDB(t, dir)
+		prefs := `{"default_search_provider_data":{"template_url_data":{"short_name":"Search",` +
+			`"url":"https://search.example.biz/?q={searchTerms}","prepopulate_id":0,"synced_guid":"guid-search"}}}`
+		if err := os.WriteFile(filepath.Join(dir, preferencesFile), []byte(prefs), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		engines, err := FindSearchEngines(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindSearchEngines() returned error: %v", err)
+		}
+		if len(engines) != 2 {
+			t.Fatalf("Expected two engines, got %+v", engines)
+		}
+
+		google := engines[0]
+		if google.Default || google.Risk != "" || google.Source != webDataFile {
+			t.Errorf("Unexpected prepopulated engine: %+v", google)
+		}
+		if got := common.ToWebKit(google.Created); got != 13345000000000000 {
+			t.Errorf("Created = %d in WebKit time, want 13345000000000000", got)
+		}
+
+		search := engines[1]
+		if !search.Default || search.Risk != common.RiskSearchHijack || search.Keyword != "sx" {
+			t.Errorf("Unexpected default engine: %+v", search)
+		}
+		if got := search.LastModified.Unix(); got != 1700003600 {
+			t.Errorf("LastModified = %d, want 1700003600", got)
+		}
+	})
+
+	t.Run("default_only_in_preferences", func(t *testing.T) {
+		dir := t.TempDir()
+		prefs := `{"default_search_provider_data":{"template_url_data":{"short_name":"DuckDuckGo",` +
+			`"keyword":"duckduckgo.com","url":"https://duckduckgo.com/?q={searchTerms}","prepopulate_id":0}}}`
+		if err := os.WriteFile(filepath.Join(dir, preferencesFile), []byte(prefs), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		engines, err := FindSearchEngines(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindSearchEngines() returned error: %v", err)
+		}
+		if len(engines) != 1 {
+			t.Fatalf("Expected one engine, got %+v", engines)
+		}
+		if e := engines[0]; !e.Default || e.Risk != "" || e.Source != preferencesFile || e.Keyword != "duckduckgo.com" {
+			t.Errorf("Unexpected engine: %+v", e)
+		}
+	})
+
+	t.Run("missing_files", func(t *testing.T) {
+		engines, err := FindSearchEngines(common.Profile{Path: t.TempDir()})
+		if err != nil || len(engines) != 0 {
+			t.Errorf("FindSearchEngines() = %+v, %v; want no engines", engines, err)
+		}
+	})
+
+	t.Run("old_columns", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, webDataFile,
+			`CREATE TABLE keywords (id INTEGER PRIMARY KEY, short_name VARCHAR NOT NULL, keyword VARCHAR NOT NULL,
+				url VARCHAR, date_created INTEGER DEFAULT 0)`,
+			`INSERT INTO keywords VALUES (1, 'Example', 'ex', 'https://search.example.com/?q={searchTerms}', 1300000000)`,
+		)
+
+		engines, err := FindSearchEngines(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindSearchEngines() returned error: %v", err)
+		}
+		want := common.SearchEngine{Name: "Example", Keyword: "ex", URL: "https://search.example.com/?q={searchTerms}",
+			Created: common.FromUnixSeconds(1300000000), Source: webDataFile}
+		if len(engines) != 1 || engines[0] != want {
+			t.Errorf("FindSearchEngines() = %+v, want [%+v]", engines, want)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package chromium
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Files holding a profile's settings. Secure Preferences holds the tracked
+// preferences on Windows and macOS, and Local State, in the user data
+// directory, holds browser-wide settings such as DNS over HTTPS.
+const (
+	preferencesFile       = "Preferences"
+	securePreferencesFile = "Secure Preferences"
+	localStateFile        = "Local State"
+)
+
+// sourceDefault is the source of settings left at their default
+const sourceDefault = "default"
+
+// restoreOnStartupURLs is session.restore_on_startup for opening the
+// startup URLs
+const restoreOnStartupURLs = "4"
+
+// prefsFile is a parsed JSON preferences file
+type prefsFile struct {
+	name string
+	tree map[string]interface{}
+}
+
+// profileSettings holds the parsed settings files of a profile, most
+// authoritative first
+type profileSettings []prefsFile
+
+// get returns the value at path and the file it was read from
+func (s profileSettings) get(path string) (interface{}, string, bool) {
+	for _, file := range s {
+		if value, ok := lookupPref(file.tree, path); ok {
+			return value, file.name, true
+		}
+	}
+	return nil, "", false
+}
+
+// FindSecuritySettings reads the security-relevant settings of a profile from
+// Secure Preferences, Preferences and the browser's Local State, and checks
+// the integrity MACs of tracked preferences. Settings that are not set are
+// reported with their default value where the default is meaningful.
+func FindSecuritySettings(profile common.Profile) ([]common.SecuritySetting, error) {
+	var settings profileSettings
+	for _, path := range []string{
+		filepath.Join(profile.Path, securePreferencesFile),
+		filepath.Join(profile.Path, preferencesFile),
+		filepath.Join(filepath.Dir(profile.Path), localStateFile),
+	} {
+		tree, err := readPrefsFile(path, profile.UID)
+		if err != nil {
+			return nil, err
+		}
+		if tree != nil {
+			settings = append(settings, prefsFile{name: filepath.Base(path), tree: tree})
+		}
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	macStatuses := make(map[string]string)
+	for _, file := range settings {
+		macs, _ := lookupPref(file.tree, "protection.macs")
+		if tree, ok := macs.(map[string]interface{}); ok {
+			for path, status := range verifyMACs(trackedMACs(tree), file.tree) {
+				macStatuses[path] = status
+			}
+		}
+	}
+
+	var results []common.SecuritySetting
+	add := func(setting common.SecuritySetting) {
+		if status, ok := macStatuses[setting.Path]; ok {
+			setting.MACStatus = status
+			if status == MACInvalid && setting.Risk == "" {
+				setting.Risk = common.RiskTampered
+			}
+			delete(macStatuses, setting.Path)
+		}
+		results = append(results, setting)
+	}
+	pref := func(name, path string, def interface{}, risk func(value interface{}) string) {
+		value, source, ok := settings.get(path)
+		if !ok {
+			if def == nil {
+				return
+			}
+			value, source = def, sourceDefault
+		}
+		setting := common.SecuritySetting{Setting: name, Value: prefText(value), Path: path, Source: source}
+		if risk != nil {
+			setting.Risk = risk(value)
+		}
+		add(setting)
+	}
+
+	// Safe Browsing is standard unless disabled or upgraded to enhanced
+	level := common.SecuritySetting{Setting: "safe_browsing_level", Value: "standard", Path: "safebrowsing.enabled", Source: sourceDefault}
+	if enabled, source, ok := settings.get("safebrowsing.enabled"); ok {
+		level.Source = source
+		if enabled == false {
+			level.Value, level.Risk = "disabled", common.RiskSafeBrowsingOff
+		}
+	}
+	if enhanced, source, ok := settings.get("safebrowsing.enhanced"); ok && enhanced == true && level.Value != "disabled" {
+		level.Value, level.Path, level.Source = "enhanced", "safebrowsing.enhanced", source
+	}
+	add(level)
+
+	pref("proxy_mode", "proxy.mode", "system", func(value interface{}) string {
+		if value == "fixed_servers" || value == "pac_script" {
+			return common.RiskProxy
+		}
+		return ""
+	})
+	pref("proxy_server", "proxy.server", nil, nil)
+	pref("proxy_pac_url", "proxy.pac_url", nil, nil)
+
+	newTabHome, _, _ := settings.get("homepage_is_newtabpage")
+	pref("homepage", "homepage", nil, func(value interface{}) string {
+		if value != "" && newTabHome != true {
+			return common.RiskStartupHomepage
+		}
+		return ""
+	})
+	pref("homepage_is_newtabpage", "homepage_is_newtabpage", nil, nil)
+	restore, _, _ := settings.get("session.restore_on_startup")
+	pref("restore_on_startup", "session.restore_on_startup", nil, nil)
+	pref("startup_urls", "session.startup_urls", nil, func(value interface{}) string {
+		if urls, ok := value.([]interface{}); ok && len(urls) > 0 && prefText(restore) == restoreOnStartupURLs {
+			return common.RiskStartupURLs
+		}
+		return ""
+	})
+
+	searchPath := "default_search_provider_data.template_url_data"
+	if engine, source, ok := settings.get(searchPath); ok {
+		data, _ := engine.(map[string]interface{})
+		risk := ""
+		if searchHijacked(data) {
+			risk = common.RiskSearchHijack
+		}
+		add(common.SecuritySetting{Setting: "default_search_name", Value: prefText(data["short_name"]), Path: searchPath, Source: source, Risk: risk})
+		results = append(results, common.SecuritySetting{Setting: "default_search_url", Value: prefText(data["url"]), Path: searchPath + ".url", Source: source, Risk: risk})
+	}
+
+	pref("dns_over_https_mode", "dns_over_https.mode", nil, func(value interface{}) string {
+		if value == "secure" {
+			return common.RiskDNSOverHTTPS
+		}
+		return ""
+	})
+	pref("dns_over_https_templates", "dns_over_https.templates", nil, func(value interface{}) string {
+		if value != "" {
+			return common.RiskDNSOverHTTPS
+		}
+		return ""
+	})
+
+	pref("extensions_developer_mode", "extensions.ui.developer_mode", false, func(value interface{}) string {
+		if value == true {
+			return common.RiskDeveloperMode
+		}
+		return ""
+	})
+	pref("download_directory", "download.default_directory", nil, nil)
+	pref("download_prompt", "download.prompt_for_download", false, nil)
+	pref("password_manager_enabled", "credentials_enable_service", true, nil)
+
+	// Tampered tracked preferences not covered above, such as extension
+	// settings, are reported on their own
+	var tampered []string
+	for path, status := range macStatuses {
+		if status == MACInvalid {
+			tampered = append(tampered, path)
+		}
+	}
+	sort.Strings(tampered)
+	for _, path := range tampered {
+		results = append(results, common.SecuritySetting{
+			Setting:   "tracked_preference",
+			Path:      path,
+			MACStatus: MACInvalid,
+			Risk:      common.RiskTampered,
+		})
+	}
+
+	return results, nil
+}
+
+// prepopulatedEngines maps the IDs of Chromium's prepopulated search
+// engines to the engines named by common.SearchEngineName
+var prepopulatedEngines = map[int64]string{
+	1: "google", 2: "yahoo", 3: "bing", 15: "yandex", 21: "baidu", 92: "duckduckgo", 101: "ecosia",
+}
+
+// searchHijacked reports whether a default search engine is neither built
+// in nor hosted by a well-known search engine
+func searchHijacked(engine map[string]interface{}) bool {
+	id, _ := strconv.ParseInt(prefText(engine["prepopulate_id"]), 10, 64)
+	return searchURLHijacked(id, prefText(engine["url"]))
+}
+
+// searchURLHijacked reports whether a search URL template points away from
+// well-known search engines. A prepopulate ID is only trusted when the URL
+// is hosted by the engine it names, since the ID is as easily rewritten as
+// the URL.
+func searchURLHijacked(prepopulateID int64, url string) bool {
+	if strings.HasPrefix(url, "{google:baseURL}") {
+		return false
+	}
+	engine := common.SearchEngineName(url)
+	if want, ok := prepopulatedEngines[prepopulateID]; ok {
+		return engine != want
+	}
+	return engine == ""
+}
+
+// readPrefsFile parses a JSON preferences file, keeping numbers as written.
+// A missing file has no preferences.
+func readPrefsFile(path, uid string) (map[string]interface{}, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	data, err := common.ReadProfileFile(path, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree map[string]interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// lookupPref returns the value at a dotted preference path
+func lookupPref(tree map[string]interface{}, path string) (interface{}, bool) {
+	var node interface{} = tree
+	for _, key := range strings.Split(path, ".") {
+		dict, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = dict[key]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// prefText formats a preference value as text; lists and dictionaries are
+// written as JSON
+func prefText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	}
+	var b strings.Builder
+	writePrefJSON(&b, value)
+	return b.String()
+}
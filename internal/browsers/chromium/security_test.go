@@ -0,0 +1,135 @@
+package chromium
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestPrefHashValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"absent", nil, ""},
+		{"string", "https://example.com/<a>", `"https://example.com/\u003Ca>"`},
+		{"list", []interface{}{"a", json.Number("4")}, `["a",4]`},
+		{"dict", map[string]interface{}{"b": true, "a": map[string]interface{}{}, "c": []interface{}{}}, `{"b":true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prefHashValue(tt.value); got != tt.want {
+				t.Errorf("prefHashValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// writePreferences writes a Preferences file whose tracked homepage and
+// startup URLs carry MACs computed with the Chromium seed, then changes the
+// startup URLs without updating their MAC
+func writePreferences(t *testing.T, dir string) {
+	t.Helper()
+
+	homepage := "https://start.example.com/"
+	startupURLs := []interface{}{"https://ads.example.net/"}
+	prefs := map[string]interface{}{
+		"homepage":               homepage,
+		"homepage_is_newtabpage": false,
+		"session":                map[string]interface{}{"restore_on_startup": 4, "startup_urls": []interface{}{"https://evil.example.org/"}},
+		"safebrowsing":           map[string]interface{}{"enabled": false},
+		"proxy":                  map[string]interface{}{"mode": "fixed_servers", "server": "127.0.0.1:8080"},
+		"extensions":             map[string]interface{}{"ui": map[string]interface{}{"developer_mode": true}},
+		"default_search_provider_data": map[string]interface{}{"template_url_data": map[string]interface{}{
+			"short_name": "Search", "url": "https://search.example.biz/?q={searchTerms}", "prepopulate_id": 0,
+		}},
+		"protection": map[string]interface{}{"macs": map[string]interface{}{
+			"homepage": prefMAC(nil, "homepage"+prefHashValue(homepage)),
+			"session":  map[string]interface{}{"startup_urls": prefMAC(nil, "session.startup_urls"+prefHashValue(startupURLs))},
+		}},
+	}
+
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, preferencesFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindSecuritySettings(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Default")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	writePreferences(t, dir)
+	localState := `{"dns_over_https": {"mode": "secure", "templates": "https://doh.example/dns-query"}}`
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), localStateFile), []byte(localState), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := FindSecuritySettings(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindSecuritySettings() returned error: %v", err)
+	}
+	bySetting := make(map[string]common.SecuritySetting)
+	for _, setting := range settings {
+		bySetting[setting.Setting] = setting
+	}
+
+	tests := []struct {
+		setting, value, source, macStatus, risk string
+	}{
+		{"safe_browsing_level", "disabled", preferencesFile, "", common.RiskSafeBrowsingOff},
+		{"proxy_mode", "fixed_servers", preferencesFile, "", common.RiskProxy},
+		{"proxy_server", "127.0.0.1:8080", preferencesFile, "", ""},
+		{"homepage", "https://start.example.com/", preferencesFile, MACValid, common.RiskStartupHomepage},
+		{"startup_urls", `["https://evil.example.org/"]`, preferencesFile, MACInvalid, common.RiskStartupURLs},
+		{"default_search_url", "https://search.example.biz/?q={searchTerms}", preferencesFile, "", common.RiskSearchHijack},
+		{"dns_over_https_mode", "secure", localStateFile, "", common.RiskDNSOverHTTPS},
+		{"extensions_developer_mode", "true", preferencesFile, "", common.RiskDeveloperMode},
+		{"password_manager_enabled", "true", sourceDefault, "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := bySetting[tt.setting]
+		if !ok {
+			t.Errorf("Missing setting %s", tt.setting)
+			continue
+		}
+		if got.Value != tt.value || got.Source != tt.source || got.MACStatus != tt.macStatus || got.Risk != tt.risk {
+			t.Errorf("%s = %+v, want value %q from %s, MAC %q, risk %q", tt.setting, got, tt.value, tt.source, tt.macStatus, tt.risk)
+		}
+	}
+
+	if settings, err := FindSecuritySettings(common.Profile{Path: t.TempDir()}); err != nil || len(settings) != 0 {
+		t.Errorf("Expected no settings without preference files, got %v, %v", settings, err)
+	}
+}
+
+func TestSearchHijacked(t *testing.T) {
+	tests := []struct {
+		engine map[string]interface{}
+		want   bool
+	}{
+		{map[string]interface{}{"url": "{google:baseURL}search?q={searchTerms}"}, false},
+		{map[string]interface{}{"url": "https://duckduckgo.com/?q={searchTerms}"}, false},
+		{map[string]interface{}{"url": "https://www.bing.com/search?q={searchTerms}", "prepopulate_id": json.Number("3")}, false},
+		{map[string]interface{}{"url": "https://search.example.biz/?q={searchTerms}", "prepopulate_id": json.Number("3")}, true},
+		{map[string]interface{}{"url": "https://www.google.com/search?q={searchTerms}", "prepopulate_id": json.Number("3")}, true},
+		{map[string]interface{}{"url": "https://www.google.xyz/search?q={searchTerms}", "prepopulate_id": json.Number("1")}, true},
+		{map[string]interface{}{"url": "https://yahoo.fun/?q={searchTerms}"}, true},
+		{map[string]interface{}{"url": "https://search.example.biz/?q={searchTerms}", "prepopulate_id": json.Number("0")}, true},
+	}
+
+	for _, tt := range tests {
+		if got := searchHijacked(tt.engine); got != tt.want {
+			t.Errorf("searchHijacked(%v) = %v, want %v", tt.engine, got, tt.want)
+		}
+	}
+}
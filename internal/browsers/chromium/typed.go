@@ -0,0 +1,165 @@
+package chromium
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Profile databases recording what users typed and the sites they use most
+const (
+	topSitesFile  = "Top Sites"
+	shortcutsFile = "Shortcuts"
+	predictorFile = "Network Action Predictor"
+)
+
+// FindTypedInput reads a profile's top sites, omnibox shortcuts and network
+// action predictor entries. Missing databases and tables are skipped, and
+// columns a version lacks are read as empty.
+func FindTypedInput(profile common.Profile) ([]common.TypedInput, error) {
+	var inputs []common.TypedInput
+	for _, read := range []struct {
+		file  string
+		query func(db *common.Database) ([]common.TypedInput, error)
+	}{
+		{topSitesFile, topSites},
+		{shortcutsFile, shortcuts},
+		{predictorFile, predictions},
+	} {
+		path := filepath.Join(profile.Path, read.file)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		db, err := common.OpenDatabase(path, profile.UID)
+		if err != nil {
+			return nil, err
+		}
+		found, err := read.query(db)
+		db.Close()
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			found[i].Source = read.file
+		}
+		inputs = append(inputs, found...)
+	}
+	return inputs, nil
+}
+
+// topSites reads the most visited sites, which older versions kept in the
+// thumbnails table
+func topSites(db *common.Database) ([]common.TypedInput, error) {
+	tables := []string{"top_sites", "thumbnails"}
+	schema, err := common.ReadSchema(db, historySchemaQuery, tables...)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if schema.Tables[table] == nil {
+			continue
+		}
+		if err := schema.Require(table+".url", table+".url_rank"); err != nil {
+			return nil, err
+		}
+
+		rows, err := db.Query(`
+			SELECT url, url_rank, ` + schema.ColumnOr(table, "title", "COALESCE(title, '')", "''") + `
+			FROM ` + table + `
+			ORDER BY url_rank
+		`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var inputs []common.TypedInput
+		for rows.Next() {
+			input := common.TypedInput{Kind: common.TypedTopSite}
+			if err := rows.Scan(&input.URL, &input.Rank, &input.Title); err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, input)
+		}
+		return inputs, rows.Err()
+	}
+	return nil, nil
+}
+
+// shortcuts reads the omnibox suggestions users picked for the text they
+// typed. The description is the page title.
+func shortcuts(db *common.Database) ([]common.TypedInput, error) {
+	schema, err := common.ReadSchema(db, historySchemaQuery, "omni_box_shortcuts")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["omni_box_shortcuts"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("omni_box_shortcuts.text", "omni_box_shortcuts.url"); err != nil {
+		return nil, err
+	}
+	lastAccess := schema.ColumnOr("omni_box_shortcuts", "last_access_time", "COALESCE(last_access_time, 0)", "0")
+
+	rows, err := db.Query(`
+		SELECT text, url, ` + schema.ColumnOr("omni_box_shortcuts", "description", "COALESCE(description, '')", "''") + `,
+			` + schema.ColumnOr("omni_box_shortcuts", "number_of_hits", "COALESCE(number_of_hits, 0)", "0") + `, ` + lastAccess + `
+		FROM omni_box_shortcuts
+		ORDER BY ` + schema.ColumnOr("omni_box_shortcuts", "last_access_time", "last_access_time", "rowid") + ` DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inputs []common.TypedInput
+	for rows.Next() {
+		input := common.TypedInput{Kind: common.TypedShortcut}
+		var lastAccess int64
+		if err := rows.Scan(&input.Text, &input.URL, &input.Title, &input.Hits, &lastAccess); err != nil {
+			return nil, err
+		}
+		input.LastUsed = common.FromWebKit(lastAccess)
+		inputs = append(inputs, input)
+	}
+	return inputs, rows.Err()
+}
+
+// predictions reads how often typed text led to each URL the omnibox
+// suggested. The predictor does not record times.
+func predictions(db *common.Database) ([]common.TypedInput, error) {
+	schema, err := common.ReadSchema(db, historySchemaQuery, "network_action_predictor")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["network_action_predictor"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("network_action_predictor.user_text", "network_action_predictor.url"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT user_text, url,
+			` + schema.ColumnOr("network_action_predictor", "number_of_hits", "COALESCE(number_of_hits, 0)", "0") + `,
+			` + schema.ColumnOr("network_action_predictor", "number_of_misses", "COALESCE(number_of_misses, 0)", "0") + `
+		FROM network_action_predictor
+		ORDER BY user_text, url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inputs []common.TypedInput
+	for rows.Next() {
+		input := common.TypedInput{Kind: common.TypedPredictor}
+		if err := rows.Scan(&input.Text, &input.URL, &input.Hits, &input.Misses); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, rows.Err()
+}
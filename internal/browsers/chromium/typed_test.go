@@ -0,0 +1,97 @@
+package chromium
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// createProfileDB creates a profile database in dir from statements
+func createProfileDB(t *testing.T, dir, file string, statements ...string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, file))
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", file, err)
+	}
+	defer db.Close()
+
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up %s: %v", file, err)
+		}
+	}
+}
+
+func TestFindTypedInput(t *testing.T) {
+	dir := t.TempDir()
+	createProfileDB(t, dir, topSitesFile,
+		`CREATE TABLE top_sites (url LONGVARCHAR NOT NULL PRIMARY KEY, url_rank INTEGER NOT NULL, title LONGVARCHAR NOT NULL)`,
+		`INSERT INTO top_sites VALUES ('https://mail.example.com/', 1, 'Mail'), ('https://example.org/', 0, 'Example')`,
+	)
+	createProfileDB(t, dir, shortcutsFile,
+		`CREATE TABLE omni_box_shortcuts (id VARCHAR PRIMARY KEY, text VARCHAR, fill_into_edit VARCHAR, url VARCHAR,
+			contents VARCHAR, contents_class VARCHAR, description VARCHAR, description_class VARCHAR,
+			transition INTEGER, type INTEGER, keyword VARCHAR, last_access_time INTEGER, number_of_hits INTEGER)`,
+		`INSERT INTO omni_box_shortcuts VALUES ('a', 'pay', 'payroll.example.com', 'https://payroll.example.com/',
+			'payroll.example.com', '', 'Payroll', '', 1, 0, '', 13345000000000000, 5)`,
+	)
+	createProfileDB(t, dir, predictorFile,
+		`CREATE TABLE network_action_predictor (id TEXT PRIMARY KEY, user_text TEXT, url TEXT,
+			number_of_hits INTEGER, number_of_misses INTEGER)`,
+		`INSERT INTO network_action_predictor VALUES ('b', 'pa', 'https://payroll.example.com/', 3, 1)`,
+	)
+
+	inputs, err := FindTypedInput(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindTypedInput() returned error: %v", err)
+	}
+	want := []common.TypedInput{
+		{Kind: common.TypedTopSite, URL: "https://example.org/", Title: "Example", Rank: 0, Source: topSitesFile},
+		{Kind: common.TypedTopSite, URL: "https://mail.example.com/", Title: "Mail", Rank: 1, Source: topSitesFile},
+		{Kind: common.TypedShortcut, Text: "pay", URL: "https://payroll.example.com/", Title: "Payroll", Hits: 5,
+			LastUsed: common.FromWebKit(13345000000000000), Source: shortcutsFile},
+		{Kind: common.TypedPredictor, Text: "pa", URL: "https://payroll.example.com/", Hits: 3, Misses: 1, Source: predictorFile},
+	}
+	if len(inputs) != len(want) {
+		t.Fatalf("FindTypedInput() = %+v, want %+v", inputs, want)
+	}
+	for i := range want {
+		if inputs[i] != want[i] {
+			t.Errorf("inputs[%d] = %+v, want %+v", i, inputs[i], want[i])
+		}
+	}
+
+	t.Run("missing_databases", func(t *testing.T) {
+		inputs, err := FindTypedInput(common.Profile{Path: t.TempDir()})
+		if err != nil || len(inputs) != 0 {
+			t.Errorf("FindTypedInput() = %+v, %v; want no input", inputs, err)
+		}
+	})
+
+	t.Run("old_columns", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, topSitesFile,
+			`CREATE TABLE thumbnails (url LONGVARCHAR PRIMARY KEY, url_rank INTEGER, thumbnail BLOB)`,
+			`INSERT INTO thumbnails VALUES ('https://example.org/', 0, NULL)`,
+		)
+		createProfileDB(t, dir, shortcutsFile,
+			`CREATE TABLE omni_box_shortcuts (id VARCHAR PRIMARY KEY, text VARCHAR, url VARCHAR, number_of_hits INTEGER)`,
+			`INSERT INTO omni_box_shortcuts VALUES ('a', 'pay', 'https://payroll.example.com/', 5)`,
+		)
+
+		inputs, err := FindTypedInput(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindTypedInput() returned error: %v", err)
+		}
+		want := []common.TypedInput{
+			{Kind: common.TypedTopSite, URL: "https://example.org/", Source: topSitesFile},
+			{Kind: common.TypedShortcut, Text: "pay", URL: "https://payroll.example.com/", Hits: 5, Source: shortcutsFile},
+		}
+		if len(inputs) != len(want) || inputs[0] != want[0] || inputs[1] != want[1] {
+			t.Errorf("FindTypedInput() = %+v, want %+v", inputs, want)
+		}
+	})
+}
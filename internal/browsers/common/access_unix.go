@@ -0,0 +1,58 @@
+//go:build !windows
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// fileOwner returns the UID owning path
+func fileOwner(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10), true
+}
+
+// openNoFollow opens path for reading, refusing a symlink at path
+func openNoFollow(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ELOOP) {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+	}
+	return file, err
+}
+
+// runAsUser configures cmd to run as the user with the given UID, with that
+// user's primary group and no supplementary groups
+func runAsUser(cmd *exec.Cmd, uid string) error {
+	userID, err := strconv.ParseUint(uid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q: %w", uid, err)
+	}
+
+	// Never take the group from the file being read, which its owner controls
+	groupID := userID
+	if account, err := user.LookupId(uid); err == nil {
+		if gid, err := strconv.ParseUint(account.Gid, 10, 32); err == nil {
+			groupID = gid
+		}
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(userID), Gid: uint32(groupID), Groups: []uint32{}},
+	}
+	return nil
+}
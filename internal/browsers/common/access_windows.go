@@ -0,0 +1,31 @@
+//go:build windows
+
+package common
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// fileOwner returns the UID owning path; Windows has no numeric UIDs
+func fileOwner(path string) (string, bool) {
+	return "", false
+}
+
+// openNoFollow opens path for reading, refusing a symlink at path
+func openNoFollow(path string) (*os.File, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrPermission}
+	}
+	return os.Open(path)
+}
+
+// runAsUser is unsupported on Windows, which has no numeric UIDs
+func runAsUser(cmd *exec.Cmd, uid string) error {
+	return errors.New("dropping privileges is not supported on Windows")
+}
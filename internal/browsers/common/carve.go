@@ -0,0 +1,495 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// SQLite file format constants
+const (
+	sqliteHeaderSize = 100
+	sqliteMagic      = "SQLite format 3\x00"
+	tableLeafPage    = 0x0d
+	utf8Encoding     = 1
+)
+
+// Regions of a database file that carved records are recovered from
+const (
+	// CarveFreelist is a page released to the freelist, typically because
+	// every row on it was deleted
+	CarveFreelist = "freelist"
+
+	// CarveFreeblock is a deleted cell inside a live table page
+	CarveFreeblock = "freeblock"
+
+	// CarveUnallocated is the gap between a live page's cell pointers and
+	// its cells, which keeps cells moved by defragmentation
+	CarveUnallocated = "unallocated"
+)
+
+// ErrNotSQLite is returned for data without a valid SQLite header
+var ErrNotSQLite = errors.New("not a SQLite database")
+
+// ErrSQLiteEncoding is returned for databases storing text as UTF-16
+var ErrSQLiteEncoding = errors.New("unsupported SQLite text encoding")
+
+// CarveLayout describes the records of the table being recovered
+type CarveLayout struct {
+	// Columns is the table's current column count
+	Columns int
+
+	// MinColumns is the fewest columns a record may have. Tables grown with
+	// ALTER TABLE keep their older records shorter.
+	MinColumns int
+
+	// RowIDAlias reports that column 0 is an INTEGER PRIMARY KEY, which is
+	// stored as NULL. It allows recovering deleted cells whose leading bytes,
+	// up to and including that column's type, were overwritten.
+	RowIDAlias bool
+
+	// Validate reports whether decoded values, padded with nil to Columns,
+	// form a plausible record of the table
+	Validate func(values []interface{}) bool
+}
+
+// CarvedRecord is a record recovered from unused space in a database file
+type CarvedRecord struct {
+	// Values are the decoded columns: nil, int64, float64, string or []byte,
+	// padded with nil to the layout's column count
+	Values []interface{}
+
+	// Page is the 1-based page the record was found on
+	Page int
+
+	// Offset is the file offset where the record's header or, when the
+	// header was partly overwritten, its first surviving column type starts
+	Offset int64
+
+	// Region is one of the carve region constants
+	Region string
+
+	// HeaderIntact reports whether the record header was found complete
+	HeaderIntact bool
+}
+
+// Confidence scores how likely the record is a genuine row, from 0 to 1. A
+// complete record on a freed page is most reliable; one rebuilt from a
+// partly overwritten header is least.
+func (r CarvedRecord) Confidence() float64 {
+	switch {
+	case !r.HeaderIntact:
+		return 0.6
+	case r.Region == CarveFreelist:
+		return 0.9
+	default:
+		return 0.8
+	}
+}
+
+// CarveRecords recovers records matching layout from the freelist pages,
+// freeblocks and unallocated space of a SQLite database file. Live cells are
+// not read; records that overflowed onto other pages are not recovered.
+func CarveRecords(data []byte, layout CarveLayout) ([]CarvedRecord, error) {
+	if len(data) < sqliteHeaderSize || string(data[:len(sqliteMagic)]) != sqliteMagic {
+		return nil, ErrNotSQLite
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, ErrNotSQLite
+	}
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding != 0 && encoding != utf8Encoding {
+		return nil, ErrSQLiteEncoding
+	}
+	usable := pageSize - int(data[20])
+	if usable < 480 {
+		return nil, ErrNotSQLite
+	}
+
+	c := &carver{layout: layout}
+	pages := len(data) / pageSize
+	freelist := freelistPages(data, pageSize, usable, pages)
+	for n := 1; n <= pages; n++ {
+		page := data[(n-1)*pageSize : (n-1)*pageSize+usable]
+		base := int64(n-1) * int64(pageSize)
+
+		if skip, ok := freelist[n]; ok {
+			c.scan(page, skip, usable, n, base, CarveFreelist)
+			continue
+		}
+
+		header := 0
+		if n == 1 {
+			header = sqliteHeaderSize
+		}
+		if page[header] != tableLeafPage {
+			continue
+		}
+
+		cells := int(binary.BigEndian.Uint16(page[header+3:]))
+		content := int(binary.BigEndian.Uint16(page[header+5:]))
+		if content == 0 {
+			content = 65536
+		}
+		if start := header + 8 + 2*cells; start < content && content <= usable {
+			c.scan(page, start, content, n, base, CarveUnallocated)
+		}
+
+		// Freeblocks form a chain in ascending order; the first four bytes of
+		// each hold the link and size over the deleted cell's leading bytes
+		block := int(binary.BigEndian.Uint16(page[header+1:]))
+		for block != 0 && block+4 <= usable {
+			next := int(binary.BigEndian.Uint16(page[block:]))
+			end := block + int(binary.BigEndian.Uint16(page[block+2:]))
+			if end > usable {
+				end = usable
+			}
+			c.scan(page, block+4, end, n, base, CarveFreeblock)
+			if next <= block {
+				break
+			}
+			block = next
+		}
+	}
+	return c.records, nil
+}
+
+// freelistPages returns the pages on the freelist, mapped to the number of
+// leading bytes that hold freelist data rather than old page content
+func freelistPages(data []byte, pageSize, usable, pages int) map[int]int {
+	freelist := make(map[int]int)
+	trunk := int(binary.BigEndian.Uint32(data[32:36]))
+	for trunk > 0 && trunk <= pages {
+		if _, seen := freelist[trunk]; seen {
+			break
+		}
+		page := data[(trunk-1)*pageSize : (trunk-1)*pageSize+usable]
+		count := int(binary.BigEndian.Uint32(page[4:8]))
+		if count > (usable-8)/4 {
+			count = (usable - 8) / 4
+		}
+		freelist[trunk] = 8 + 4*count
+		for i := 0; i < count; i++ {
+			leaf := int(binary.BigEndian.Uint32(page[8+4*i:]))
+			if leaf > 0 && leaf <= pages {
+				if _, seen := freelist[leaf]; !seen {
+					freelist[leaf] = 0
+				}
+			}
+		}
+		trunk = int(binary.BigEndian.Uint32(page[0:4]))
+	}
+	return freelist
+}
+
+// carver collects the records of one layout
+type carver struct {
+	layout  CarveLayout
+	records []CarvedRecord
+}
+
+// scan tries every offset of page[start:end] as the start of a record,
+// resuming after each record found
+func (c *carver) scan(page []byte, start, end, n int, base int64, region string) {
+	buf := page[:end]
+	for offset := start; offset < end; {
+		values, next, intact, ok := c.parse(buf, offset)
+		if !ok {
+			offset++
+			continue
+		}
+		c.records = append(c.records, CarvedRecord{
+			Values:       values,
+			Page:         n,
+			Offset:       base + int64(offset),
+			Region:       region,
+			HeaderIntact: intact,
+		})
+		offset = next
+	}
+}
+
+// parse decodes a record at offset, first with its header length and then,
+// for tables with a rowid alias, as column types following an overwritten
+// header length and rowid column type
+func (c *carver) parse(buf []byte, offset int) ([]interface{}, int, bool, bool) {
+	if values, next, ok := c.parseHeader(buf, offset); ok {
+		return values, next, true, true
+	}
+	if c.layout.RowIDAlias {
+		if values, next, ok := c.parseTypes(buf, offset); ok {
+			return values, next, false, true
+		}
+	}
+	return nil, 0, false, false
+}
+
+// parseHeader decodes a record whose header starts at offset
+func (c *carver) parseHeader(buf []byte, offset int) ([]interface{}, int, bool) {
+	headerLen, n := readVarint(buf[offset:])
+	if n == 0 || headerLen < 2 || headerLen > uint64(9*c.layout.Columns+9) || offset+int(headerLen) > len(buf) {
+		return nil, 0, false
+	}
+	headerEnd := offset + int(headerLen)
+
+	var types []uint64
+	for p := offset + n; p < headerEnd; {
+		serialType, n := readVarint(buf[p:headerEnd])
+		if n == 0 {
+			return nil, 0, false
+		}
+		types = append(types, serialType)
+		p += n
+	}
+	if len(types) == 0 || c.layout.RowIDAlias && types[0] != 0 {
+		return nil, 0, false
+	}
+	return c.decode(buf, headerEnd, types)
+}
+
+// parseTypes decodes a record whose column types, after the rowid alias,
+// start at offset
+func (c *carver) parseTypes(buf []byte, offset int) ([]interface{}, int, bool) {
+	types := []uint64{0}
+	p := offset
+	for len(types) < c.layout.Columns {
+		serialType, n := readVarint(buf[p:])
+		if n == 0 {
+			return nil, 0, false
+		}
+		types = append(types, serialType)
+		p += n
+	}
+	return c.decode(buf, p, types)
+}
+
+// decode reads the values of the given serial types from buf at body and
+// validates them against the layout
+func (c *carver) decode(buf []byte, body int, types []uint64) ([]interface{}, int, bool) {
+	if len(types) < c.layout.MinColumns || len(types) > c.layout.Columns {
+		return nil, 0, false
+	}
+
+	values := make([]interface{}, c.layout.Columns)
+	p := body
+	for i, serialType := range types {
+		size := serialSize(serialType)
+		if size < 0 || size > len(buf)-p {
+			return nil, 0, false
+		}
+		raw := buf[p : p+size]
+		p += size
+
+		switch {
+		case serialType == 0:
+		case serialType <= 6:
+			values[i] = readInt(raw)
+		case serialType == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(raw))
+		case serialType == 8:
+			values[i] = int64(0)
+		case serialType == 9:
+			values[i] = int64(1)
+		case serialType%2 == 0:
+			values[i] = append([]byte(nil), raw...)
+		default:
+			if !utf8.Valid(raw) {
+				return nil, 0, false
+			}
+			values[i] = string(raw)
+		}
+	}
+
+	if c.layout.Validate != nil && !c.layout.Validate(values) {
+		return nil, 0, false
+	}
+	return values, p, true
+}
+
+// serialSize returns the body size of a value of the given serial type, or
+// -1 for the reserved types
+func serialSize(serialType uint64) int {
+	switch {
+	case serialType <= 4:
+		return int(serialType)
+	case serialType == 5:
+		return 6
+	case serialType == 6 || serialType == 7:
+		return 8
+	case serialType == 8 || serialType == 9:
+		return 0
+	case serialType < 12:
+		return -1
+	case serialType > math.MaxInt32:
+		return -1
+	}
+	return int(serialType-12) / 2
+}
+
+// readInt decodes a big-endian two's complement integer of 1 to 8 bytes
+func readInt(raw []byte) int64 {
+	var v int64
+	if raw[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, b := range raw {
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+// readVarint decodes a SQLite variable-length integer, returning it and its
+// length, or a length of 0 if buf ends first
+func readVarint(buf []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(buf); i++ {
+		if i == 8 {
+			return v<<8 | uint64(buf[i]), 9
+		}
+		v = v<<7 | uint64(buf[i]&0x7f)
+		if buf[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// URLTable names the columns of a table of visited URLs
+type URLTable struct {
+	// Table is the table name
+	Table string
+
+	// URL, Title, VisitCount and LastVisit are column names
+	URL, Title, VisitCount, LastVisit string
+
+	// Time converts a last visit timestamp
+	Time func(int64) time.Time
+}
+
+// earliestVisit is before any browser history a carved record could hold
+var earliestVisit = time.Date(1995, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// CarveURLs recovers deleted records of a table of visited URLs from the
+// free space of db's main file. URLs still in the table and duplicate copies
+// of a record are left out. The table's current columns locate the URL,
+// title, visit count and last visit time in each record.
+func CarveURLs(db *Database, table URLTable) ([]CarvedEntry, error) {
+	columns, err := db.Columns(table.Table)
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+	index := make(map[string]int, len(columns))
+	for i, name := range columns {
+		index[name] = i
+	}
+	urlColumn, ok := index[table.URL]
+	if !ok {
+		return nil, nil
+	}
+	titleColumn, hasTitle := index[table.Title]
+	countColumn, hasCount := index[table.VisitCount]
+	visitColumn, hasVisit := index[table.LastVisit]
+
+	var rowIDAlias bool
+	err = db.QueryRow(`SELECT COUNT(*) = 1 AND SUM(cid = 0 AND UPPER(type) = 'INTEGER') = 1
+		FROM pragma_table_info(?) WHERE pk > 0`, table.Table).Scan(&rowIDAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := db.StringSet(`SELECT ` + table.URL + ` FROM ` + table.Table)
+	if err != nil {
+		return nil, err
+	}
+	data, err := db.MainFile()
+	if err != nil {
+		return nil, err
+	}
+
+	optional := func(values []interface{}, i int, ok bool, want func(interface{}) bool) bool {
+		return !ok || values[i] == nil || want(values[i])
+	}
+	isString := func(v interface{}) bool { _, ok := v.(string); return ok }
+	isCount := func(v interface{}) bool { n, ok := v.(int64); return ok && n >= 0 }
+
+	// Bytes that merely resemble a record rarely decode to a plausible visit
+	// time, which rejects most false positives of partly overwritten records
+	latestVisit := time.Now().Add(24 * time.Hour)
+	isVisitTime := func(v interface{}) bool {
+		n, ok := v.(int64)
+		if !ok {
+			return false
+		}
+		if n == 0 {
+			return true
+		}
+		t := table.Time(n)
+		return !t.Before(earliestVisit) && !t.After(latestVisit)
+	}
+
+	records, err := CarveRecords(data, CarveLayout{
+		Columns:    len(columns),
+		MinColumns: urlColumn + 1,
+		RowIDAlias: rowIDAlias,
+		Validate: func(values []interface{}) bool {
+			url, ok := values[urlColumn].(string)
+			if !ok || url == "" || ParseURL(url).Scheme == "" {
+				return false
+			}
+			return optional(values, titleColumn, hasTitle, isString) &&
+				optional(values, countColumn, hasCount, isCount) &&
+				optional(values, visitColumn, hasVisit, isVisitTime)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		url       string
+		lastVisit int64
+	}
+	best := make(map[key]int)
+	var entries []CarvedEntry
+	for _, record := range records {
+		entry := CarvedEntry{
+			URL:        record.Values[urlColumn].(string),
+			Region:     record.Region,
+			Page:       record.Page,
+			Offset:     record.Offset,
+			Confidence: record.Confidence(),
+		}
+		if live[entry.URL] {
+			continue
+		}
+		if hasTitle {
+			entry.Title, _ = record.Values[titleColumn].(string)
+		}
+		if hasCount {
+			count, _ := record.Values[countColumn].(int64)
+			entry.VisitCount = int(count)
+		}
+		var visit int64
+		if hasVisit {
+			if visit, _ = record.Values[visitColumn].(int64); visit != 0 {
+				entry.LastVisit = table.Time(visit)
+			}
+		}
+
+		k := key{entry.URL, visit}
+		if i, seen := best[k]; seen {
+			if entry.Confidence > entries[i].Confidence {
+				entries[i] = entry
+			}
+			continue
+		}
+		best[k] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,171 @@
+package common
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createCarveDB creates a database with a Chromium-style urls table, deletes
+// a run of rows large enough to free whole pages and a few single rows from
+// live pages, and commits without vacuuming. It returns the database path,
+// every inserted URL and the deleted URLs.
+func createCarveDB(t *testing.T) (string, map[string]bool, map[string]bool) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "History")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`PRAGMA page_size = 4096`,
+		`PRAGMA secure_delete = OFF`,
+		`PRAGMA auto_vacuum = NONE`,
+		`CREATE TABLE urls (id INTEGER PRIMARY KEY AUTOINCREMENT, url LONGVARCHAR, title LONGVARCHAR,
+			visit_count INTEGER DEFAULT 0 NOT NULL, typed_count INTEGER DEFAULT 0 NOT NULL,
+			last_visit_time INTEGER NOT NULL, hidden INTEGER DEFAULT 0 NOT NULL)`,
+	}
+	for i := 1; i <= 400; i++ {
+		statements = append(statements, fmt.Sprintf(
+			`INSERT INTO urls (url, title, visit_count, last_visit_time) VALUES ('https://site%d.example.com/path/%s', 'Page %d', %d, %d)`,
+			i, strings.Repeat("x", i%90), i, i%7, 13340000000000000+int64(i)*1000000))
+	}
+	statements = append(statements,
+		`DELETE FROM urls WHERE id BETWEEN 100 AND 299`,
+		`DELETE FROM urls WHERE id IN (10, 20, 350)`,
+	)
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+
+	inserted, deleted := make(map[string]bool), make(map[string]bool)
+	for i := 1; i <= 400; i++ {
+		url := fmt.Sprintf("https://site%d.example.com/path/%s", i, strings.Repeat("x", i%90))
+		inserted[url] = true
+		if i >= 100 && i <= 299 || i == 10 || i == 20 || i == 350 {
+			deleted[url] = true
+		}
+	}
+	return path, inserted, deleted
+}
+
+func TestCarveRecords(t *testing.T) {
+	path, inserted, deleted := createCarveDB(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := CarveRecords(data, CarveLayout{
+		Columns:    7,
+		MinColumns: 7,
+		RowIDAlias: true,
+		Validate: func(values []interface{}) bool {
+			url, ok := values[1].(string)
+			_, isTime := values[5].(int64)
+			return ok && strings.HasPrefix(url, "https://") && isTime
+		},
+	})
+	if err != nil {
+		t.Fatalf("CarveRecords() returned error: %v", err)
+	}
+
+	// Page splits leave stale copies of live rows behind, so every carved
+	// record must be an inserted row but not necessarily a deleted one
+	found := make(map[string]CarvedRecord)
+	for _, record := range records {
+		url := record.Values[1].(string)
+		if !inserted[url] {
+			t.Errorf("Carved a record that was never inserted: %q", url)
+		}
+		if deleted[url] {
+			found[url] = record
+		}
+	}
+
+	// Rebalancing overwrites some deleted cells; every deleted row whose URL
+	// is still in the file must be recovered, including rows deleted from
+	// live pages whose leading bytes the freeblock header overwrote
+	for url := range deleted {
+		if _, ok := found[url]; !ok && bytes.Contains(data, []byte(url)) {
+			t.Errorf("Deleted record %s is in the file but was not recovered", url)
+		}
+	}
+	if len(found) < len(deleted)/2 {
+		t.Errorf("Recovered %d of %d deleted records", len(found), len(deleted))
+	}
+	for _, url := range []string{"https://site10.example.com/path/xxxxxxxxxx", "https://site20.example.com/path/" + strings.Repeat("x", 20)} {
+		record, ok := found[url]
+		if !ok {
+			t.Errorf("Expected to recover %s from a freeblock", url)
+			continue
+		}
+		if record.Region != CarveFreeblock || record.HeaderIntact || record.Confidence() != 0.6 {
+			t.Errorf("%s recovered as %+v, want a freeblock record with an overwritten header", url, record)
+		}
+	}
+
+	regions := make(map[string]int)
+	for _, record := range found {
+		regions[record.Region]++
+		if title, _ := record.Values[2].(string); !strings.HasPrefix(title, "Page ") {
+			t.Errorf("Unexpected title in %+v", record)
+		}
+	}
+	if regions[CarveFreelist] == 0 {
+		t.Errorf("Expected records from freelist pages, got %v", regions)
+	}
+}
+
+func TestCarveRecordsRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrNotSQLite},
+		{"not_sqlite", []byte(strings.Repeat("x", 200)), ErrNotSQLite},
+		{"bad_page_size", append([]byte(sqliteMagic), make([]byte, 100)...), ErrNotSQLite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CarveRecords(tt.data, CarveLayout{Columns: 1}); !errors.Is(err, tt.want) {
+				t.Errorf("CarveRecords() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	tests := []struct {
+		name  string
+		buf   []byte
+		value uint64
+		n     int
+	}{
+		{"one_byte", []byte{0x7f}, 0x7f, 1},
+		{"two_bytes", []byte{0x81, 0x00}, 0x80, 2},
+		{"nine_bytes", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 1<<64 - 1, 9},
+		{"truncated", []byte{0x81}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, n := readVarint(tt.buf)
+			if value != tt.value || n != tt.n {
+				t.Errorf("readVarint() = %d, %d; want %d, %d", value, n, tt.value, tt.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Cursor is a high-water mark into a single browser history database
+type Cursor struct {
+	// VisitID is the highest visit row ID returned so far
+	VisitID int64 `json:"visit_id"`
+
+	// VisitTime is the visit time of the row identified by VisitID
+	VisitTime time.Time `json:"visit_time"`
+}
+
+// IsZero reports whether the cursor has never been advanced
+func (c Cursor) IsZero() bool {
+	return c.VisitID == 0 && c.VisitTime.IsZero()
+}
+
+// Advance returns a cursor pointing at the entry with the highest visit ID, or
+// the unchanged cursor if there are no entries. The previous mark is not
+// compared so that a cursor can move backwards after history was cleared.
+func (c Cursor) Advance(entries []HistoryEntry) Cursor {
+	if len(entries) == 0 {
+		return c
+	}
+
+	next := Cursor{VisitID: entries[0].VisitID, VisitTime: entries[0].VisitTime}
+	for _, entry := range entries[1:] {
+		if entry.VisitID > next.VisitID {
+			next = Cursor{VisitID: entry.VisitID, VisitTime: entry.VisitTime}
+		}
+	}
+	return next
+}
+
+// cursorNameRegex restricts cursor names to characters that are safe in file names
+var cursorNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// ValidCursorName reports whether name can be used as a cursor name
+func ValidCursorName(name string) bool {
+	return cursorNameRegex.MatchString(name) && name != "." && name != ".."
+}
+
+// CursorStore persists named sets of cursors, keyed by database path, as small
+// JSON state files so differential queries survive extension restarts
+type CursorStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewCursorStore returns a store that keeps its state files under dir
+func NewCursorStore(dir string) (*CursorStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("cursor state directory not configured")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating cursor state directory: %w", err)
+	}
+	return &CursorStore{dir: dir}, nil
+}
+
+// Dir returns the directory holding the state files
+func (s *CursorStore) Dir() string {
+	return s.dir
+}
+
+// Update loads the cursors saved under name, passes them to fn, and saves the
+// result. The whole sequence is serialized so concurrent queries using the same
+// cursor name do not return overlapping rows.
+func (s *CursorStore) Update(name string, fn func(map[string]Cursor) (map[string]Cursor, error)) error {
+	if !ValidCursorName(name) {
+		return fmt.Errorf("invalid cursor name %q", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursors, err := s.load(name)
+	if err != nil {
+		return err
+	}
+
+	cursors, err = fn(cursors)
+	if err != nil {
+		return err
+	}
+
+	return s.save(name, cursors)
+}
+
+// cursorPath returns the state file path for a cursor name
+func (s *CursorStore) cursorPath(name string) string {
+	return filepath.Join(s.dir, "cursor-"+name+".json")
+}
+
+// load reads the cursors saved under name, returning an empty set if none exist
+func (s *CursorStore) load(name string) (map[string]Cursor, error) {
+	cursors := make(map[string]Cursor)
+
+	data, err := os.ReadFile(s.cursorPath(name))
+	if os.IsNotExist(err) {
+		return cursors, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cursor %s: %w", name, err)
+	}
+
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("parsing cursor %s: %w", name, err)
+	}
+
+	return cursors, nil
+}
+
+// save atomically replaces the state file for name
+func (s *CursorStore) save(name string, cursors map[string]Cursor) error {
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".cursor-*")
+	if err != nil {
+		return fmt.Errorf("writing cursor %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing cursor %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing cursor %s: %w", name, err)
+	}
+
+	return os.Rename(tmp.Name(), s.cursorPath(name))
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCursorAdvance(t *testing.T) {
+	base := time.Unix(1640995200, 0)
+
+	t.Run("empty_entries_keep_cursor", func(t *testing.T) {
+		cursor := Cursor{VisitID: 5, VisitTime: base}
+		if got := cursor.Advance(nil); got != cursor {
+			t.Errorf("Advance(nil) = %+v, expected %+v", got, cursor)
+		}
+	})
+
+	t.Run("moves_to_highest_visit_id", func(t *testing.T) {
+		entries := []HistoryEntry{
+			{VisitID: 7, VisitTime: base.Add(2 * time.Second)},
+			{VisitID: 9, VisitTime: base.Add(time.Second)},
+			{VisitID: 8, VisitTime: base.Add(3 * time.Second)},
+		}
+
+		got := Cursor{VisitID: 6, VisitTime: base}.Advance(entries)
+		if got.VisitID != 9 || !got.VisitTime.Equal(base.Add(time.Second)) {
+			t.Errorf("Advance() = %+v, expected visit 9", got)
+		}
+	})
+
+	t.Run("moves_backwards_after_reset", func(t *testing.T) {
+		entries := []HistoryEntry{{VisitID: 2, VisitTime: base}}
+
+		got := Cursor{VisitID: 500, VisitTime: base.Add(-time.Hour)}.Advance(entries)
+		if got.VisitID != 2 {
+			t.Errorf("Advance() = %+v, expected visit 2", got)
+		}
+	})
+}
+
+func TestValidCursorName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"default", true},
+		{"siem_feed-1.hourly", true},
+		{"", false},
+		{"..", false},
+		{"../etc/passwd", false},
+		{"with space", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidCursorName(tt.name); got != tt.valid {
+			t.Errorf("ValidCursorName(%q) = %v, expected %v", tt.name, got, tt.valid)
+		}
+	}
+}
+
+func TestCursorStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+
+	store, err := NewCursorStore(dir)
+	if err != nil {
+		t.Fatalf("NewCursorStore() returned error: %v", err)
+	}
+
+	visitTime := time.Unix(1640995200, 123456000).UTC()
+	err = store.Update("siem", func(cursors map[string]Cursor) (map[string]Cursor, error) {
+		if len(cursors) != 0 {
+			t.Errorf("Expected no saved cursors, got %d", len(cursors))
+		}
+		cursors["chrome:/home/user/.config/google-chrome/Default"] = Cursor{VisitID: 42, VisitTime: visitTime}
+		return cursors, nil
+	})
+	if err != nil {
+		t.Fatalf("Update() returned error: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "cursor-siem.json"))
+	if err != nil {
+		t.Fatalf("Cursor state file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Cursor state file mode = %v, expected 0600", info.Mode().Perm())
+	}
+
+	// A new store over the same directory simulates an extension restart
+	reopened, err := NewCursorStore(dir)
+	if err != nil {
+		t.Fatalf("NewCursorStore() returned error: %v", err)
+	}
+
+	err = reopened.Update("siem", func(cursors map[string]Cursor) (map[string]Cursor, error) {
+		got := cursors["chrome:/home/user/.config/google-chrome/Default"]
+		if got.VisitID != 42 || !got.VisitTime.Equal(visitTime) {
+			t.Errorf("Reloaded cursor = %+v, expected visit 42 at %v", got, visitTime)
+		}
+		return cursors, nil
+	})
+	if err != nil {
+		t.Fatalf("Update() returned error: %v", err)
+	}
+
+	if err := store.Update("../escape", func(c map[string]Cursor) (map[string]Cursor, error) { return c, nil }); err == nil {
+		t.Error("Expected error for invalid cursor name")
+	}
+}
@@ -0,0 +1,107 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CustomPath is an additional browser data directory configured by the operator.
+// A path starting with "~/" is resolved against the home directory of every
+// enumerated user; any other path is used as is.
+type CustomPath struct {
+	// Path is the browser data directory
+	Path string
+
+	// Browser is the browser variant reported for profiles found under Path.
+	// When empty the variant is detected from the path as for built-in paths.
+	Browser string
+}
+
+// IsPerUser reports whether the path is resolved against each user's home
+func (c CustomPath) IsPerUser() bool {
+	return strings.HasPrefix(c.Path, "~/")
+}
+
+// Resolve returns the path for a user with the given home directory
+func (c CustomPath) Resolve(homeDir string) string {
+	if !c.IsPerUser() {
+		return filepath.Clean(c.Path)
+	}
+	return filepath.Join(homeDir, filepath.FromSlash(strings.TrimPrefix(c.Path, "~/")))
+}
+
+// Matches reports whether dir is this custom path, resolved for any user
+func (c CustomPath) Matches(dir string) bool {
+	if !c.IsPerUser() {
+		return filepath.Clean(c.Path) == filepath.Clean(dir)
+	}
+	suffix := string(filepath.Separator) + filepath.FromSlash(strings.TrimPrefix(c.Path, "~/"))
+	return strings.HasSuffix(filepath.Clean(dir), filepath.Clean(suffix))
+}
+
+// CustomPathSet is a concurrency-safe list of custom paths for one browser engine
+type CustomPathSet struct {
+	mu    sync.RWMutex
+	paths []CustomPath
+}
+
+// Set replaces the custom paths
+func (s *CustomPathSet) Set(paths []CustomPath) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths = append([]CustomPath(nil), paths...)
+}
+
+// ForUser returns the existing per-user custom paths for a user
+func (s *CustomPathSet) ForUser(user UserInfo) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var paths []string
+	for _, custom := range s.paths {
+		if custom.IsPerUser() {
+			if path := custom.Resolve(user.HomeDir); pathExists(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// Global returns the existing custom paths that are not resolved per user
+func (s *CustomPathSet) Global() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var paths []string
+	for _, custom := range s.paths {
+		if !custom.IsPerUser() {
+			if path := custom.Resolve(""); pathExists(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// BrowserFor returns the configured browser variant for a data directory, or
+// an empty string if dir is not a custom path with a browser set
+func (s *CustomPathSet) BrowserFor(dir string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, custom := range s.paths {
+		if custom.Browser != "" && custom.Matches(dir) {
+			return custom.Browser
+		}
+	}
+	return ""
+}
+
+// pathExists reports whether a file or directory exists at path
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
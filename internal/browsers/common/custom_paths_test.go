@@ -0,0 +1,39 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCustomPathSet(t *testing.T) {
+	home := t.TempDir()
+	perUser := filepath.Join(home, ".config", "thorium")
+	if err := os.MkdirAll(perUser, 0755); err != nil {
+		t.Fatalf("Failed to create custom path: %v", err)
+	}
+	global := t.TempDir()
+
+	var set CustomPathSet
+	set.Set([]CustomPath{
+		{Path: "~/.config/thorium", Browser: "thorium"},
+		{Path: global},
+		{Path: "~/.config/missing"},
+	})
+
+	user := UserInfo{Username: "alice", HomeDir: home}
+	if paths := set.ForUser(user); len(paths) != 1 || paths[0] != perUser {
+		t.Errorf("ForUser() = %v, expected [%s]", paths, perUser)
+	}
+
+	if paths := set.Global(); len(paths) != 1 || paths[0] != global {
+		t.Errorf("Global() = %v, expected [%s]", paths, global)
+	}
+
+	if browser := set.BrowserFor(perUser); browser != "thorium" {
+		t.Errorf("BrowserFor(%s) = %q, expected thorium", perUser, browser)
+	}
+	if browser := set.BrowserFor(global); browser != "" {
+		t.Errorf("BrowserFor(%s) = %q, expected none", global, browser)
+	}
+}
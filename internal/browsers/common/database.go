@@ -0,0 +1,281 @@
+package common
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// AccessOptions controls how browser databases are read
+type AccessOptions struct {
+	// SnapshotDir is where private snapshot directories are created; empty
+	// uses the system temporary directory
+	SnapshotDir string
+
+	// DropPrivileges reads other users' files through Helper running as the
+	// owning user when the extension runs as root
+	DropPrivileges bool
+
+	// AllowedPaths are directories outside users' homes that profile paths
+	// may resolve into
+	AllowedPaths []string
+
+	// Helper is the command that copies the file named by its final argument
+	// to stdout, exiting with one of the ReadHelper exit codes
+	Helper []string
+}
+
+// Exit codes of the read helper
+const (
+	ReadHelperOK         = 0
+	ReadHelperFailed     = 1
+	ReadHelperNotExist   = 2
+	ReadHelperPermission = 3
+)
+
+var accessOptions atomic.Pointer[AccessOptions]
+
+// SetAccessOptions replaces the options used to read browser databases
+func SetAccessOptions(opts AccessOptions) {
+	accessOptions.Store(&opts)
+}
+
+// currentAccessOptions returns the options used to read browser databases
+func currentAccessOptions() AccessOptions {
+	if opts := accessOptions.Load(); opts != nil {
+		return *opts
+	}
+	return AccessOptions{}
+}
+
+// databaseCompanions are the files SQLite keeps next to a database whose
+// contents are part of the database
+var databaseCompanions = []string{"-wal", "-journal"}
+
+// Database is a read-only connection to a private snapshot of a browser
+// database. Reading a snapshot sees writes still held in the browser's WAL
+// and never contends with the browser's locks.
+type Database struct {
+	*sql.DB
+	dir  string
+	file string
+}
+
+// OpenDatabase snapshots the database at path, owned by the user with the
+// given UID, into a private directory and opens the snapshot. The snapshot
+// is removed by Close.
+func OpenDatabase(path string, uid string) (*Database, error) {
+	opts := currentAccessOptions()
+
+	dir, err := os.MkdirTemp(opts.SnapshotDir, "browser-snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("creating snapshot directory: %w", err)
+	}
+
+	snapshot := filepath.Join(dir, filepath.Base(path))
+	if err := copyPrivate(path, snapshot, uid, opts); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	for _, suffix := range databaseCompanions {
+		err := copyPrivate(path+suffix, snapshot+suffix, uid, opts)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", snapshot))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Database{DB: db, dir: dir, file: snapshot}, nil
+}
+
+// Close closes the connection and removes the snapshot
+func (d *Database) Close() error {
+	err := d.DB.Close()
+	if removeErr := os.RemoveAll(d.dir); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// ReadProfileFile reads a file of a profile owned by the user with the given
+// UID, such as a JSON preferences file, the same way databases are copied:
+// as the owner when privileges are dropped, and never through a symlink.
+func ReadProfileFile(path string, uid string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := readOwned(path, uid, currentAccessOptions(), &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// StringSet runs a single-column query and returns the distinct values
+func (d *Database) StringSet(query string) (map[string]bool, error) {
+	rows, err := d.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := make(map[string]bool)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		set[value] = true
+	}
+	return set, rows.Err()
+}
+
+// MainFile returns the raw contents of the snapshot's main database file,
+// without the pages held in its WAL
+func (d *Database) MainFile() ([]byte, error) {
+	return os.ReadFile(d.file)
+}
+
+// Columns returns the column names of a table in order
+func (d *Database) Columns(table string) ([]string, error) {
+	rows, err := d.Query(`SELECT name FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// HasTable reports whether the database has a table with the given name
+func (d *Database) HasTable(name string) (bool, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	return count > 0, err
+}
+
+// ReadStringSet runs a single-column query against a private snapshot of
+// the database at path and returns the distinct values. A missing database
+// has none.
+func ReadStringSet(path, uid, query string) (map[string]bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return make(map[string]bool), nil
+	}
+
+	db, err := OpenDatabase(path, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.StringSet(query)
+}
+
+// copyPrivate copies src to a new file at dst readable only by the
+// extension, reading it with readOwned
+func copyPrivate(src, dst, uid string, opts AccessOptions) error {
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+
+	err = readOwned(src, uid, opts, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+// readOwned copies the file at path, owned by the user with the given UID,
+// to w. Other users' files are read as their owner when privileges are
+// dropped, and symlinks are never followed.
+func readOwned(path, uid string, opts AccessOptions, w io.Writer) error {
+	if opts.DropPrivileges && len(opts.Helper) > 0 && os.Geteuid() == 0 && uid != "" && uid != "0" {
+		return readAsUser(path, uid, opts.Helper, w)
+	}
+	return readNoFollow(path, w)
+}
+
+// readNoFollow copies the regular file at path to w without following a
+// symlink at path
+func readNoFollow(path string, w io.Writer) error {
+	file, err := openNoFollow(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+	}
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// readAsUser copies path to w by running helper as the user with the given UID
+func readAsUser(path, uid string, helper []string, w io.Writer) error {
+	cmd := exec.Command(helper[0], append(helper[1:], path)...)
+	if err := runAsUser(cmd, uid); err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case ReadHelperNotExist:
+			return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+		case ReadHelperPermission:
+			return &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+		}
+		return fmt.Errorf("reading %s as uid %s: %s", path, uid, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return err
+}
+
+// RunReadHelper copies the file at path to w and returns the helper exit code.
+// It is the body of the helper process used when privileges are dropped.
+func RunReadHelper(path string, w io.Writer, errOut io.Writer) int {
+	err := readNoFollow(path, w)
+	switch {
+	case err == nil:
+		return ReadHelperOK
+	case errors.Is(err, fs.ErrNotExist):
+		return ReadHelperNotExist
+	case errors.Is(err, fs.ErrPermission):
+		return ReadHelperPermission
+	default:
+		fmt.Fprintln(errOut, err)
+		return ReadHelperFailed
+	}
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOpenDatabase(t *testing.T) {
+	snapshotDir := t.TempDir()
+	SetAccessOptions(AccessOptions{SnapshotDir: snapshotDir})
+	defer SetAccessOptions(AccessOptions{})
+
+	path := filepath.Join(t.TempDir(), "History")
+	source, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	source.SetMaxOpenConns(1)
+
+	// Rows written in WAL mode stay in History-wal while the browser is open
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA wal_autocheckpoint=0",
+		"CREATE TABLE urls (url TEXT)",
+		"INSERT INTO urls VALUES ('https://example.com')",
+	} {
+		if _, err := source.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	t.Run("reads_uncheckpointed_writes", func(t *testing.T) {
+		db, err := OpenDatabase(path, "")
+		if err != nil {
+			t.Fatalf("OpenDatabase() error = %v", err)
+		}
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM urls").Scan(&count); err != nil {
+			t.Fatalf("query error = %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 row from the snapshot, got %d", count)
+		}
+
+		entries, _ := os.ReadDir(snapshotDir)
+		if len(entries) != 1 {
+			t.Fatalf("Expected one snapshot directory, got %d", len(entries))
+		}
+		if runtime.GOOS != "windows" {
+			info, _ := os.Stat(filepath.Join(snapshotDir, entries[0].Name(), "History"))
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("Snapshot mode = %o, want 600", perm)
+			}
+		}
+
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+		if entries, _ := os.ReadDir(snapshotDir); len(entries) != 0 {
+			t.Errorf("Expected the snapshot to be removed, found %d entries", len(entries))
+		}
+	})
+
+	t.Run("missing_database", func(t *testing.T) {
+		_, err := OpenDatabase(filepath.Join(t.TempDir(), "History"), "")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("OpenDatabase() error = %v, want not exist", err)
+		}
+	})
+
+	t.Run("symlink_refused", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "History")
+		if err := os.Symlink(path, link); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+		_, err := OpenDatabase(link, "")
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("OpenDatabase() error = %v, want permission denied", err)
+		}
+	})
+}
+
+func TestReadProfileFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Preferences")
+	if err := os.WriteFile(path, []byte(`{"name":"Work"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ReadProfileFile(path, "")
+	if err != nil || string(data) != `{"name":"Work"}` {
+		t.Errorf("ReadProfileFile() = %q, %v", data, err)
+	}
+
+	if _, err := ReadProfileFile(filepath.Join(t.TempDir(), "Preferences"), ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadProfileFile() error = %v, want not exist", err)
+	}
+
+	link := filepath.Join(t.TempDir(), "Preferences")
+	if err := os.Symlink(path, link); err == nil {
+		if _, err := ReadProfileFile(link, ""); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("ReadProfileFile() error = %v, want permission denied", err)
+		}
+	}
+}
+
+func TestRunReadHelper(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "places.sqlite")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := RunReadHelper(path, &out, &errOut); code != ReadHelperOK || out.String() != "data" {
+		t.Errorf("RunReadHelper() = %d, %q", code, out.String())
+	}
+	if code := RunReadHelper(filepath.Join(dir, "missing"), &out, &errOut); code != ReadHelperNotExist {
+		t.Errorf("RunReadHelper(missing) = %d, want %d", code, ReadHelperNotExist)
+	}
+}
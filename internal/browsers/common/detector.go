@@ -1,14 +1,13 @@
 package common
 
 import (
-	"bufio"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
+	"sync"
 )
 
 // UserInfo represents information about a system user
@@ -19,64 +18,92 @@ type UserInfo struct {
 	IsAccessible bool
 }
 
-// usersFromContext returns a list of all system users
-func UsersFromContext() ([]UserInfo, error) {
-	switch runtime.GOOS {
-	case "windows":
-		return getUsersWindows()
-	case "darwin":
-		return getUsersMacOS()
-	default:
-		return getUsersLinux()
-	}
+// UserFilter restricts which users are enumerated
+type UserFilter struct {
+	// Include lists the only usernames to enumerate; empty means all users
+	Include []string
+
+	// Exclude lists usernames that are never enumerated
+	Exclude []string
+
+	// MinUID and MaxUID bound the UIDs treated as regular users on Linux.
+	// Unset bounds come from UID_MIN and UID_MAX in /etc/login.defs.
+	MinUID *int
+	MaxUID *int
+
+	// SkipLoginDefs ignores /etc/login.defs and uses the default UID range
+	SkipLoginDefs bool
+
+	// Sources lists the Linux user sources in order; empty uses DefaultUserSources
+	Sources []string
+
+	// HomeParents are scanned by the home_scan source; empty uses DefaultHomeParents
+	HomeParents []string
 }
 
-// getUsersLinux enumerates users on Linux systems
-func getUsersLinux() ([]UserInfo, error) {
-	var users []UserInfo
+// DefaultMinUID is the lowest regular user UID on most Linux distributions
+const DefaultMinUID = 1000
 
-	file, err := os.Open("/etc/passwd")
-	if err != nil {
-		log.Printf("Warning: Failed to open /etc/passwd for user enumeration: %v", err)
-		return users, err
-	}
-	defer file.Close()
+var (
+	userFilterMu sync.RWMutex
+	userFilter   UserFilter
+)
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Split(line, ":")
+// SetUserFilter replaces the filter applied by UsersFromContext
+func SetUserFilter(filter UserFilter) {
+	userFilterMu.Lock()
+	defer userFilterMu.Unlock()
+	userFilter = filter
+}
 
-		if len(fields) < 6 {
-			continue
+// currentUserFilter returns the filter applied by UsersFromContext
+func currentUserFilter() UserFilter {
+	userFilterMu.RLock()
+	defer userFilterMu.RUnlock()
+	return userFilter
+}
+
+// Allows reports whether the filter admits the given username
+func (f UserFilter) Allows(username string) bool {
+	for _, excluded := range f.Exclude {
+		if excluded == username {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, included := range f.Include {
+		if included == username {
+			return true
 		}
+	}
+	return false
+}
 
-		username := fields[0]
-		uid := fields[2]
-		homeDir := fields[5]
-
-		// Skip system users (UID < 1000) and users without valid home directories
-		if uidInt, err := strconv.Atoi(uid); err == nil && uidInt >= 1000 {
-			if strings.HasPrefix(homeDir, "/home/") || strings.HasPrefix(homeDir, "/Users/") {
-				user := UserInfo{
-					Username: username,
-					HomeDir:  homeDir,
-					UID:      uid,
-				}
-
-				// Check if home directory is accessible
-				if _, err := os.Stat(homeDir); err == nil {
-					user.IsAccessible = true
-				} else {
-					log.Printf("Debug: User %s home directory not accessible: %v", username, err)
-				}
-
-				users = append(users, user)
-			}
+// UsersFromContext returns a list of all system users allowed by the user filter
+func UsersFromContext() ([]UserInfo, error) {
+	var users []UserInfo
+	var err error
+
+	filter := currentUserFilter()
+	switch runtime.GOOS {
+	case "windows":
+		users, err = getUsersWindows()
+	case "darwin":
+		users, err = getUsersMacOS()
+	default:
+		users, err = getUsersLinux(filter)
+	}
+
+	var allowed []UserInfo
+	for _, user := range users {
+		if filter.Allows(user.Username) {
+			allowed = append(allowed, user)
 		}
 	}
 
-	return users, scanner.Err()
+	return allowed, err
 }
 
 // getUsersMacOS enumerates users on macOS systems
@@ -87,7 +114,7 @@ func getUsersMacOS() ([]UserInfo, error) {
 	cmd := exec.Command("dscl", ".", "list", "/Users")
 	output, err := cmd.Output()
 	if err != nil {
-		log.Printf("Warning: Failed to enumerate macOS users with dscl: %v", err)
+		slog.Warn("Failed to enumerate macOS users with dscl", "error", err)
 		return users, err
 	}
 
@@ -102,7 +129,7 @@ func getUsersMacOS() ([]UserInfo, error) {
 		cmd := exec.Command("dscl", ".", "read", "/Users/"+username)
 		userOutput, err := cmd.Output()
 		if err != nil {
-			log.Printf("Debug: Failed to get user info for %s: %v", username, err)
+			slog.Debug("Failed to get user info", "user", username, "error", err)
 			continue
 		}
 
@@ -130,7 +157,7 @@ func getUsersMacOS() ([]UserInfo, error) {
 			if _, err := os.Stat(homeDir); err == nil {
 				user.IsAccessible = true
 			} else {
-				log.Printf("Debug: User %s home directory not accessible: %v", username, err)
+				slog.Debug("User home directory not accessible", "user", username, "home", homeDir, "error", err)
 			}
 
 			users = append(users, user)
@@ -148,7 +175,7 @@ func getUsersWindows() ([]UserInfo, error) {
 	usersDir := filepath.Join("C:", "Users")
 	entries, err := os.ReadDir(usersDir)
 	if err != nil {
-		log.Printf("Warning: Failed to read Windows users directory: %v", err)
+		slog.Warn("Failed to read Windows users directory", "error", err)
 		return users, err
 	}
 
@@ -174,7 +201,7 @@ func getUsersWindows() ([]UserInfo, error) {
 		if _, err := os.Stat(homeDir); err == nil {
 			user.IsAccessible = true
 		} else {
-			log.Printf("Debug: User %s home directory not accessible: %v", username, err)
+			slog.Debug("User home directory not accessible", "user", username, "home", homeDir, "error", err)
 		}
 
 		users = append(users, user)
@@ -39,7 +39,7 @@ func TestGetUsersLinux(t *testing.T) {
 		t.Skip("Skipping Linux-specific test on non-Linux OS")
 	}
 
-	users, err := getUsersLinux()
+	users, err := getUsersLinux(UserFilter{})
 
 	// Test should handle both success and failure gracefully
 	if err != nil {
@@ -130,3 +130,26 @@ func TestUserInfoStructure(t *testing.T) {
 		t.Error("UserInfo IsAccessible not set correctly")
 	}
 }
+
+func TestUserFilterAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   UserFilter
+		username string
+		expected bool
+	}{
+		{"empty_filter_allows_all", UserFilter{}, "alice", true},
+		{"included_user", UserFilter{Include: []string{"alice"}}, "alice", true},
+		{"not_included_user", UserFilter{Include: []string{"alice"}}, "bob", false},
+		{"excluded_user", UserFilter{Exclude: []string{"bob"}}, "bob", false},
+		{"exclude_wins_over_include", UserFilter{Include: []string{"bob"}, Exclude: []string{"bob"}}, "bob", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allows(tt.username); got != tt.expected {
+				t.Errorf("Allows(%q) = %v, expected %v", tt.username, got, tt.expected)
+			}
+		})
+	}
+}
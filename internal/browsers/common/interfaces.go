@@ -22,6 +22,70 @@ type Browser interface {
 	FindHistory(profile Profile) ([]HistoryEntry, error)
 }
 
+// HistorySource describes how to discover and read history for one browser engine
+type HistorySource struct {
+	// Engine is the display name of the browser engine
+	Engine string
+
+	// FindProfiles discovers the profiles of the selected users
+	FindProfiles func(selection UserSelection) ([]Profile, error)
+
+	// FindHistorySince discovers history entries recorded after a cursor
+	FindHistorySince func(profile Profile, cursor Cursor) ([]HistoryEntry, error)
+
+	// FindHistoryMatching discovers history entries recorded after a cursor,
+	// preselecting in SQL the URLs that may match a filter
+	FindHistoryMatching func(profile Profile, cursor Cursor, filter URLFilter) ([]HistoryEntry, error)
+
+	// DatabaseFiles are the file names within a profile directory whose
+	// changes indicate that new history was written
+	DatabaseFiles []string
+
+	// FindAutofill reads the saved form entries of a profile
+	FindAutofill func(profile Profile, opts AutofillOptions) ([]AutofillEntry, error)
+
+	// FindPermissions reads the per-site permissions of a profile
+	FindPermissions func(profile Profile) ([]SitePermission, error)
+
+	// FindPreferences reads the preferences set in a profile's preference
+	// files; nil for engines without readable preference files
+	FindPreferences func(profile Profile) ([]Preference, error)
+
+	// FindSecuritySettings reads the security-relevant settings of a
+	// profile; nil for engines without them
+	FindSecuritySettings func(profile Profile) ([]SecuritySetting, error)
+
+	// FindSearchEngines reads the search engines configured in a profile
+	FindSearchEngines func(profile Profile) ([]SearchEngine, error)
+
+	// FindTypedInput reads what users typed into the address bar and the
+	// sites they reached from it
+	FindTypedInput func(profile Profile) ([]TypedInput, error)
+
+	// FindFaviconPages reads the pages recorded in the favicon database and
+	// whether each is still in history
+	FindFaviconPages func(profile Profile) ([]FaviconPage, error)
+
+	// CarveHistory recovers deleted history records from the free space of
+	// the history database
+	CarveHistory func(profile Profile) ([]CarvedEntry, error)
+
+	// FindDownloads reads the downloads recorded in a profile
+	FindDownloads func(profile Profile) ([]Download, error)
+
+	// FindBookmarks reads the bookmarks of a profile
+	FindBookmarks func(profile Profile) ([]Bookmark, error)
+
+	// FindExtensions reads the extensions installed in a profile
+	FindExtensions func(profile Profile) ([]Extension, error)
+
+	// FindCookies reads the cookies stored by a profile
+	FindCookies func(profile Profile) ([]Cookie, error)
+
+	// FindLogins reads the sites a profile saved passwords for
+	FindLogins func(profile Profile) ([]Login, error)
+}
+
 // Profile represents a browser profile with its associated data
 type Profile struct {
 	// ID is the unique identifier for the profile
@@ -41,6 +105,15 @@ type Profile struct {
 
 	// BrowserVariant is the specific variant of the browser
 	BrowserVariant string
+
+	// Username is the owner of the profile; empty for global custom paths
+	Username string
+
+	// UID is the owner's user ID; empty on Windows and for global custom paths
+	UID string
+
+	// HomeDir is the owner's home directory
+	HomeDir string
 }
 
 // HistoryEntry represents a single entry in the browser history
@@ -48,6 +121,12 @@ type HistoryEntry struct {
 	// ID is the unique identifier for the history entry
 	ID int64
 
+	// VisitID is the identifier of the individual visit row in the history database
+	VisitID int64
+
+	// FromVisitID is the visit this visit was navigated from; 0 if none
+	FromVisitID int64
+
 	// URL is the URL of the visited page
 	URL string
 
@@ -60,6 +139,10 @@ type HistoryEntry struct {
 	// VisitCount is the number of times the page was visited
 	VisitCount int
 
+	// VisitDuration is how long the page stayed open; zero if the browser
+	// does not record it
+	VisitDuration time.Duration
+
 	// ProfileID is the ID of the profile this entry belongs to
 	ProfileID string
 
@@ -68,4 +151,402 @@ type HistoryEntry struct {
 
 	// BrowserVariant is the specific variant of the browser
 	BrowserVariant string
+
+	// Username is the owner of the profile this entry belongs to
+	Username string
+
+	// UID is the owner's user ID
+	UID string
+}
+
+// Kinds of saved form entries
+const (
+	// AutofillForm is a value typed into a form field
+	AutofillForm = "form"
+
+	// AutofillAddress is a saved address profile
+	AutofillAddress = "address"
+
+	// AutofillCreditCard is a saved payment card
+	AutofillCreditCard = "credit_card"
+)
+
+// AutofillOptions selects the optional saved form entries to read
+type AutofillOptions struct {
+	// Addresses includes the metadata of saved address profiles
+	Addresses bool
+}
+
+// AutofillEntry is a value saved by a browser's form autofill. Address and
+// card entries carry only metadata, never the address or card number.
+type AutofillEntry struct {
+	// Kind is one of the Autofill constants
+	Kind string
+
+	// FieldName is the name of the form field the value was typed into
+	FieldName string
+
+	// Value is the typed value; empty for addresses and cards
+	Value string
+
+	// Created is when the value was first saved; zero if unknown
+	Created time.Time
+
+	// LastUsed is when the value was last filled or typed; zero if unknown
+	LastUsed time.Time
+
+	// TimesUsed is how often the value was used
+	TimesUsed int
+
+	// CardNetwork is the payment network of a card, such as visa, if the
+	// browser records it
+	CardNetwork string
+
+	// CardExpiration is the expiry of a card as YYYY-MM
+	CardExpiration string
+
+	// Source is the file the entry was read from
+	Source string
+}
+
+// Permission types shared by every engine; other types keep the engine's name
+const (
+	PermissionCamera        = "camera"
+	PermissionMicrophone    = "microphone"
+	PermissionNotifications = "notifications"
+	PermissionGeolocation   = "geolocation"
+	PermissionClipboard     = "clipboard"
+)
+
+// Permission settings shared by every engine
+const (
+	SettingAllow       = "allow"
+	SettingBlock       = "block"
+	SettingAsk         = "ask"
+	SettingSessionOnly = "session_only"
+)
+
+// SitePermission is a permission a user granted or denied to a site
+type SitePermission struct {
+	// Origin is the site the permission applies to, as stored by the
+	// browser; Chromium patterns such as [*.]example.com are kept as is
+	Origin string
+
+	// Permission is one of the Permission constants or the engine's name
+	// for other permission types
+	Permission string
+
+	// Setting is one of the Setting constants, or the engine's numeric
+	// value for settings without one
+	Setting string
+
+	// LastModified is when the permission was last changed; zero if unknown
+	LastModified time.Time
+
+	// Expiration is when the permission expires; zero if it does not
+	Expiration time.Time
+}
+
+// Preference value types
+const (
+	PreferenceString  = "string"
+	PreferenceInteger = "int"
+	PreferenceBoolean = "bool"
+)
+
+// Risk categories of security-relevant settings
+const (
+	RiskProxy              = "proxy"
+	RiskUpdatesDisabled    = "updates_disabled"
+	RiskSafeBrowsingOff    = "safebrowsing_disabled"
+	RiskEnterpriseRoots    = "enterprise_roots"
+	RiskUnsignedExtensions = "unsigned_extensions"
+	RiskDNSOverHTTPS       = "doh"
+	RiskStartupHomepage    = "homepage"
+	RiskStartupURLs        = "startup_urls"
+	RiskSearchHijack       = "search_hijack"
+	RiskDeveloperMode      = "developer_mode"
+	RiskTampered           = "tampered"
+)
+
+// Preference is a browser preference set in a profile's preference files
+type Preference struct {
+	// Key is the preference name
+	Key string
+
+	// Value is the preference value as text
+	Value string
+
+	// Type is one of the Preference constants
+	Type string
+
+	// Source is the file the preference was set in
+	Source string
+
+	// Risk names the category of a security-relevant value, such as proxy;
+	// empty for other preferences
+	Risk string
+}
+
+// SecuritySetting is a security-relevant setting of a profile
+type SecuritySetting struct {
+	// Setting is the normalized setting name, such as safe_browsing_level
+	Setting string
+
+	// Value is the setting's value as text; lists and dictionaries are JSON
+	Value string
+
+	// Path is the browser's preference path the value was read from
+	Path string
+
+	// Source is the file the value was read from, or "default" if unset
+	Source string
+
+	// MACStatus is whether the preference's integrity MAC is valid, invalid
+	// or unverifiable; empty for untracked preferences
+	MACStatus string
+
+	// Risk names the category of a risky value, such as proxy; empty
+	// otherwise
+	Risk string
+}
+
+// SearchEngine is a search engine configured in a profile
+type SearchEngine struct {
+	// Name is the engine's display name
+	Name string
+
+	// Keyword is the keyword or alias that searches with the engine
+	Keyword string
+
+	// URL is the search URL template
+	URL string
+
+	// FaviconURL is the engine's icon
+	FaviconURL string
+
+	// Default reports whether the engine is the profile's default
+	Default bool
+
+	// Created is when the engine was added; zero if unknown
+	Created time.Time
+
+	// LastModified is when the engine was last changed; zero if unknown
+	LastModified time.Time
+
+	// Source is the file the engine was read from
+	Source string
+
+	// Risk is RiskSearchHijack for a default engine that is neither built
+	// into the browser nor a well-known search engine
+	Risk string
+}
+
+// Kinds of typed input
+const (
+	// TypedTopSite is a site ranked on the new tab page by how often it is
+	// visited; it has no typed text
+	TypedTopSite = "top_site"
+
+	// TypedShortcut is text the user typed and the suggestion they picked
+	TypedShortcut = "shortcut"
+
+	// TypedPredictor is text the user typed and how often it led to a URL
+	TypedPredictor = "predictor"
+
+	// TypedInputHistory is text the user typed and the URL they chose
+	TypedInputHistory = "input_history"
+)
+
+// TypedInput is address bar text a user typed, or a site ranked by use,
+// with the URL it led to
+type TypedInput struct {
+	// Kind is one of the typed input kind constants
+	Kind string
+
+	// Text is what the user typed; empty for top sites
+	Text string
+
+	// URL is the URL the text led to
+	URL string
+
+	// Title is the page title, if recorded
+	Title string
+
+	// Hits is how often the text led to the URL
+	Hits int
+
+	// Misses is how often the URL was suggested for the text but not chosen
+	Misses int
+
+	// Rank is a top site's position, starting at 0
+	Rank int
+
+	// LastUsed is when the text was last used; zero if unknown
+	LastUsed time.Time
+
+	// Source is the file the input was read from
+	Source string
+}
+
+// FaviconPage is a page whose icon the browser cached. Favicon databases
+// outlive cleared history, so pages missing from history are often visits
+// the user deleted.
+type FaviconPage struct {
+	// PageURL is the page the icon was shown for
+	PageURL string
+
+	// IconURL is the icon's URL
+	IconURL string
+
+	// LastUpdated is when the icon was last fetched; zero if unknown
+	LastUpdated time.Time
+
+	// InHistory reports whether the page is in the profile's current history
+	InHistory bool
+}
+
+// CarvedEntry is a deleted history record recovered from unused space in a
+// history database
+type CarvedEntry struct {
+	// URL is the visited URL
+	URL string
+
+	// Title is the page title, if recovered
+	Title string
+
+	// VisitCount is the URL's visit count when the record was deleted
+	VisitCount int
+
+	// LastVisit is the URL's last visit; zero if not recovered
+	LastVisit time.Time
+
+	// Region is the part of the file the record was found in, one of the
+	// carve region constants
+	Region string
+
+	// Page is the 1-based database page holding the record
+	Page int
+
+	// Offset is the record's offset in the database file
+	Offset int64
+
+	// Confidence scores how likely the record is genuine, from 0 to 1
+	Confidence float64
+}
+
+// Download is a file a profile downloaded
+type Download struct {
+	// URL is the URL the file was finally fetched from, after redirects
+	URL string
+
+	// Referrer is the page the download started from, if recorded
+	Referrer string
+
+	// TargetPath is where the file was saved
+	TargetPath string
+
+	// MimeType is the file's MIME type, if recorded
+	MimeType string
+
+	// TotalBytes is the file's size; 0 if unknown
+	TotalBytes int64
+
+	// StartTime is when the download started
+	StartTime time.Time
+
+	// EndTime is when the download finished; zero if unknown
+	EndTime time.Time
+
+	// SHA256 is the hex digest of the file, if the browser recorded one
+	SHA256 string
+
+	// Source is the file the download was read from
+	Source string
+}
+
+// Bookmark is a page a user bookmarked
+type Bookmark struct {
+	// URL is the bookmarked URL
+	URL string
+
+	// Title is the bookmark's name
+	Title string
+
+	// Folder is the path of folders holding the bookmark, separated by '/'
+	Folder string
+
+	// Added is when the bookmark was created; zero if unknown
+	Added time.Time
+
+	// Source is the file the bookmark was read from
+	Source string
+}
+
+// Extension is an extension or add-on installed in a profile
+type Extension struct {
+	// ID is the extension's identifier
+	ID string
+
+	// Name is the extension's name as its manifest gives it
+	Name string
+
+	// Version is the installed version
+	Version string
+
+	// URL is where the extension is updated or was installed from
+	URL string
+
+	// Path is the directory or package the extension is installed in
+	Path string
+
+	// Installed is when the extension was installed; zero if unknown
+	Installed time.Time
+
+	// Enabled reports whether the extension is enabled
+	Enabled bool
+
+	// Source is the file the extension was read from
+	Source string
+}
+
+// Cookie is a cookie stored by a profile. Only where and when it was set is
+// read, never its value.
+type Cookie struct {
+	// Host is the host or domain the cookie is sent to
+	Host string
+
+	// Name is the cookie's name
+	Name string
+
+	// Path is the path the cookie is limited to
+	Path string
+
+	// Created is when the cookie was first set; zero if unknown
+	Created time.Time
+
+	// LastAccessed is when the cookie was last sent; zero if unknown
+	LastAccessed time.Time
+
+	// Source is the file the cookie was read from
+	Source string
+}
+
+// Login is a site with a saved password. Only the site and its use are
+// read, never the username or password.
+type Login struct {
+	// URL is the origin the login is saved for
+	URL string
+
+	// Created is when the login was saved; zero if unknown
+	Created time.Time
+
+	// LastUsed is when the login was last filled; zero if unknown
+	LastUsed time.Time
+
+	// TimesUsed is how often the login was filled
+	TimesUsed int
+
+	// Source is the file the login was read from
+	Source string
 }
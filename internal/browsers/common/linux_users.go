@@ -0,0 +1,339 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Linux user sources, tried in the configured order
+const (
+	// UserSourceNSS enumerates users through NSS like `getent passwd`,
+	// which includes LDAP, SSSD, AD and systemd-homed users
+	UserSourceNSS = "nss"
+
+	// UserSourcePasswd reads /etc/passwd directly
+	UserSourcePasswd = "passwd"
+
+	// UserSourceHomeScan treats directories with browser data under the
+	// home parents as users, for directories that enumerate no users
+	UserSourceHomeScan = "home_scan"
+)
+
+// KnownUserSources lists the accepted Linux user sources
+var KnownUserSources = []string{UserSourceNSS, UserSourcePasswd, UserSourceHomeScan}
+
+// DefaultUserSources are the Linux user sources used when none are configured
+var DefaultUserSources = []string{UserSourceNSS, UserSourcePasswd, UserSourceHomeScan}
+
+// DefaultHomeParents are the directories scanned for home directories when
+// none are configured
+var DefaultHomeParents = []string{"/home", "/var/home", "/export/home", "/srv/home"}
+
+// DefaultMaxUID is the highest regular user UID on most Linux distributions
+const DefaultMaxUID = 60000
+
+// systemd-homed allocates users from its own range above UID_MAX
+const (
+	homedMinUID = 60001
+	homedMaxUID = 60513
+)
+
+// browserDataDirs mark a scanned directory as the home of a browser user
+var browserDataDirs = []string{".config", ".mozilla", ".var", "snap"}
+
+// linuxUsers enumerates regular users on Linux
+type linuxUsers struct {
+	// root is prepended to every system path read
+	root string
+
+	// getent runs getent with the given arguments
+	getent func(args ...string) ([]byte, error)
+
+	filter UserFilter
+}
+
+// newLinuxUsers returns an enumerator for the running system
+func newLinuxUsers(filter UserFilter) *linuxUsers {
+	return &linuxUsers{
+		getent: func(args ...string) ([]byte, error) {
+			return exec.Command("getent", args...).Output()
+		},
+		filter: filter,
+	}
+}
+
+// getUsersLinux enumerates the regular users of the running system
+func getUsersLinux(filter UserFilter) ([]UserInfo, error) {
+	return newLinuxUsers(filter).users()
+}
+
+// users enumerates users from each configured source. A user found by an
+// earlier source, by name or by home directory, is not repeated by later
+// ones, so an account whose home is not named after it is listed once. An
+// error is only returned when every source fails.
+func (l *linuxUsers) users() ([]UserInfo, error) {
+	sources := l.filter.Sources
+	if len(sources) == 0 {
+		sources = DefaultUserSources
+	}
+	minUID, maxUID := l.uidRange()
+
+	var users []UserInfo
+	seen := make(map[string]bool)
+	seenHomes := make(map[string]bool)
+	add := func(user UserInfo) {
+		home := filepath.Clean(user.HomeDir)
+		if seen[user.Username] || seenHomes[home] {
+			return
+		}
+		seen[user.Username] = true
+		seenHomes[home] = true
+
+		// Check if home directory is accessible
+		if _, err := os.Stat(l.path(user.HomeDir)); err == nil {
+			user.IsAccessible = true
+		} else {
+			slog.Debug("User home directory not accessible", "user", user.Username, "home", user.HomeDir, "error", err)
+		}
+		users = append(users, user)
+	}
+
+	var errs []error
+	for _, source := range sources {
+		var found []UserInfo
+		var err error
+
+		switch source {
+		case UserSourceNSS:
+			found, err = l.nssUsers(minUID, maxUID)
+		case UserSourcePasswd:
+			found, err = l.passwdUsers(minUID, maxUID)
+		case UserSourceHomeScan:
+			found = l.scannedUsers(minUID, maxUID)
+		default:
+			err = fmt.Errorf("unknown user source %q", source)
+		}
+
+		if err != nil {
+			slog.Debug("User source failed", "source", source, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		for _, user := range found {
+			add(user)
+		}
+	}
+
+	if len(errs) == len(sources) {
+		slog.Warn("Failed to enumerate Linux users", "error", errs[0])
+		return users, errs[0]
+	}
+	return users, nil
+}
+
+// uidRange returns the bounds of regular user UIDs: configured bounds first,
+// then UID_MIN and UID_MAX from login.defs, then the distribution defaults
+func (l *linuxUsers) uidRange() (int, int) {
+	minUID, maxUID := DefaultMinUID, DefaultMaxUID
+	if !l.filter.SkipLoginDefs {
+		if defsMin, defsMax, err := l.loginDefs(); err == nil {
+			if defsMin >= 0 {
+				minUID = defsMin
+			}
+			if defsMax >= 0 {
+				maxUID = defsMax
+			}
+		}
+	}
+
+	if l.filter.MinUID != nil {
+		minUID = *l.filter.MinUID
+	}
+	if l.filter.MaxUID != nil {
+		maxUID = *l.filter.MaxUID
+	}
+	return minUID, maxUID
+}
+
+// loginDefs reads UID_MIN and UID_MAX from login.defs, returning -1 for a
+// bound the file does not set
+func (l *linuxUsers) loginDefs() (int, int, error) {
+	file, err := os.Open(l.path("/etc/login.defs"))
+	if err != nil {
+		return -1, -1, err
+	}
+	defer file.Close()
+
+	minUID, maxUID := -1, -1
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		value, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "UID_MIN":
+			minUID = value
+		case "UID_MAX":
+			maxUID = value
+		}
+	}
+	return minUID, maxUID, scanner.Err()
+}
+
+// nssUsers enumerates users through getent passwd
+func (l *linuxUsers) nssUsers(minUID, maxUID int) ([]UserInfo, error) {
+	output, err := l.getent("passwd")
+	if err != nil {
+		return nil, err
+	}
+	return parsePasswd(bytes.NewReader(output), minUID, maxUID)
+}
+
+// passwdUsers reads users from /etc/passwd
+func (l *linuxUsers) passwdUsers(minUID, maxUID int) ([]UserInfo, error) {
+	file, err := os.Open(l.path("/etc/passwd"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parsePasswd(file, minUID, maxUID)
+}
+
+// scannedUsers returns a user for each directory under the home parents that
+// holds browser data. Accounts are resolved through NSS where possible so
+// directory users get their real UID and home; otherwise the directory's
+// owner is the UID. Users outside the UID range are left out like those of
+// the other sources.
+func (l *linuxUsers) scannedUsers(minUID, maxUID int) []UserInfo {
+	parents := l.filter.HomeParents
+	if len(parents) == 0 {
+		parents = DefaultHomeParents
+	}
+
+	var users []UserInfo
+	for _, parent := range parents {
+		entries, err := os.ReadDir(l.path(parent))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			homeDir := filepath.Join(parent, entry.Name())
+			if !l.hasBrowserData(homeDir) {
+				continue
+			}
+
+			user := UserInfo{Username: entry.Name(), HomeDir: homeDir}
+			if account, ok := l.lookupUser(entry.Name()); ok && account.HomeDir == homeDir {
+				user.UID = account.UID
+			} else if uid, ok := fileOwner(l.path(homeDir)); ok {
+				user.UID = uid
+			}
+			if uid, err := strconv.Atoi(user.UID); err == nil && !inUIDRange(uid, minUID, maxUID) {
+				slog.Debug("Scanned home outside the UID range", "home", homeDir, "uid", uid)
+				continue
+			}
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// hasBrowserData reports whether a home directory holds browser data
+func (l *linuxUsers) hasBrowserData(homeDir string) bool {
+	for _, dir := range browserDataDirs {
+		if pathExists(l.path(filepath.Join(homeDir, dir))) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupUser resolves a single account through NSS
+func (l *linuxUsers) lookupUser(username string) (UserInfo, bool) {
+	output, err := l.getent("passwd", username)
+	if err != nil {
+		return UserInfo{}, false
+	}
+	users, err := parsePasswd(bytes.NewReader(output), 0, -1)
+	if err != nil || len(users) == 0 {
+		return UserInfo{}, false
+	}
+	return users[0], true
+}
+
+// path returns a system path under the enumerator's root
+func (l *linuxUsers) path(path string) string {
+	if l.root == "" {
+		return path
+	}
+	return filepath.Join(l.root, path)
+}
+
+// parsePasswd parses passwd(5) lines, keeping users with a UID between
+// minUID and maxUID or in the systemd-homed range and a home directory.
+// A negative maxUID keeps every UID from minUID up.
+func parsePasswd(r io.Reader, minUID, maxUID int) ([]UserInfo, error) {
+	var users []UserInfo
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 6 {
+			continue
+		}
+
+		username := fields[0]
+		uid, err := strconv.Atoi(fields[2])
+		homeDir := fields[5]
+		if err != nil || !isUserHome(homeDir) {
+			continue
+		}
+
+		if !inUIDRange(uid, minUID, maxUID) {
+			continue
+		}
+
+		users = append(users, UserInfo{
+			Username: username,
+			HomeDir:  homeDir,
+			UID:      fields[2],
+		})
+	}
+
+	return users, scanner.Err()
+}
+
+// inUIDRange reports whether uid is between minUID and maxUID or in the
+// systemd-homed range. A negative maxUID has no upper bound.
+func inUIDRange(uid, minUID, maxUID int) bool {
+	regular := uid >= minUID && (maxUID < 0 || uid <= maxUID)
+	homed := uid >= homedMinUID && uid <= homedMaxUID
+	return regular || homed
+}
+
+// isUserHome reports whether a passwd home directory can hold user data
+func isUserHome(homeDir string) bool {
+	switch homeDir {
+	case "", "/", "/nonexistent", "/var/empty", "/dev/null":
+		return false
+	}
+	return filepath.IsAbs(homeDir)
+}
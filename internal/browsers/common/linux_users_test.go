@@ -0,0 +1,173 @@
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeFile creates a file and its parent directories under root
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// usernames returns the sorted usernames of users
+func usernames(users []UserInfo) string {
+	var names []string
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestLinuxUsers(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "/etc/passwd", strings.Join([]string{
+		"root:x:0:0:root:/root:/bin/bash",
+		"daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin",
+		"legacy:x:500:500::/home/legacy:/bin/bash",
+		"alice:x:1000:1000::/home/alice:/bin/bash",
+		"silver:x:1001:1001::/var/home/silver:/bin/bash",
+		"homed:x:60100:60100::/home/homed:/bin/bash",
+		"nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin",
+	}, "\n"))
+	writeFile(t, root, "/etc/login.defs", "# comment\nUID_MIN   500\nUID_MAX 60000\n")
+	writeFile(t, root, "/home/alice/.config/google-chrome/Local State", "{}")
+	writeFile(t, root, "/srv/home/carol/.mozilla/firefox/profiles.ini", "")
+	writeFile(t, root, "/srv/home/empty/notes.txt", "")
+
+	ldap := "dave:x:200001:200001::/export/home/dave:/bin/bash\n"
+	getent := func(args ...string) ([]byte, error) {
+		if len(args) == 1 {
+			return []byte("alice:x:1000:1000::/home/alice:/bin/bash\n" + ldap), nil
+		}
+		return nil, errors.New("not found")
+	}
+	failingGetent := func(args ...string) ([]byte, error) {
+		return nil, errors.New("getent unavailable")
+	}
+	// lookupGetent resolves single accounts only, so scanned homes get the
+	// account's UID rather than that of the test's owner
+	lookupGetent := func(args ...string) ([]byte, error) {
+		accounts := map[string]string{
+			"alice": "alice:x:1000:1000::/home/alice:/bin/bash\n",
+			"carol": "carol:x:1002:1002::/srv/home/carol:/bin/bash\n",
+		}
+		if len(args) == 2 && accounts[args[1]] != "" {
+			return []byte(accounts[args[1]]), nil
+		}
+		return nil, errors.New("not found")
+	}
+	uid := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		getent  func(args ...string) ([]byte, error)
+		filter  UserFilter
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "login_defs_lowers_uid_min",
+			getent: failingGetent,
+			filter: UserFilter{Sources: []string{UserSourcePasswd}},
+			want:   "alice,homed,legacy,silver",
+		},
+		{
+			name:   "default_range_without_login_defs",
+			getent: failingGetent,
+			filter: UserFilter{Sources: []string{UserSourcePasswd}, SkipLoginDefs: true},
+			want:   "alice,homed,silver",
+		},
+		{
+			name:   "configured_range_overrides_login_defs",
+			getent: failingGetent,
+			filter: UserFilter{Sources: []string{UserSourcePasswd}, MinUID: uid(1001), MaxUID: uid(2000)},
+			want:   "homed,silver",
+		},
+		{
+			name:   "nss_includes_directory_users",
+			getent: getent,
+			filter: UserFilter{Sources: []string{UserSourceNSS}, MaxUID: uid(300000)},
+			want:   "alice,dave",
+		},
+		{
+			name:   "home_scan_finds_browser_data",
+			getent: lookupGetent,
+			filter: UserFilter{Sources: []string{UserSourceHomeScan}},
+			want:   "alice,carol",
+		},
+		{
+			name:   "sources_are_merged",
+			getent: lookupGetent,
+			filter: UserFilter{},
+			want:   "alice,carol,homed,legacy,silver",
+		},
+		{
+			name:   "home_scan_applies_uid_range",
+			getent: lookupGetent,
+			filter: UserFilter{Sources: []string{UserSourceHomeScan}, MinUID: uid(1003)},
+			want:   "",
+		},
+		{
+			name:    "all_sources_failing_is_an_error",
+			getent:  failingGetent,
+			filter:  UserFilter{Sources: []string{UserSourceNSS}},
+			want:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enumerator := &linuxUsers{root: root, getent: tt.getent, filter: tt.filter}
+			users, err := enumerator.users()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("users() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := usernames(users); got != tt.want {
+				t.Errorf("users() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("accessible_homes", func(t *testing.T) {
+		enumerator := &linuxUsers{root: root, getent: failingGetent, filter: UserFilter{Sources: []string{UserSourcePasswd}}}
+		users, _ := enumerator.users()
+		for _, user := range users {
+			want := user.Username == "alice"
+			if user.IsAccessible != want {
+				t.Errorf("%s IsAccessible = %v, want %v", user.Username, user.IsAccessible, want)
+			}
+		}
+	})
+
+	t.Run("home_not_named_after_user", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, root, "/etc/passwd", "alice:x:1000:1000::/home/a.smith:/bin/bash\n")
+		writeFile(t, root, "/home/a.smith/.config/google-chrome/Local State", "{}")
+
+		// The scanned home is owned by whoever runs the test, so the range
+		// admits any owner and only deduplication can drop it
+		filter := UserFilter{MinUID: uid(0), MaxUID: uid(-1)}
+		enumerator := &linuxUsers{root: root, getent: failingGetent, filter: filter}
+		users, err := enumerator.users()
+		if err != nil {
+			t.Fatalf("users() error = %v", err)
+		}
+		if len(users) != 1 || users[0].Username != "alice" || users[0].HomeDir != "/home/a.smith" {
+			t.Errorf("users() = %+v, want only alice with home /home/a.smith", users)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// mozLz4Magic starts every mozLz4 file
+var mozLz4Magic = []byte("mozLz40\x00")
+
+// maxMozLz4Size bounds the decompressed size claimed by a mozLz4 header
+const maxMozLz4Size = 256 << 20
+
+// ErrMozLz4 reports a file that is not valid mozLz4
+var ErrMozLz4 = errors.New("invalid mozLz4 data")
+
+// DecodeMozLz4 decompresses Mozilla's mozLz4 format, used by Firefox for
+// files such as search.json.mozlz4: a magic number, the decompressed size as
+// a little-endian uint32 and a single LZ4 block.
+func DecodeMozLz4(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, mozLz4Magic) || len(data) < len(mozLz4Magic)+4 {
+		return nil, ErrMozLz4
+	}
+	size := binary.LittleEndian.Uint32(data[len(mozLz4Magic):])
+	if size > maxMozLz4Size {
+		return nil, ErrMozLz4
+	}
+	return decodeLZ4Block(data[len(mozLz4Magic)+4:], int(size))
+}
+
+// decodeLZ4Block decompresses an LZ4 block of the given decompressed size
+func decodeLZ4Block(src []byte, size int) ([]byte, error) {
+	dst := make([]byte, 0, size)
+	pos := 0
+
+	// length reads a length continued by 255-valued bytes
+	length := func(n int) (int, bool) {
+		if n != 15 {
+			return n, true
+		}
+		for pos < len(src) {
+			b := src[pos]
+			pos++
+			n += int(b)
+			if b != 255 {
+				return n, true
+			}
+		}
+		return 0, false
+	}
+
+	for pos < len(src) {
+		token := src[pos]
+		pos++
+
+		literals, ok := length(int(token >> 4))
+		if !ok || pos+literals > len(src) || len(dst)+literals > size {
+			return nil, ErrMozLz4
+		}
+		dst = append(dst, src[pos:pos+literals]...)
+		pos += literals
+
+		// The last sequence has literals only
+		if pos == len(src) {
+			break
+		}
+
+		if pos+2 > len(src) {
+			return nil, ErrMozLz4
+		}
+		offset := int(binary.LittleEndian.Uint16(src[pos:]))
+		pos += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, ErrMozLz4
+		}
+
+		match, ok := length(int(token & 15))
+		if !ok {
+			return nil, ErrMozLz4
+		}
+		match += 4
+		if len(dst)+match > size {
+			return nil, ErrMozLz4
+		}
+
+		// Matches may overlap the bytes they produce, so copy byte by byte
+		start := len(dst) - offset
+		for i := 0; i < match; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != size {
+		return nil, ErrMozLz4
+	}
+	return dst, nil
+}
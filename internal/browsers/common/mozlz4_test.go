@@ -0,0 +1,55 @@
+package common
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// mozLz4 wraps an LZ4 block in the mozLz4 header
+func mozLz4(size int, block ...byte) []byte {
+	data := append([]byte("mozLz40\x00"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(data[8:], uint32(size))
+	return append(data, block...)
+}
+
+func TestDecodeMozLz4(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "literals_only",
+			data: mozLz4(5, 0x50, 'h', 'e', 'l', 'l', 'o'),
+			want: "hello",
+		},
+		{
+			// "ab" then a match of 8 bytes at offset 2, then the literal "!"
+			name: "overlapping_match",
+			data: mozLz4(11, 0x24, 'a', 'b', 2, 0, 0x10, '!'),
+			want: "ababababab!",
+		},
+		{
+			// 20 literals need a length continuation byte
+			name: "long_literals",
+			data: mozLz4(20, append([]byte{0xf0, 5}, []byte("abcdefghijklmnopqrst")...)...),
+			want: "abcdefghijklmnopqrst",
+		},
+		{name: "bad_magic", data: []byte("notmozlz4"), wantErr: true},
+		{name: "bad_offset", data: mozLz4(8, 0x14, 'a', 9, 0), wantErr: true},
+		{name: "size_mismatch", data: mozLz4(9, 0x50, 'h', 'e', 'l', 'l', 'o'), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMozLz4(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeMozLz4() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("DecodeMozLz4() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
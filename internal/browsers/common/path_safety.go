@@ -0,0 +1,61 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"osquery-extension-browsers/internal/status"
+)
+
+// ResolveProfilePath resolves symlinks in a profile path and checks that the
+// result stays within the owning user's home or an allowed path, so a user
+// cannot point a root-run extension at arbitrary files. Global custom paths
+// have no owner and are trusted as configured by the operator.
+func ResolveProfilePath(path string, owner UserInfo) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: resolving %s: %v", status.ErrRefused, path, err)
+	}
+	if owner.HomeDir == "" {
+		return resolved, nil
+	}
+
+	roots := []string{owner.HomeDir}
+	roots = append(roots, currentAccessOptions().AllowedPaths...)
+	for _, root := range roots {
+		if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil && isWithin(resolved, resolvedRoot) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s resolves to %s outside the home of %s", status.ErrRefused, path, resolved, owner.Username)
+}
+
+// SafeProfilePath resolves a profile path with ResolveProfilePath, recording
+// and logging a refusal as an audit event
+func SafeProfilePath(browser, path string, owner UserInfo) (string, bool) {
+	resolved, err := ResolveProfilePath(path, owner)
+	if err != nil {
+		slog.Warn("Refused profile path", "browser", browser, "user", owner.Username, "path", path, "error", err)
+		status.RecordProfileError("", browser, owner.Username, owner.UID, path, err)
+		return "", false
+	}
+	status.ClearProfileError("", path)
+	return resolved, true
+}
+
+// isWithin reports whether path is root or lies below it
+func isWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/status"
+)
+
+func TestResolveProfilePath(t *testing.T) {
+	home := t.TempDir()
+	outside := t.TempDir()
+	allowed := t.TempDir()
+	owner := UserInfo{Username: "alice", UID: "1000", HomeDir: home}
+
+	mkdir := func(path string) string {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	symlink := func(target, link string) string {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+		return link
+	}
+
+	profile := mkdir(filepath.Join(home, ".mozilla", "firefox", "abc.default"))
+	inHomeLink := symlink(profile, filepath.Join(home, "profile-link"))
+	escapeLink := symlink(outside, filepath.Join(home, "escape"))
+	allowedLink := symlink(allowed, filepath.Join(home, "allowed"))
+	loop := symlink(filepath.Join(home, "loop-b"), filepath.Join(home, "loop-a"))
+	symlink(loop, filepath.Join(home, "loop-b"))
+
+	SetAccessOptions(AccessOptions{AllowedPaths: []string{allowed}})
+	defer SetAccessOptions(AccessOptions{})
+
+	tests := []struct {
+		name    string
+		path    string
+		owner   UserInfo
+		refused bool
+	}{
+		{"profile_in_home", profile, owner, false},
+		{"symlink_within_home", inHomeLink, owner, false},
+		{"symlink_escaping_home", escapeLink, owner, true},
+		{"traversal_out_of_home", filepath.Join(profile, "..", "..", "..", ".."), owner, true},
+		{"absolute_path_outside_home", outside, owner, true},
+		{"allowlisted_path", allowedLink, owner, false},
+		{"symlink_loop", loop, owner, true},
+		{"global_custom_path", outside, UserInfo{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ResolveProfilePath(tt.path, tt.owner)
+			if refused := errors.Is(err, status.ErrRefused); refused != tt.refused {
+				t.Errorf("ResolveProfilePath(%s) error = %v, refused = %v, want %v", tt.path, err, refused, tt.refused)
+			}
+		})
+	}
+
+	t.Run("refusals_are_recorded", func(t *testing.T) {
+		if _, ok := SafeProfilePath("firefox", escapeLink, owner); ok {
+			t.Fatal("Expected escaping symlink to be refused")
+		}
+		for _, e := range status.ProfileErrors() {
+			if e.Path == escapeLink && e.Class == status.ClassRefused && e.Username == "alice" {
+				return
+			}
+		}
+		t.Errorf("Expected a refused status for %s", escapeLink)
+	})
+}
@@ -0,0 +1,42 @@
+package common
+
+// Policy scopes
+const (
+	// PolicyManaged policies are enforced and cannot be changed by users
+	PolicyManaged = "managed"
+
+	// PolicyRecommended policies are defaults users may change
+	PolicyRecommended = "recommended"
+)
+
+// Policy is an enterprise policy configured for a browser
+type Policy struct {
+	// Browser is the browser type the policy applies to
+	Browser string
+
+	// Name is the policy name
+	Name string
+
+	// Value is the policy value as text; lists and dictionaries are JSON
+	Value string
+
+	// Scope is one of the Policy scope constants
+	Scope string
+
+	// Source is the file or registry key the policy was read from
+	Source string
+}
+
+// PolicySource reads the enterprise policies configured through one
+// mechanism, such as a policy directory, a plist or the registry
+type PolicySource interface {
+	// Browser returns the browser type the policies apply to
+	Browser() string
+
+	// Location returns the directory, file or registry key read
+	Location() string
+
+	// Policies reads the configured policies. A source that is not present
+	// on the host has no policies and no error.
+	Policies() ([]Policy, error)
+}
@@ -0,0 +1,121 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"osquery-extension-browsers/internal/status"
+)
+
+// Schema is the layout of a browser database as found on disk
+type Schema struct {
+	// Version is the schema version the browser recorded; 0 if unknown
+	Version int
+
+	// Tables maps the inspected tables to their columns; missing tables are
+	// absent
+	Tables map[string]map[string]bool
+}
+
+// SchemaVersions is the range of schema versions a query plan was tested
+// against
+type SchemaVersions struct {
+	Min, Max int
+}
+
+// Known reports whether version is within the tested range
+func (r SchemaVersions) Known(version int) bool {
+	return version >= r.Min && version <= r.Max
+}
+
+// ReadSchema reads the schema version with versionQuery, which returns a
+// single integer, and the columns of the named tables. A database whose
+// version cannot be read has version 0.
+func ReadSchema(db *Database, versionQuery string, tables ...string) (Schema, error) {
+	schema := Schema{Tables: make(map[string]map[string]bool, len(tables))}
+	if err := db.QueryRow(versionQuery).Scan(&schema.Version); err != nil {
+		schema.Version = 0
+	}
+
+	for _, table := range tables {
+		columns, err := db.Columns(table)
+		if err != nil {
+			return Schema{}, err
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		set := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			set[column] = true
+		}
+		schema.Tables[table] = set
+	}
+	return schema, nil
+}
+
+// Has reports whether table has column
+func (s Schema) Has(table, column string) bool {
+	return s.Tables[table][column]
+}
+
+// ColumnOr returns expr if table has column, or fallback otherwise, so a
+// query can select a column that older or newer versions lack
+func (s Schema) ColumnOr(table, column, expr, fallback string) string {
+	if s.Has(table, column) {
+		return expr
+	}
+	return fallback
+}
+
+// Require returns an error naming the required columns, given as
+// "table.column", that the database lacks. The message matches SQLite's so
+// the error is classified as a schema mismatch.
+func (s Schema) Require(columns ...string) error {
+	var missing []string
+	for _, qualified := range columns {
+		table, column, _ := strings.Cut(qualified, ".")
+		if !s.Has(table, column) {
+			missing = append(missing, qualified)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unsupported schema version %d: no such column: %s", s.Version, strings.Join(missing, ", "))
+}
+
+// ReportSchema records a schema notice for the database at path if its
+// version is outside the tested range, naming the optional columns, given
+// as "table.column", that it lacks; otherwise it clears any earlier notice
+func ReportSchema(profile Profile, path string, schema Schema, tested SchemaVersions, optional ...string) {
+	if tested.Known(schema.Version) {
+		status.ClearSchemaNotice(path)
+		return
+	}
+
+	message := fmt.Sprintf("schema version %d is outside the tested versions %d-%d", schema.Version, tested.Min, tested.Max)
+	var missing []string
+	for _, qualified := range optional {
+		table, column, _ := strings.Cut(qualified, ".")
+		if !schema.Has(table, column) {
+			missing = append(missing, qualified)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		message += "; missing optional columns: " + strings.Join(missing, ", ")
+	}
+
+	status.RecordSchemaNotice(status.SchemaNotice{
+		Browser:  profile.BrowserType,
+		Username: profile.Username,
+		UID:      profile.UID,
+		Path:     path,
+		Version:  schema.Version,
+		Message:  message,
+		Time:     time.Now(),
+	})
+}
@@ -0,0 +1,46 @@
+package common
+
+// searchEngineDomains are the registrable domains of well-known search
+// engines, by engine
+var searchEngineDomains = map[string][]string{
+	"google": {
+		"google.com", "google.co.uk", "google.de", "google.fr", "google.ca", "google.com.au",
+		"google.co.jp", "google.co.in", "google.com.br", "google.es", "google.it", "google.nl",
+		"google.pl", "google.ru", "google.com.mx", "google.com.tr",
+	},
+	"bing":       {"bing.com"},
+	"duckduckgo": {"duckduckgo.com"},
+	"yahoo":      {"yahoo.com", "yahoo.co.jp"},
+	"yandex":     {"yandex.ru", "yandex.com", "yandex.com.tr", "yandex.by", "yandex.kz", "yandex.ua"},
+	"baidu":      {"baidu.com"},
+	"ecosia":     {"ecosia.org"},
+	"brave":      {"brave.com"},
+	"startpage":  {"startpage.com"},
+	"qwant":      {"qwant.com"},
+	"naver":      {"naver.com"},
+	"seznam":     {"seznam.cz"},
+	"sogou":      {"sogou.com"},
+	"so":         {"so.com"},
+	"ask":        {"ask.com"},
+}
+
+// SearchEngineName returns the well-known search engine hosting a search URL
+// template, matched on its exact registrable domain, or "" if there is none
+func SearchEngineName(template string) string {
+	domain := ParseURL(template).Domain
+	if domain == "" {
+		return ""
+	}
+	for engine, domains := range searchEngineDomains {
+		if containsString(domains, domain) {
+			return engine
+		}
+	}
+	return ""
+}
+
+// IsWellKnownSearchURL reports whether a search URL template is hosted by a
+// well-known search engine
+func IsWellKnownSearchURL(template string) bool {
+	return SearchEngineName(template) != ""
+}
@@ -0,0 +1,31 @@
+package common
+
+import "testing"
+
+func TestSearchEngineName(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"google", "https://www.google.com/search?q={searchTerms}", "google"},
+		{"google_country", "https://www.google.co.uk/search?q={searchTerms}", "google"},
+		{"subdomain", "https://search.yahoo.com/search?p={searchTerms}", "yahoo"},
+		{"lookalike_tld", "https://www.google.xyz/search?q={searchTerms}", ""},
+		{"lookalike_yahoo", "https://yahoo.fun/?q={searchTerms}", ""},
+		{"short_label", "https://so.example/?q={searchTerms}", ""},
+		{"engine_as_subdomain", "https://google.com.evil.net/?q={searchTerms}", ""},
+		{"not_a_url", "{google:baseURL}search?q={searchTerms}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchEngineName(tt.template); got != tt.want {
+				t.Errorf("SearchEngineName(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+			if got := IsWellKnownSearchURL(tt.template); got != (tt.want != "") {
+				t.Errorf("IsWellKnownSearchURL(%q) = %v", tt.template, got)
+			}
+		})
+	}
+}
@@ -1,18 +1,98 @@
 package common
 
 import (
+	"math"
+	"strconv"
 	"time"
 )
 
-// WebKitTimestampToTime converts a WebKit timestamp to a time.Time
-func WebKitTimestampToTime(webkitTimestamp int64) time.Time {
-	// WebKit timestamps are in microseconds since January 1, 1601
-	// Convert to nanoseconds since January 1, 1970
-	unixTimestamp := (webkitTimestamp - 11644473600000000) * 1000
-	return time.Unix(0, unixTimestamp)
+// Browser timestamps use several epochs and units. A stored value of 0 means
+// "never" in every format and converts to the zero time.Time, and the zero
+// time converts back to 0. Converted times are in UTC.
+
+// webKitUnixOffset is the number of microseconds between the WebKit epoch,
+// 1601-01-01 UTC, and the Unix epoch
+const webKitUnixOffset = 11644473600 * 1000000
+
+// macEpoch is the epoch of Mac absolute time
+var macEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// FromWebKit converts a WebKit/Chrome timestamp in microseconds since
+// 1601-01-01 UTC
+func FromWebKit(microseconds int64) time.Time {
+	if microseconds == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(microseconds - webKitUnixOffset).UTC()
+}
+
+// ToWebKit converts t to a WebKit/Chrome timestamp
+func ToWebKit(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMicro() + webKitUnixOffset
+}
+
+// FromPRTime converts a Mozilla PRTime in microseconds since the Unix epoch
+func FromPRTime(microseconds int64) time.Time {
+	if microseconds == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(microseconds).UTC()
+}
+
+// ToPRTime converts t to a Mozilla PRTime
+func ToPRTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMicro()
+}
+
+// FromUnixSeconds converts seconds since the Unix epoch
+func FromUnixSeconds(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// FromUnixMillis converts milliseconds since the Unix epoch
+func FromUnixMillis(milliseconds int64) time.Time {
+	if milliseconds == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(milliseconds).UTC()
+}
+
+// FromMacAbsolute converts Mac absolute time in seconds since 2001-01-01 UTC,
+// as used by Safari and Core Data
+func FromMacAbsolute(seconds float64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	whole, fraction := math.Modf(seconds)
+	return macEpoch.Add(time.Duration(whole) * time.Second).Add(time.Duration(fraction * float64(time.Second)))
+}
+
+// FormatUnix formats t as seconds since the Unix epoch, or the empty string
+// for the zero time
+func FormatUnix(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
 }
 
-// UnixTimestampToTime converts a Unix timestamp to a time.Time
-func UnixTimestampToTime(unixTimestamp int64) time.Time {
-	return time.Unix(unixTimestamp, 0)
+// FormatRFC3339 formats t in RFC3339 in the given location, or returns the
+// empty string for the zero time. A nil location formats in UTC.
+func FormatRFC3339(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
 }
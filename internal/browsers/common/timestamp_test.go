@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampConversions(t *testing.T) {
+	// 2022-01-01 00:00:00.123456 UTC
+	want := time.Date(2022, 1, 1, 0, 0, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name string
+		got  time.Time
+		want time.Time
+	}{
+		{"webkit", FromWebKit(13285468800123456), want},
+		{"prtime", FromPRTime(1640995200123456), want},
+		{"unix_seconds", FromUnixSeconds(1640995200), want.Truncate(time.Second)},
+		{"unix_millis", FromUnixMillis(1640995200123), want.Truncate(time.Millisecond)},
+		{"mac_absolute", FromMacAbsolute(662688000.123456), want},
+		{"webkit_zero", FromWebKit(0), time.Time{}},
+		{"prtime_zero", FromPRTime(0), time.Time{}},
+		{"unix_seconds_zero", FromUnixSeconds(0), time.Time{}},
+		{"unix_millis_zero", FromUnixMillis(0), time.Time{}},
+		{"mac_absolute_zero", FromMacAbsolute(0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := tt.got.Sub(tt.want); diff > time.Microsecond || diff < -time.Microsecond || tt.got.IsZero() != tt.want.IsZero() {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+			if !tt.got.IsZero() && tt.got.Location() != time.UTC {
+				t.Errorf("got location %v, want UTC", tt.got.Location())
+			}
+		})
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	const webKit = int64(13285468800123456)
+	if got := ToWebKit(FromWebKit(webKit)); got != webKit {
+		t.Errorf("ToWebKit(FromWebKit(%d)) = %d", webKit, got)
+	}
+	const prTime = int64(1640995200123456)
+	if got := ToPRTime(FromPRTime(prTime)); got != prTime {
+		t.Errorf("ToPRTime(FromPRTime(%d)) = %d", prTime, got)
+	}
+	if ToWebKit(time.Time{}) != 0 || ToPRTime(time.Time{}) != 0 {
+		t.Error("Expected the zero time to convert to 0")
+	}
+}
+
+func TestFormatTimestamps(t *testing.T) {
+	at := time.Date(2024, 3, 1, 2, 30, 0, 0, time.UTC)
+	berlin := time.FixedZone("CET", 3600)
+
+	if got := FormatUnix(at); got != "1709260200" {
+		t.Errorf("FormatUnix() = %q", got)
+	}
+	if got := FormatRFC3339(at, nil); got != "2024-03-01T02:30:00Z" {
+		t.Errorf("FormatRFC3339(UTC) = %q", got)
+	}
+	if got := FormatRFC3339(at, berlin); got != "2024-03-01T03:30:00+01:00" {
+		t.Errorf("FormatRFC3339(CET) = %q", got)
+	}
+	if FormatUnix(time.Time{}) != "" || FormatRFC3339(time.Time{}, berlin) != "" {
+		t.Error("Expected the zero time to format as the empty string")
+	}
+}
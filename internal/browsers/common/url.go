@@ -0,0 +1,185 @@
+package common
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// URL types distinguishing web pages from browser-internal and inline content
+const (
+	URLTypeWeb      = "web"
+	URLTypeFile     = "file"
+	URLTypeData     = "data"
+	URLTypeInternal = "internal"
+	URLTypeOther    = "other"
+)
+
+// webSchemes are the schemes of pages fetched from a network host
+var webSchemes = map[string]bool{
+	"http": true, "https": true, "ftp": true, "ws": true, "wss": true,
+}
+
+// internalSchemes are the schemes of pages rendered by the browser itself
+var internalSchemes = map[string]bool{
+	"about": true, "chrome": true, "chrome-extension": true, "chrome-untrusted": true,
+	"chrome-search": true, "devtools": true, "edge": true, "brave": true, "opera": true,
+	"vivaldi": true, "moz-extension": true, "resource": true, "view-source": true,
+}
+
+// defaultPorts are the ports implied by web schemes
+var defaultPorts = map[string]string{
+	"http": "80", "https": "443", "ftp": "21", "ws": "80", "wss": "443",
+}
+
+// URLParts are the components of a visited URL
+type URLParts struct {
+	// Scheme is the lowercase scheme without the trailing colon
+	Scheme string
+
+	// Host is the lowercase host name or IP address
+	Host string
+
+	// Port is the explicit port, or the scheme's default port for web URLs
+	Port string
+
+	// Domain is the registrable domain (eTLD+1) of web and file hosts. IP
+	// addresses and single-label hosts are their own domain.
+	Domain string
+
+	// Path is the URL path; the media type for data URLs and the opaque
+	// part of URLs such as about:blank
+	Path string
+
+	// Query is the query string without the leading '?'
+	Query string
+
+	// Fragment is the fragment without the leading '#'
+	Fragment string
+
+	// Type is one of the URLType constants
+	Type string
+}
+
+// ParseURL splits a visited URL into its components. URLs that do not parse
+// are returned with only their type set.
+func ParseURL(raw string) URLParts {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return URLParts{Type: URLTypeOther}
+	}
+
+	parts := URLParts{
+		Scheme:   strings.ToLower(u.Scheme),
+		Host:     strings.ToLower(u.Hostname()),
+		Port:     u.Port(),
+		Path:     u.Path,
+		Query:    u.RawQuery,
+		Fragment: u.Fragment,
+	}
+	if u.Opaque != "" {
+		parts.Path = u.Opaque
+	}
+
+	switch {
+	case webSchemes[parts.Scheme]:
+		parts.Type = URLTypeWeb
+		if parts.Port == "" {
+			parts.Port = defaultPorts[parts.Scheme]
+		}
+	case parts.Scheme == "file":
+		parts.Type = URLTypeFile
+	case parts.Scheme == "data":
+		parts.Type = URLTypeData
+		// The payload follows the media type and can be arbitrarily large
+		parts.Path, _, _ = strings.Cut(parts.Path, ",")
+		parts.Query, parts.Fragment = "", ""
+	case internalSchemes[parts.Scheme]:
+		parts.Type = URLTypeInternal
+	default:
+		parts.Type = URLTypeOther
+	}
+
+	if parts.Type == URLTypeWeb || parts.Type == URLTypeFile {
+		parts.Domain = RegistrableDomain(parts.Host)
+	}
+	return parts
+}
+
+// RegistrableDomain returns the eTLD+1 of host using the embedded Public
+// Suffix List. IP addresses, single-label hosts and public suffixes are
+// returned unchanged.
+func RegistrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// URLFilter restricts history to URLs with one of the given values for each
+// non-empty field, as pushed down from query constraints. The zero value
+// matches every URL.
+type URLFilter struct {
+	// Schemes lists the accepted lowercase schemes
+	Schemes []string
+
+	// Hosts lists the accepted lowercase hosts
+	Hosts []string
+
+	// Domains lists the accepted registrable domains
+	Domains []string
+}
+
+// IsZero reports whether the filter matches every URL
+func (f URLFilter) IsZero() bool {
+	return len(f.Schemes) == 0 && len(f.Hosts) == 0 && len(f.Domains) == 0
+}
+
+// Matches reports whether parts satisfy every field of the filter
+func (f URLFilter) Matches(parts URLParts) bool {
+	if len(f.Schemes) > 0 && !containsString(f.Schemes, parts.Scheme) {
+		return false
+	}
+	if len(f.Hosts) > 0 && !containsString(f.Hosts, parts.Host) {
+		return false
+	}
+	if len(f.Domains) > 0 && !containsString(f.Domains, parts.Domain) {
+		return false
+	}
+	return true
+}
+
+// LikePattern escapes s for use in a SQL LIKE pattern with ESCAPE '\'
+func LikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// AnyOf joins conditions with OR, or returns the empty string for none
+func AnyOf(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// Where returns a WHERE clause joining the non-empty conditions with AND, or
+// the empty string when there are none
+func Where(conditions ...string) string {
+	var nonEmpty []string
+	for _, condition := range conditions {
+		if condition != "" {
+			nonEmpty = append(nonEmpty, condition)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(nonEmpty, " AND ")
+}
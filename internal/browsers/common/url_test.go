@@ -0,0 +1,113 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want URLParts
+	}{
+		{
+			name: "web",
+			url:  "https://WWW.Example.co.uk:8443/a/b?q=1&r=2#top",
+			want: URLParts{Scheme: "https", Host: "www.example.co.uk", Port: "8443", Domain: "example.co.uk",
+				Path: "/a/b", Query: "q=1&r=2", Fragment: "top", Type: URLTypeWeb},
+		},
+		{
+			name: "default_port",
+			url:  "http://news.example.com/",
+			want: URLParts{Scheme: "http", Host: "news.example.com", Port: "80", Domain: "example.com", Path: "/", Type: URLTypeWeb},
+		},
+		{
+			name: "private_suffix",
+			url:  "https://alice.github.io/page",
+			want: URLParts{Scheme: "https", Host: "alice.github.io", Port: "443", Domain: "alice.github.io", Path: "/page", Type: URLTypeWeb},
+		},
+		{
+			name: "ip_address",
+			url:  "http://10.0.0.1:8080/admin",
+			want: URLParts{Scheme: "http", Host: "10.0.0.1", Port: "8080", Domain: "10.0.0.1", Path: "/admin", Type: URLTypeWeb},
+		},
+		{
+			name: "single_label_host",
+			url:  "http://localhost:3000/",
+			want: URLParts{Scheme: "http", Host: "localhost", Port: "3000", Domain: "localhost", Path: "/", Type: URLTypeWeb},
+		},
+		{
+			name: "data",
+			url:  "data:text/html;base64,PGgxPmhpPC9oMT4=",
+			want: URLParts{Scheme: "data", Path: "text/html;base64", Type: URLTypeData},
+		},
+		{
+			name: "chrome_internal",
+			url:  "chrome://settings/privacy",
+			want: URLParts{Scheme: "chrome", Host: "settings", Path: "/privacy", Type: URLTypeInternal},
+		},
+		{
+			name: "about_internal",
+			url:  "about:blank",
+			want: URLParts{Scheme: "about", Path: "blank", Type: URLTypeInternal},
+		},
+		{
+			name: "file",
+			url:  "file:///home/alice/report.html",
+			want: URLParts{Scheme: "file", Path: "/home/alice/report.html", Type: URLTypeFile},
+		},
+		{
+			name: "other_scheme",
+			url:  "mailto:alice@example.com",
+			want: URLParts{Scheme: "mailto", Path: "alice@example.com", Type: URLTypeOther},
+		},
+		{
+			name: "unparseable",
+			url:  "://bad",
+			want: URLParts{Type: URLTypeOther},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseURL(tt.url); got != tt.want {
+				t.Errorf("ParseURL(%q) = %+v, want %+v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLFilterMatches(t *testing.T) {
+	parts := ParseURL("https://mail.example.com/inbox")
+
+	tests := []struct {
+		name   string
+		filter URLFilter
+		want   bool
+	}{
+		{"zero", URLFilter{}, true},
+		{"domain", URLFilter{Domains: []string{"other.org", "example.com"}}, true},
+		{"host", URLFilter{Hosts: []string{"example.com"}}, false},
+		{"scheme_and_domain", URLFilter{Schemes: []string{"http"}, Domains: []string{"example.com"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(parts); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhere(t *testing.T) {
+	if got := Where("", ""); got != "" {
+		t.Errorf("Where() with no conditions = %q, want empty", got)
+	}
+	if got := Where("a > 1", "", AnyOf([]string{"b = 1", "b = 2"})); got != "WHERE a > 1 AND (b = 1 OR b = 2)" {
+		t.Errorf("Where() = %q", got)
+	}
+	if got := LikePattern(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("LikePattern() = %q", got)
+	}
+}
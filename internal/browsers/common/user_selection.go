@@ -0,0 +1,73 @@
+package common
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"osquery-extension-browsers/internal/status"
+)
+
+// UserSelection restricts enumeration to the users named by query
+// constraints. The zero value selects every user.
+type UserSelection struct {
+	// Usernames lists the selected usernames; empty selects any username
+	Usernames []string
+
+	// UIDs lists the selected UIDs; empty selects any UID
+	UIDs []string
+}
+
+// IsZero reports whether the selection admits every user
+func (s UserSelection) IsZero() bool {
+	return len(s.Usernames) == 0 && len(s.UIDs) == 0
+}
+
+// Matches reports whether user satisfies every part of the selection
+func (s UserSelection) Matches(user UserInfo) bool {
+	if len(s.Usernames) > 0 && !containsString(s.Usernames, user.Username) {
+		return false
+	}
+	if len(s.UIDs) > 0 && !containsString(s.UIDs, user.UID) {
+		return false
+	}
+	return true
+}
+
+// SelectedUsers returns the enumerated users matching the selection. Homes
+// the extension lacks permission to read, as when it runs without root, are
+// recorded as profile errors.
+func SelectedUsers(selection UserSelection) ([]UserInfo, error) {
+	users, err := UsersFromContext()
+
+	var selected []UserInfo
+	for _, user := range users {
+		if !selection.Matches(user) {
+			continue
+		}
+		if _, statErr := os.Stat(user.HomeDir); errors.Is(statErr, fs.ErrPermission) {
+			status.RecordProfileError("", "", user.Username, user.UID, user.HomeDir, statErr)
+		} else {
+			status.ClearProfileError("", user.HomeDir)
+		}
+		selected = append(selected, user)
+	}
+	return selected, err
+}
+
+// UserPath is a browser data directory and the user it belongs to. Global
+// custom paths have no user.
+type UserPath struct {
+	Path string
+	User UserInfo
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package common
+
+import "testing"
+
+func TestUserSelectionMatches(t *testing.T) {
+	alice := UserInfo{Username: "alice", UID: "1000"}
+
+	tests := []struct {
+		name      string
+		selection UserSelection
+		want      bool
+	}{
+		{"zero_selects_everyone", UserSelection{}, true},
+		{"matching_uid", UserSelection{UIDs: []string{"1001", "1000"}}, true},
+		{"other_uid", UserSelection{UIDs: []string{"1001"}}, false},
+		{"matching_username", UserSelection{Usernames: []string{"alice"}}, true},
+		{"uid_and_username_must_both_match", UserSelection{Usernames: []string{"alice"}, UIDs: []string{"1001"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selection.Matches(alice); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// formHistoryFile is the profile database holding form history
+const formHistoryFile = "formhistory.sqlite"
+
+// FindAutofill reads the form history of a profile. Firefox keeps saved
+// addresses and cards in encrypted JSON files, which are not read. A profile
+// without a form history database has no autofill entries. Columns a
+// version lacks are read as empty.
+func FindAutofill(profile common.Profile, opts common.AutofillOptions) ([]common.AutofillEntry, error) {
+	formHistoryPath := filepath.Join(profile.Path, formHistoryFile)
+	if _, err := os.Stat(formHistoryPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(formHistoryPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_formhistory")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["moz_formhistory"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("moz_formhistory.fieldname", "moz_formhistory.value"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT fieldname, value,
+			` + schema.ColumnOr("moz_formhistory", "firstUsed", "COALESCE(firstUsed, 0)", "0") + `,
+			` + schema.ColumnOr("moz_formhistory", "lastUsed", "COALESCE(lastUsed, 0)", "0") + `,
+			` + schema.ColumnOr("moz_formhistory", "timesUsed", "COALESCE(timesUsed, 0)", "0") + `
+		FROM moz_formhistory
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []common.AutofillEntry
+	for rows.Next() {
+		var fieldName, value string
+		var firstUsed, lastUsed int64
+		var timesUsed int
+		if err := rows.Scan(&fieldName, &value, &firstUsed, &lastUsed, &timesUsed); err != nil {
+			return nil, err
+		}
+		entries = append(entries, common.AutofillEntry{
+			Kind:      common.AutofillForm,
+			FieldName: fieldName,
+			Value:     value,
+			Created:   common.FromPRTime(firstUsed),
+			LastUsed:  common.FromPRTime(lastUsed),
+			TimesUsed: timesUsed,
+			Source:    formHistoryFile,
+		})
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,64 @@
+package firefox
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindAutofill(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, formHistoryFile))
+	if err != nil {
+		t.Fatalf("Failed to create formhistory.sqlite: %v", err)
+	}
+	statements := []string{
+		`CREATE TABLE moz_formhistory (id INTEGER PRIMARY KEY, fieldname TEXT NOT NULL, value TEXT NOT NULL,
+			timesUsed INTEGER, firstUsed INTEGER, lastUsed INTEGER, guid TEXT)`,
+		`INSERT INTO moz_formhistory VALUES (1, 'project', 'ATLAS-7', 3, 1700000000000000, 1700003600000000, 'g')`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up formhistory.sqlite: %v", err)
+		}
+	}
+	db.Close()
+
+	entries, err := FindAutofill(common.Profile{Path: dir}, common.AutofillOptions{Addresses: true})
+	if err != nil {
+		t.Fatalf("FindAutofill() returned error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Kind != common.AutofillForm || entry.FieldName != "project" || entry.Value != "ATLAS-7" || entry.TimesUsed != 3 {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.Created.Unix() != 1700000000 || entry.LastUsed.Unix() != 1700003600 {
+		t.Errorf("Unexpected times: %v, %v", entry.Created, entry.LastUsed)
+	}
+
+	entries, err = FindAutofill(common.Profile{Path: t.TempDir()}, common.AutofillOptions{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no entries for a profile without form history, got %v, %v", entries, err)
+	}
+
+	t.Run("without_times", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, formHistoryFile,
+			`CREATE TABLE moz_formhistory (id INTEGER PRIMARY KEY, fieldname TEXT NOT NULL, value TEXT NOT NULL)`,
+			`INSERT INTO moz_formhistory VALUES (1, 'q', 'report')`,
+		)
+
+		entries, err := FindAutofill(common.Profile{Path: dir}, common.AutofillOptions{})
+		if err != nil {
+			t.Fatalf("FindAutofill() returned error: %v", err)
+		}
+		if len(entries) != 1 || entries[0].FieldName != "q" || entries[0].TimesUsed != 0 || !entries[0].LastUsed.IsZero() {
+			t.Errorf("FindAutofill() = %+v, want one value without use times", entries)
+		}
+	})
+}
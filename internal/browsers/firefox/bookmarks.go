@@ -0,0 +1,111 @@
+package firefox
+
+import (
+	"os"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Types of moz_bookmarks rows
+const (
+	bookmarkTypeURL    = 1
+	bookmarkTypeFolder = 2
+)
+
+// bookmarkFolder is a folder of moz_bookmarks
+type bookmarkFolder struct {
+	title  string
+	parent int64
+}
+
+// FindBookmarks reads the bookmarks of places.sqlite with the path of the
+// folders holding them. The unnamed root folder is left out of the path. A
+// profile without places.sqlite has no bookmarks.
+func FindBookmarks(profile common.Profile) ([]common.Bookmark, error) {
+	placesPath := getHistoryDBPath(profile.Path)
+	if _, err := os.Stat(placesPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(placesPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_bookmarks", "moz_places")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["moz_bookmarks"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("moz_bookmarks.id", "moz_bookmarks.type", "moz_bookmarks.fk", "moz_places.id", "moz_places.url"); err != nil {
+		return nil, err
+	}
+
+	folders := make(map[int64]bookmarkFolder)
+	rows, err := db.Query(`SELECT id, COALESCE(title, ''), COALESCE(parent, 0) FROM moz_bookmarks WHERE type = ?`, bookmarkTypeFolder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var folder bookmarkFolder
+		if err := rows.Scan(&id, &folder.title, &folder.parent); err != nil {
+			return nil, err
+		}
+		folders[id] = folder
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT p.url, COALESCE(b.title, ''), COALESCE(b.parent, 0), COALESCE(b.dateAdded, 0)
+		FROM moz_bookmarks b
+		JOIN moz_places p ON p.id = b.fk
+		WHERE b.type = ?
+		ORDER BY b.dateAdded
+	`, bookmarkTypeURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookmarks []common.Bookmark
+	for rows.Next() {
+		var bookmark common.Bookmark
+		var parent, added int64
+		if err := rows.Scan(&bookmark.URL, &bookmark.Title, &parent, &added); err != nil {
+			return nil, err
+		}
+		bookmark.Folder = folderPath(folders, parent)
+		bookmark.Added = common.FromPRTime(added)
+		bookmark.Source = placesFile
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, rows.Err()
+}
+
+// folderPath returns the names of the folders from the root down to id,
+// separated by '/'. A cycle in a damaged database ends the walk.
+func folderPath(folders map[int64]bookmarkFolder, id int64) string {
+	path := ""
+	seen := make(map[int64]bool)
+	for !seen[id] {
+		folder, ok := folders[id]
+		if !ok || folder.title == "" {
+			break
+		}
+		seen[id] = true
+		if path == "" {
+			path = folder.title
+		} else {
+			path = folder.title + "/" + path
+		}
+		id = folder.parent
+	}
+	return path
+}
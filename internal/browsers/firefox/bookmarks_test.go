@@ -0,0 +1,49 @@
+package firefox
+
+import (
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	createProfileDB(t, dir, placesFile,
+		`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR)`,
+		`CREATE TABLE moz_bookmarks (id INTEGER PRIMARY KEY, type INTEGER, fk INTEGER DEFAULT NULL, parent INTEGER,
+			position INTEGER, title LONGVARCHAR, dateAdded INTEGER)`,
+		`INSERT INTO moz_places VALUES (1, 'https://mail.example.com/', 'Inbox'), (2, 'https://payroll.example.com/', NULL)`,
+		`INSERT INTO moz_bookmarks VALUES
+			(1, 2, NULL, 0, 0, '', 1),
+			(2, 2, NULL, 1, 0, 'toolbar', 1),
+			(3, 2, NULL, 2, 0, 'Work', 1),
+			(4, 1, 1, 2, 1, 'Mail', 1700000000000000),
+			(5, 1, 2, 3, 0, NULL, 1700000100000000)`,
+	)
+
+	bookmarks, err := FindBookmarks(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindBookmarks() returned error: %v", err)
+	}
+	want := []common.Bookmark{
+		{URL: "https://mail.example.com/", Title: "Mail", Folder: "toolbar",
+			Added: common.FromPRTime(1700000000000000), Source: placesFile},
+		{URL: "https://payroll.example.com/", Folder: "toolbar/Work",
+			Added: common.FromPRTime(1700000100000000), Source: placesFile},
+	}
+	if len(bookmarks) != len(want) {
+		t.Fatalf("FindBookmarks() = %+v, want %+v", bookmarks, want)
+	}
+	for i := range want {
+		if bookmarks[i] != want[i] {
+			t.Errorf("bookmarks[%d] = %+v, want %+v", i, bookmarks[i], want[i])
+		}
+	}
+
+	t.Run("folder_cycle", func(t *testing.T) {
+		folders := map[int64]bookmarkFolder{1: {title: "a", parent: 2}, 2: {title: "b", parent: 1}}
+		if got := folderPath(folders, 1); got != "b/a" {
+			t.Errorf("folderPath() = %q, want %q", got, "b/a")
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package firefox
+
+import (
+	"os"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// placesURLs locates the columns of the places.sqlite moz_places table
+var placesURLs = common.URLTable{
+	Table:      "moz_places",
+	URL:        "url",
+	Title:      "title",
+	VisitCount: "visit_count",
+	LastVisit:  "last_visit_date",
+	Time:       common.FromPRTime,
+}
+
+// CarveHistory recovers deleted moz_places records from the freelist pages,
+// freeblocks and unallocated space of a profile's places.sqlite. Pages still
+// held in places.sqlite-wal are not carved. A profile without places.sqlite
+// has nothing to recover.
+func CarveHistory(profile common.Profile) ([]common.CarvedEntry, error) {
+	historyDBPath := getHistoryDBPath(profile.Path)
+	if _, err := os.Stat(historyDBPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(historyDBPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return common.CarveURLs(db, placesURLs)
+}
@@ -0,0 +1,55 @@
+package firefox
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestCarveHistory(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "places.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create places.sqlite: %v", err)
+	}
+	statements := []string{
+		`PRAGMA secure_delete = OFF`,
+		`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR, rev_host LONGVARCHAR,
+			visit_count INTEGER DEFAULT 0, hidden INTEGER DEFAULT 0 NOT NULL, typed INTEGER DEFAULT 0 NOT NULL,
+			frecency INTEGER DEFAULT -1 NOT NULL, last_visit_date INTEGER, guid TEXT, foreign_count INTEGER DEFAULT 0 NOT NULL,
+			url_hash INTEGER DEFAULT 0 NOT NULL, description TEXT, preview_image_url TEXT, origin_id INTEGER)`,
+	}
+	for i := 1; i <= 100; i++ {
+		statements = append(statements, fmt.Sprintf(
+			`INSERT INTO moz_places (url, title, rev_host, visit_count, last_visit_date, guid, url_hash, origin_id)
+			VALUES ('https://host%d.example.com/%s', NULL, 'moc.elpmaxe.%d.', 1, 1700000000000000, 'guid%08d', %d, 1)`,
+			i, strings.Repeat("p", 60), i, i, 47000000000+i))
+	}
+	statements = append(statements, `DELETE FROM moz_places WHERE id % 10 != 0`)
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up places.sqlite: %v", err)
+		}
+	}
+	db.Close()
+
+	entries, err := CarveHistory(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("CarveHistory() returned error: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("Expected deleted places to be recovered")
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.URL, "https://host10.example.com/") {
+			t.Errorf("Carved a URL still in history: %+v", entry)
+		}
+		if entry.Title != "" || entry.VisitCount != 1 || !entry.LastVisit.Equal(common.FromPRTime(1700000000000000)) {
+			t.Errorf("Unexpected carved entry: %+v", entry)
+		}
+	}
+}
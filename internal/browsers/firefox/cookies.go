@@ -0,0 +1,62 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// cookiesFile is the profile database holding cookies
+const cookiesFile = "cookies.sqlite"
+
+// FindCookies reads where and when the cookies of a profile were set. Cookie
+// values are never read. A profile without cookies.sqlite has no cookies.
+func FindCookies(profile common.Profile) ([]common.Cookie, error) {
+	cookiesPath := filepath.Join(profile.Path, cookiesFile)
+	if _, err := os.Stat(cookiesPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(cookiesPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_cookies")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["moz_cookies"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("moz_cookies.host", "moz_cookies.name", "moz_cookies.creationTime"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT host, name, ` + schema.ColumnOr("moz_cookies", "path", "COALESCE(path, '')", "''") + `,
+			COALESCE(creationTime, 0), ` + schema.ColumnOr("moz_cookies", "lastAccessed", "COALESCE(lastAccessed, 0)", "0") + `
+		FROM moz_cookies
+		ORDER BY creationTime
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cookies []common.Cookie
+	for rows.Next() {
+		var cookie common.Cookie
+		var created, accessed int64
+		if err := rows.Scan(&cookie.Host, &cookie.Name, &cookie.Path, &created, &accessed); err != nil {
+			return nil, err
+		}
+		cookie.Created = common.FromPRTime(created)
+		cookie.LastAccessed = common.FromPRTime(accessed)
+		cookie.Source = cookiesFile
+		cookies = append(cookies, cookie)
+	}
+	return cookies, rows.Err()
+}
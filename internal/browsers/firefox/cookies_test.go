@@ -0,0 +1,44 @@
+package firefox
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindCookies(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, cookiesFile))
+	if err != nil {
+		t.Fatalf("Failed to create cookies.sqlite: %v", err)
+	}
+	statements := []string{
+		`CREATE TABLE moz_cookies (id INTEGER PRIMARY KEY, originAttributes TEXT NOT NULL DEFAULT '', name TEXT,
+			value TEXT, host TEXT, path TEXT, expiry INTEGER, lastAccessed INTEGER, creationTime INTEGER)`,
+		`INSERT INTO moz_cookies VALUES (1, '', 'sid', 'secret', '.example.com', '/', 0, 1700000001000000, 1700000000000000)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up cookies.sqlite: %v", err)
+		}
+	}
+	db.Close()
+
+	cookies, err := FindCookies(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindCookies() returned error: %v", err)
+	}
+	want := common.Cookie{
+		Host:         ".example.com",
+		Name:         "sid",
+		Path:         "/",
+		Created:      common.FromPRTime(1700000000000000),
+		LastAccessed: common.FromPRTime(1700000001000000),
+		Source:       cookiesFile,
+	}
+	if len(cookies) != 1 || cookies[0] != want {
+		t.Errorf("FindCookies() = %+v, want [%+v]", cookies, want)
+	}
+}
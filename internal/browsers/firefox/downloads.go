@@ -0,0 +1,102 @@
+package firefox
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Annotations on a downloaded URL's moz_places row recording the download
+const (
+	destinationAnnotation = "downloads/destinationFileURI"
+	metaDataAnnotation    = "downloads/metaData"
+)
+
+// windowsDrivePath matches the path of a file URI on a Windows drive
+var windowsDrivePath = regexp.MustCompile(`^/[A-Za-z]:`)
+
+// downloadMetaData is the JSON value of the downloads/metaData annotation
+type downloadMetaData struct {
+	EndTime  int64 `json:"endTime"`
+	FileSize int64 `json:"fileSize"`
+}
+
+// FindDownloads reads the downloads Firefox records as annotations on the
+// downloaded URL in places.sqlite. The annotation's creation is the start of
+// the download. Firefox records no referrer, MIME type or hash. A profile
+// without places.sqlite has no downloads.
+func FindDownloads(profile common.Profile) ([]common.Download, error) {
+	placesPath := getHistoryDBPath(profile.Path)
+	if _, err := os.Stat(placesPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(placesPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_annos", "moz_anno_attributes")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["moz_annos"] == nil || schema.Tables["moz_anno_attributes"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("moz_annos.place_id", "moz_annos.anno_attribute_id", "moz_annos.content", "moz_anno_attributes.name"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT p.url, a.content, `+schema.ColumnOr("moz_annos", "dateAdded", "COALESCE(a.dateAdded, 0)", "0")+`, COALESCE(m.content, '')
+		FROM moz_annos a
+		JOIN moz_anno_attributes n ON n.id = a.anno_attribute_id AND n.name = ?
+		JOIN moz_places p ON p.id = a.place_id
+		LEFT JOIN moz_annos m ON m.place_id = a.place_id
+			AND m.anno_attribute_id = (SELECT id FROM moz_anno_attributes WHERE name = ?)
+		ORDER BY a.id
+	`, destinationAnnotation, metaDataAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var downloads []common.Download
+	for rows.Next() {
+		var download common.Download
+		var destination, metaData string
+		var added int64
+		if err := rows.Scan(&download.URL, &destination, &added, &metaData); err != nil {
+			return nil, err
+		}
+		download.TargetPath = fileURIPath(destination)
+		download.StartTime = common.FromPRTime(added)
+
+		var meta downloadMetaData
+		if json.Unmarshal([]byte(metaData), &meta) == nil {
+			download.EndTime = common.FromUnixMillis(meta.EndTime)
+			download.TotalBytes = meta.FileSize
+		}
+		download.Source = placesFile
+		downloads = append(downloads, download)
+	}
+	return downloads, rows.Err()
+}
+
+// fileURIPath returns the local path of a file URI, or the value unchanged
+// if it is not one
+func fileURIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	if windowsDrivePath.MatchString(u.Path) {
+		return strings.ReplaceAll(u.Path[1:], "/", `\`)
+	}
+	return u.Path
+}
@@ -0,0 +1,68 @@
+package firefox
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// createProfileDB creates a profile database in dir from statements
+func createProfileDB(t *testing.T, dir, file string, statements ...string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, file))
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", file, err)
+	}
+	defer db.Close()
+
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up %s: %v", file, err)
+		}
+	}
+}
+
+func TestFindDownloads(t *testing.T) {
+	dir := t.TempDir()
+	createProfileDB(t, dir, placesFile,
+		`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR)`,
+		`CREATE TABLE moz_anno_attributes (id INTEGER PRIMARY KEY, name VARCHAR(32) UNIQUE NOT NULL)`,
+		`CREATE TABLE moz_annos (id INTEGER PRIMARY KEY, place_id INTEGER NOT NULL, anno_attribute_id INTEGER,
+			content LONGVARCHAR, dateAdded INTEGER DEFAULT 0)`,
+		`INSERT INTO moz_places VALUES (1, 'https://cdn.example.org/tool.sh', NULL), (2, 'https://example.net/setup.exe', NULL)`,
+		`INSERT INTO moz_anno_attributes VALUES (1, 'downloads/destinationFileURI'), (2, 'downloads/metaData')`,
+		`INSERT INTO moz_annos VALUES
+			(1, 1, 1, 'file:///home/alice/Downloads/tool.sh', 1700000000000000),
+			(2, 1, 2, '{"state":1,"endTime":1700000005000,"fileSize":2048}', 1700000005000000),
+			(3, 2, 1, 'file:///C:/Users/alice/Downloads/setup%20x.exe', 1700000100000000)`,
+	)
+
+	downloads, err := FindDownloads(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindDownloads() returned error: %v", err)
+	}
+	want := []common.Download{
+		{URL: "https://cdn.example.org/tool.sh", TargetPath: "/home/alice/Downloads/tool.sh", TotalBytes: 2048,
+			StartTime: common.FromPRTime(1700000000000000), EndTime: common.FromUnixMillis(1700000005000), Source: placesFile},
+		{URL: "https://example.net/setup.exe", TargetPath: `C:\Users\alice\Downloads\setup x.exe`,
+			StartTime: common.FromPRTime(1700000100000000), Source: placesFile},
+	}
+	if len(downloads) != len(want) {
+		t.Fatalf("FindDownloads() = %+v, want %+v", downloads, want)
+	}
+	for i := range want {
+		if downloads[i] != want[i] {
+			t.Errorf("downloads[%d] = %+v, want %+v", i, downloads[i], want[i])
+		}
+	}
+
+	t.Run("missing_places_sqlite", func(t *testing.T) {
+		downloads, err := FindDownloads(common.Profile{Path: t.TempDir()})
+		if err != nil || len(downloads) != 0 {
+			t.Errorf("FindDownloads() = %+v, %v; want no downloads", downloads, err)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package firefox
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// extensionsFile is the JSON file listing a profile's add-ons
+const extensionsFile = "extensions.json"
+
+// addon is an entry of extensions.json
+type addon struct {
+	ID            string `json:"id"`
+	Version       string `json:"version"`
+	Type          string `json:"type"`
+	Active        bool   `json:"active"`
+	UserDisabled  bool   `json:"userDisabled"`
+	SourceURI     string `json:"sourceURI"`
+	UpdateURL     string `json:"updateURL"`
+	Path          string `json:"path"`
+	InstallDate   int64  `json:"installDate"`
+	DefaultLocale struct {
+		Name string `json:"name"`
+	} `json:"defaultLocale"`
+}
+
+// FindExtensions reads the extensions listed in extensions.json. Themes,
+// dictionaries and language packs are left out. The URL is where the
+// extension was installed from, or its update URL if that is unknown. A
+// profile without the file has no extensions.
+func FindExtensions(profile common.Profile) ([]common.Extension, error) {
+	path := filepath.Join(profile.Path, extensionsFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := common.ReadProfileFile(path, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Addons []addon `json:"addons"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var extensions []common.Extension
+	for _, a := range file.Addons {
+		if a.Type != "extension" {
+			continue
+		}
+		extension := common.Extension{
+			ID:        a.ID,
+			Name:      a.DefaultLocale.Name,
+			Version:   a.Version,
+			URL:       a.SourceURI,
+			Path:      a.Path,
+			Installed: common.FromUnixMillis(a.InstallDate),
+			Enabled:   a.Active && !a.UserDisabled,
+			Source:    extensionsFile,
+		}
+		if extension.URL == "" {
+			extension.URL = a.UpdateURL
+		}
+		extensions = append(extensions, extension)
+	}
+	return extensions, nil
+}
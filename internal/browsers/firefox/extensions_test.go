@@ -0,0 +1,44 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindExtensions(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"addons": [
+		{"id": "helper@example.com", "version": "1.0", "type": "extension", "active": true,
+			"sourceURI": "https://addons.example.org/helper.xpi", "path": "/profile/extensions/helper@example.com.xpi",
+			"installDate": 1700000000000, "defaultLocale": {"name": "Helper"}},
+		{"id": "tracker@example.net", "version": "2.1", "type": "extension", "active": true, "userDisabled": true,
+			"updateURL": "https://example.net/update.json", "installDate": 1700000100000, "defaultLocale": {"name": "Tracker"}},
+		{"id": "dark@mozilla.org", "type": "theme", "active": true}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, extensionsFile), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	extensions, err := FindExtensions(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindExtensions() returned error: %v", err)
+	}
+	want := []common.Extension{
+		{ID: "helper@example.com", Name: "Helper", Version: "1.0", URL: "https://addons.example.org/helper.xpi",
+			Path: "/profile/extensions/helper@example.com.xpi", Installed: common.FromUnixMillis(1700000000000),
+			Enabled: true, Source: extensionsFile},
+		{ID: "tracker@example.net", Name: "Tracker", Version: "2.1", URL: "https://example.net/update.json",
+			Installed: common.FromUnixMillis(1700000100000), Source: extensionsFile},
+	}
+	if len(extensions) != len(want) {
+		t.Fatalf("FindExtensions() = %+v, want %+v", extensions, want)
+	}
+	for i := range want {
+		if extensions[i] != want[i] {
+			t.Errorf("extensions[%d] = %+v, want %+v", i, extensions[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// faviconsFile is the profile database caching page icons
+const faviconsFile = "favicons.sqlite"
+
+// FindFaviconPages reads the pages mapped to icons in favicons.sqlite and
+// checks each page against moz_places. Firefox records when a cached icon
+// expires rather than when it was fetched, so pages have no update time. A
+// profile without favicons.sqlite has no pages; one without places.sqlite
+// has none of its pages in history.
+func FindFaviconPages(profile common.Profile) ([]common.FaviconPage, error) {
+	faviconsPath := filepath.Join(profile.Path, faviconsFile)
+	if _, err := os.Stat(faviconsPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	history, err := common.ReadStringSet(getHistoryDBPath(profile.Path), profile.UID, `SELECT url FROM moz_places`)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := common.OpenDatabase(faviconsPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_pages_w_icons", "moz_icons_to_pages", "moz_icons")
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Require("moz_pages_w_icons.id", "moz_pages_w_icons.page_url", "moz_icons_to_pages.page_id",
+		"moz_icons_to_pages.icon_id", "moz_icons.id", "moz_icons.icon_url"); err != nil {
+		return nil, err
+	}
+
+	// An icon has a row per size, all sharing its URL
+	rows, err := db.Query(`
+		SELECT DISTINCT p.page_url, i.icon_url
+		FROM moz_pages_w_icons p
+		JOIN moz_icons_to_pages ip ON ip.page_id = p.id
+		JOIN moz_icons i ON i.id = ip.icon_id
+		ORDER BY p.page_url, i.icon_url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []common.FaviconPage
+	for rows.Next() {
+		var page common.FaviconPage
+		if err := rows.Scan(&page.PageURL, &page.IconURL); err != nil {
+			return nil, err
+		}
+		page.InHistory = history[page.PageURL]
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
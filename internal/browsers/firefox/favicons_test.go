@@ -0,0 +1,58 @@
+package firefox
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindFaviconPages(t *testing.T) {
+	dir := t.TempDir()
+	for file, statements := range map[string][]string{
+		faviconsFile: {
+			`CREATE TABLE moz_icons (id INTEGER PRIMARY KEY, icon_url TEXT NOT NULL, fixed_icon_url_hash INTEGER NOT NULL,
+				width INTEGER NOT NULL DEFAULT 0, root INTEGER NOT NULL DEFAULT 0, expire_ms INTEGER NOT NULL DEFAULT 0, data BLOB)`,
+			`CREATE TABLE moz_pages_w_icons (id INTEGER PRIMARY KEY, page_url TEXT NOT NULL, page_url_hash INTEGER NOT NULL)`,
+			`CREATE TABLE moz_icons_to_pages (page_id INTEGER NOT NULL, icon_id INTEGER NOT NULL, PRIMARY KEY (page_id, icon_id))`,
+			`INSERT INTO moz_icons VALUES (1, 'https://mail.example.com/favicon.ico', 0, 16, 0, 0, NULL),
+				(2, 'https://mail.example.com/favicon.ico', 0, 32, 0, 0, NULL),
+				(3, 'https://leak.example.net/icon.png', 0, 16, 0, 0, NULL)`,
+			`INSERT INTO moz_pages_w_icons VALUES (1, 'https://mail.example.com/inbox', 0), (2, 'https://leak.example.net/upload', 0)`,
+			`INSERT INTO moz_icons_to_pages VALUES (1, 1), (1, 2), (2, 3)`,
+		},
+		"places.sqlite": {
+			`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR)`,
+			`INSERT INTO moz_places VALUES (1, 'https://mail.example.com/inbox', 'Inbox')`,
+		},
+	} {
+		db, err := sql.Open("sqlite3", filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+		for _, stmt := range statements {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("Failed to set up %s: %v", file, err)
+			}
+		}
+		db.Close()
+	}
+
+	pages, err := FindFaviconPages(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindFaviconPages() returned error: %v", err)
+	}
+	want := []common.FaviconPage{
+		{PageURL: "https://leak.example.net/upload", IconURL: "https://leak.example.net/icon.png"},
+		{PageURL: "https://mail.example.com/inbox", IconURL: "https://mail.example.com/favicon.ico", InHistory: true},
+	}
+	if len(pages) != len(want) {
+		t.Fatalf("FindFaviconPages() = %+v, want %+v", pages, want)
+	}
+	for i := range want {
+		if pages[i] != want[i] {
+			t.Errorf("pages[%d] = %+v, want %+v", i, pages[i], want[i])
+		}
+	}
+}
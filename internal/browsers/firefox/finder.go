@@ -1,19 +1,48 @@
 package firefox
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sync"
 
 	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/status"
 )
 
+// customPaths holds the operator-configured Firefox profile directories
+var customPaths common.CustomPathSet
+
+// SetCustomPaths replaces the operator-configured Firefox profile directories
+func SetCustomPaths(paths []common.CustomPath) {
+	customPaths.Set(paths)
+}
+
 // FindFirefoxPaths returns the paths to Firefox browser data directories for all users
 func FindFirefoxPaths() []string {
-	users, err := common.UsersFromContext()
+	paths := []string{}
+	for _, userPath := range FindFirefoxUserPaths(common.UserSelection{}) {
+		paths = append(paths, userPath.Path)
+	}
+	return paths
+}
+
+// FindFirefoxUserPaths returns the Firefox browser data directories of the
+// selected users with the user each belongs to. Global custom paths belong to
+// no user and are only returned when every user is selected.
+func FindFirefoxUserPaths(selection common.UserSelection) []common.UserPath {
+	var globalPaths []common.UserPath
+	if selection.IsZero() {
+		for _, path := range customPaths.Global() {
+			globalPaths = append(globalPaths, common.UserPath{Path: path})
+		}
+	}
+
+	users, err := common.SelectedUsers(selection)
 	if err != nil || len(users) == 0 {
-		return []string{}
+		return globalPaths
 	}
 
 	// Filter accessible users
@@ -25,13 +54,13 @@ func FindFirefoxPaths() []string {
 	}
 
 	if len(accessibleUsers) == 0 {
-		return []string{}
+		return globalPaths
 	}
 
 	// Use worker pool for better performance and resource management
 	allPaths := scanUsersWithWorkerPool(accessibleUsers, findFirefoxPathsForUser)
 
-	return allPaths
+	return append(allPaths, globalPaths...)
 }
 
 // findFirefoxPathsForUser returns Firefox paths for a specific user
@@ -64,16 +93,22 @@ func findFirefoxPathsForUser(user common.UserInfo) []string {
 	// Filter paths that exist
 	var existingPaths []string
 	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
+		_, err := os.Stat(path)
+		switch {
+		case err == nil:
 			existingPaths = append(existingPaths, path)
+		case errors.Is(err, fs.ErrPermission):
+			// Other users' data is unreadable without root; report it rather than skip silently
+			status.RecordProfileError("", "firefox", user.Username, user.UID, path, err)
 		}
 	}
 
-	return existingPaths
+	return append(existingPaths, customPaths.ForUser(user)...)
 }
 
-// scanUsersWithWorkerPool scans users concurrently using a worker pool pattern
-func scanUsersWithWorkerPool(users []common.UserInfo, scanFunc func(common.UserInfo) []string) []string {
+// scanUsersWithWorkerPool scans users concurrently using a worker pool pattern,
+// tagging each path found with the user it was found for
+func scanUsersWithWorkerPool(users []common.UserInfo, scanFunc func(common.UserInfo) []string) []common.UserPath {
 	// Determine optimal number of workers based on system and user count
 	maxWorkers := runtime.NumCPU()
 	if len(users) < maxWorkers {
@@ -85,7 +120,7 @@ func scanUsersWithWorkerPool(users []common.UserInfo, scanFunc func(common.UserI
 
 	// Create channels for work distribution
 	userChan := make(chan common.UserInfo, len(users))
-	resultChan := make(chan []string, len(users))
+	resultChan := make(chan []common.UserPath, len(users))
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -94,7 +129,10 @@ func scanUsersWithWorkerPool(users []common.UserInfo, scanFunc func(common.UserI
 		go func() {
 			defer wg.Done()
 			for user := range userChan {
-				paths := scanFunc(user)
+				var paths []common.UserPath
+				for _, path := range scanFunc(user) {
+					paths = append(paths, common.UserPath{Path: path, User: user})
+				}
 				resultChan <- paths
 			}
 		}()
@@ -115,7 +153,7 @@ func scanUsersWithWorkerPool(users []common.UserInfo, scanFunc func(common.UserI
 	}()
 
 	// Collect results
-	var allPaths []string
+	var allPaths []common.UserPath
 	for paths := range resultChan {
 		allPaths = append(allPaths, paths...)
 	}
@@ -2,16 +2,36 @@ package firefox
 
 import (
 	"database/sql"
-	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"osquery-extension-browsers/internal/browsers/common"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// HistorySource reads history from Firefox-based browser profiles
+var HistorySource = common.HistorySource{
+	Engine:              "Firefox",
+	FindProfiles:        FindUserProfiles,
+	FindHistorySince:    FindHistorySince,
+	FindHistoryMatching: FindHistoryMatching,
+	DatabaseFiles:       []string{"places.sqlite", "places.sqlite-wal"},
+	FindAutofill:        FindAutofill,
+	FindPermissions:     FindPermissions,
+	FindPreferences:     FindPreferences,
+	FindSearchEngines:   FindSearchEngines,
+	FindTypedInput:      FindTypedInput,
+	FindFaviconPages:    FindFaviconPages,
+	CarveHistory:        CarveHistory,
+	FindDownloads:       FindDownloads,
+	FindBookmarks:       FindBookmarks,
+	FindExtensions:      FindExtensions,
+	FindCookies:         FindCookies,
+	FindLogins:          FindLogins,
+}
+
 // FindHistory discovers history entries for a specific Firefox profile.
 //
 // The function automatically handles missing places.sqlite databases by returning
@@ -26,6 +46,20 @@ import (
 // This graceful handling aligns with the robust error handling pattern used throughout
 // the extension, where individual profile failures don't stop overall processing.
 func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
+	return FindHistorySince(profile, common.Cursor{})
+}
+
+// FindHistorySince discovers history entries recorded after the given cursor,
+// with the same missing-database handling as FindHistory. A zero cursor
+// returns the full history.
+func FindHistorySince(profile common.Profile, cursor common.Cursor) ([]common.HistoryEntry, error) {
+	return FindHistoryMatching(profile, cursor, common.URLFilter{})
+}
+
+// FindHistoryMatching discovers history entries recorded after the given
+// cursor whose URLs may match the filter. The filter is applied in SQL and
+// can admit extra rows, which callers remove with URLFilter.Matches.
+func FindHistoryMatching(profile common.Profile, cursor common.Cursor, urlFilter common.URLFilter) ([]common.HistoryEntry, error) {
 	historyDBPath := getHistoryDBPath(profile.Path)
 
 	// Check if places.sqlite exists before attempting to open it
@@ -34,23 +68,43 @@ func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
 		return []common.HistoryEntry{}, nil
 	}
 
-	// Open the SQLite database
-	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&immutable=1", historyDBPath))
+	// Open a private snapshot of the SQLite database
+	db, err := common.OpenDatabase(historyDBPath, profile.UID)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_places", "moz_historyvisits")
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Require(historyRequiredColumns...); err != nil {
+		return nil, err
+	}
+	common.ReportSchema(profile, historyDBPath, schema, historySchemaVersions, historyOptionalColumns...)
+
+	filter, args, err := cursorFilter(db.DB, cursor)
+	if err != nil {
+		return nil, err
+	}
+	urlCondition, urlArgs := urlFilterCondition(urlFilter, schema.Has("moz_places", "rev_host"))
+	args = append(args, urlArgs...)
+
 	// Query the history entries
-	// We're using a simple query to get the most recent visits
+	// We're using a simple query to get the most recent visits, selecting the
+	// optional columns this version has
 	query := `
-		SELECT p.id, p.url, p.title, h.visit_date, p.visit_count
+		SELECT h.id, ` + schema.ColumnOr("moz_historyvisits", "from_visit", "COALESCE(h.from_visit, 0)", "0") + `, p.id, p.url,
+			` + schema.ColumnOr("moz_places", "title", "p.title", "NULL") + `, h.visit_date,
+			` + schema.ColumnOr("moz_places", "visit_count", "COALESCE(p.visit_count, 0)", "0") + `
 		FROM moz_places p
 		JOIN moz_historyvisits h ON p.id = h.place_id
+		` + common.Where(filter, urlCondition) + `
 		ORDER BY h.visit_date DESC
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -59,26 +113,30 @@ func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
 	var historyEntries []common.HistoryEntry
 
 	for rows.Next() {
-		var id int64
+		var visitID, fromVisitID, id int64
 		var url string
 		var title sql.NullString
 		var visitDate int64
 		var visitCount int
 
-		err := rows.Scan(&id, &url, &title, &visitDate, &visitCount)
+		err := rows.Scan(&visitID, &fromVisitID, &id, &url, &title, &visitDate, &visitCount)
 		if err != nil {
 			return nil, err
 		}
 
 		historyEntry := common.HistoryEntry{
 			ID:             id,
+			VisitID:        visitID,
+			FromVisitID:    fromVisitID,
 			URL:            url,
 			Title:          title.String,
-			VisitTime:      parseUnixTime(visitDate),
+			VisitTime:      common.FromPRTime(visitDate),
 			VisitCount:     visitCount,
 			ProfileID:      profile.ID,
 			BrowserType:    profile.BrowserType,
 			BrowserVariant: profile.BrowserVariant,
+			Username:       profile.Username,
+			UID:            profile.UID,
 		}
 
 		historyEntries = append(historyEntries, historyEntry)
@@ -91,18 +149,103 @@ func FindHistory(profile common.Profile) ([]common.HistoryEntry, error) {
 	return historyEntries, nil
 }
 
+// historySchemaQuery reads the places.sqlite schema version, which Firefox
+// keeps in the SQLite user version
+const historySchemaQuery = `PRAGMA user_version`
+
+// historySchemaVersions are the places.sqlite schema versions the history
+// query was tested against
+var historySchemaVersions = common.SchemaVersions{Min: 30, Max: 78}
+
+// historyRequiredColumns are the places.sqlite columns the history query
+// cannot do without
+var historyRequiredColumns = []string{
+	"moz_places.id", "moz_places.url",
+	"moz_historyvisits.id", "moz_historyvisits.place_id", "moz_historyvisits.visit_date",
+}
+
+// historyOptionalColumns are the places.sqlite columns the history query
+// reads when a version has them, and fills in as empty otherwise
+var historyOptionalColumns = []string{
+	"moz_places.title", "moz_places.visit_count", "moz_places.rev_host", "moz_historyvisits.from_visit",
+}
+
+// placesFile is the profile database holding history, bookmarks and
+// downloads
+const placesFile = "places.sqlite"
+
 // getHistoryDBPath returns the path to the history database for a given profile
 func getHistoryDBPath(profilePath string) string {
-	return filepath.Join(profilePath, "places.sqlite")
+	return filepath.Join(profilePath, placesFile)
+}
+
+// cursorFilter returns the condition selecting visits after the cursor.
+// Visit IDs restart once history is cleared, so the ID is only trusted while
+// the visit it points at still carries the recorded date; otherwise visits are
+// selected by date instead.
+func cursorFilter(db *sql.DB, cursor common.Cursor) (string, []interface{}, error) {
+	if cursor.IsZero() {
+		return "", nil, nil
+	}
+
+	var visitDate int64
+	err := db.QueryRow(`SELECT visit_date FROM moz_historyvisits WHERE id = ?`, cursor.VisitID).Scan(&visitDate)
+	switch {
+	case err == nil && visitDate == common.ToPRTime(cursor.VisitTime):
+		return "h.id > ?", []interface{}{cursor.VisitID}, nil
+	case err != nil && err != sql.ErrNoRows:
+		return "", nil, err
+	}
+
+	return "h.visit_date > ?", []interface{}{common.ToPRTime(cursor.VisitTime)}, nil
 }
 
-// parseUnixTime converts Unix timestamp to time.Time
-// Firefox's timestamp is in microseconds since Unix epoch (1970-01-01 00:00:00 UTC)
-func parseUnixTime(unixTime int64) time.Time {
-	if unixTime == 0 {
-		return time.Time{}
+// urlFilterCondition returns a condition preselecting URLs that may match the
+// filter. Hosts and domains are looked up through the indexed rev_host
+// column, which holds the host reversed with a trailing dot; without it they
+// are left to URLFilter.Matches.
+func urlFilterCondition(filter common.URLFilter, hasRevHost bool) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	var schemes []string
+	for _, scheme := range filter.Schemes {
+		schemes = append(schemes, `p.url LIKE ? ESCAPE '\'`)
+		args = append(args, common.LikePattern(scheme)+":%")
+	}
+
+	if !hasRevHost {
+		return common.AnyOf(schemes), args
+	}
+
+	var hosts []string
+	for _, host := range filter.Hosts {
+		hosts = append(hosts, "p.rev_host = ?")
+		args = append(args, reverseHost(host))
+	}
+
+	// A domain and its subdomains share the reversed domain as a prefix
+	// ending in a dot; '/' sorts right after '.' and bounds the range
+	var domains []string
+	for _, domain := range filter.Domains {
+		prefix := reverseHost(domain)
+		domains = append(domains, "(p.rev_host >= ? AND p.rev_host < ?)")
+		args = append(args, prefix, strings.TrimSuffix(prefix, ".")+"/")
 	}
 
-	// Convert microseconds to nanoseconds for time.Unix
-	return time.Unix(0, unixTime*1000)
+	for _, alternatives := range [][]string{schemes, hosts, domains} {
+		if condition := common.AnyOf(alternatives); condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// reverseHost returns host in the form Firefox stores in rev_host
+func reverseHost(host string) string {
+	runes := []rune(strings.ToLower(host))
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes) + "."
 }
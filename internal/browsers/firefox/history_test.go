@@ -1,13 +1,15 @@
 package firefox
 
 import (
+	"database/sql"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
-	"time"
 
 	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/status"
 )
 
 func TestFindHistory(t *testing.T) {
@@ -195,48 +197,6 @@ func TestGetHistoryDBPath(t *testing.T) {
 	}
 }
 
-func TestParseUnixTime(t *testing.T) {
-	tests := []struct {
-		name     string
-		unixTime int64
-		expected time.Time
-	}{
-		{
-			name:     "zero_time",
-			unixTime: 0,
-			expected: time.Time{},
-		},
-		{
-			name:     "firefox_timestamp",
-			unixTime: 1640995200000000, // 2022-01-01 00:00:00 UTC in microseconds
-			expected: time.Unix(1640995200, 0),
-		},
-		{
-			name:     "current_time_microseconds",
-			unixTime: 1640995200123456,                 // With microseconds
-			expected: time.Unix(1640995200, 123456000), // Convert to nanoseconds
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := parseUnixTime(tt.unixTime)
-
-			if tt.name == "zero_time" {
-				if !result.IsZero() {
-					t.Errorf("parseUnixTime(%d) should return zero time, got %v", tt.unixTime, result)
-				}
-			} else {
-				// For non-zero times, check that the Unix seconds match
-				if result.Unix() != tt.expected.Unix() {
-					t.Errorf("parseUnixTime(%d) = %v, expected Unix seconds %d, got %d",
-						tt.unixTime, result, tt.expected.Unix(), result.Unix())
-				}
-			}
-		})
-	}
-}
-
 func TestFindHistoryErrorHandling(t *testing.T) {
 	t.Run("profile_with_zen_browser_variant", func(t *testing.T) {
 		// Create a temporary directory for testing
@@ -336,3 +296,176 @@ func TestFindHistoryErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestFindHistoryMatching(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "places.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create places.sqlite: %v", err)
+	}
+	statements := []string{
+		`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR,
+			rev_host LONGVARCHAR, visit_count INTEGER DEFAULT 0)`,
+		`CREATE INDEX moz_places_hostindex ON moz_places (rev_host)`,
+		`CREATE TABLE moz_historyvisits (id INTEGER PRIMARY KEY, from_visit INTEGER, place_id INTEGER, visit_date INTEGER)`,
+		`INSERT INTO moz_places (id, url, title, rev_host, visit_count) VALUES
+			(1, 'https://example.com/', 'Example', 'moc.elpmaxe.', 1),
+			(2, 'https://mail.example.com/inbox', 'Mail', 'moc.elpmaxe.liam.', 1),
+			(3, 'http://badexample.com/', 'Lookalike', 'moc.elpmaxedab.', 1),
+			(4, 'https://example.org/', 'Other TLD', 'gro.elpmaxe.', 1)`,
+		`INSERT INTO moz_historyvisits (id, place_id, visit_date) VALUES
+			(1, 1, 1640995200000000), (2, 2, 1640995201000000),
+			(3, 3, 1640995202000000), (4, 4, 1640995203000000)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up places.sqlite: %v", err)
+		}
+	}
+	db.Close()
+
+	profile := common.Profile{ID: "test-profile", Path: dir, BrowserType: "firefox", BrowserVariant: "firefox"}
+
+	tests := []struct {
+		name   string
+		filter common.URLFilter
+		want   []int64
+	}{
+		{"domain_includes_subdomains", common.URLFilter{Domains: []string{"example.com"}}, []int64{1, 2}},
+		{"host", common.URLFilter{Hosts: []string{"mail.example.com"}}, []int64{2}},
+		{"scheme", common.URLFilter{Schemes: []string{"http"}}, []int64{3}},
+		{"scheme_and_domain", common.URLFilter{Schemes: []string{"https"}, Domains: []string{"example.com", "example.org"}}, []int64{1, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := FindHistoryMatching(profile, common.Cursor{}, tt.filter)
+			if err != nil {
+				t.Fatalf("FindHistoryMatching() returned error: %v", err)
+			}
+			var got []int64
+			for i := len(entries) - 1; i >= 0; i-- {
+				got = append(got, entries[i].ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("FindHistoryMatching() places = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindHistorySchemaVersions(t *testing.T) {
+	tests := []struct {
+		name       string
+		statements []string
+		filter     common.URLFilter
+		wantErr    bool
+		wantNotice string
+		wantTitle  string
+		wantCount  int
+	}{
+		{
+			name: "current_version",
+			statements: []string{
+				`PRAGMA user_version = 77`,
+				`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR, rev_host LONGVARCHAR,
+					visit_count INTEGER DEFAULT 0, hidden INTEGER DEFAULT 0 NOT NULL, frecency INTEGER DEFAULT -1 NOT NULL,
+					last_visit_date INTEGER, guid TEXT, url_hash INTEGER DEFAULT 0 NOT NULL, origin_id INTEGER)`,
+				`CREATE TABLE moz_historyvisits (id INTEGER PRIMARY KEY, from_visit INTEGER, place_id INTEGER,
+					visit_date INTEGER, visit_type INTEGER, session INTEGER, source INTEGER DEFAULT 0 NOT NULL)`,
+				`INSERT INTO moz_places (id, url, title, rev_host, visit_count) VALUES (1, 'https://example.com/', 'Example', 'moc.elpmaxe.', 4)`,
+				`INSERT INTO moz_historyvisits (id, from_visit, place_id, visit_date) VALUES (1, 0, 1, 1640995200000000)`,
+			},
+			filter:    common.URLFilter{Hosts: []string{"example.com"}},
+			wantTitle: "Example",
+			wantCount: 4,
+		},
+		{
+			name: "unknown_old_version_without_rev_host",
+			statements: []string{
+				`PRAGMA user_version = 11`,
+				`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR)`,
+				`CREATE TABLE moz_historyvisits (id INTEGER PRIMARY KEY, place_id INTEGER, visit_date INTEGER)`,
+				`INSERT INTO moz_places VALUES (1, 'https://example.com/', 'Example')`,
+				`INSERT INTO moz_historyvisits VALUES (1, 1, 1640995200000000)`,
+			},
+			filter: common.URLFilter{Hosts: []string{"example.com"}},
+			wantNotice: "schema version 11 is outside the tested versions 30-78; missing optional columns: " +
+				"moz_historyvisits.from_visit, moz_places.rev_host, moz_places.visit_count",
+			wantTitle: "Example",
+		},
+		{
+			name: "unknown_future_version",
+			statements: []string{
+				`PRAGMA user_version = 90`,
+				`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, rev_host LONGVARCHAR,
+					visit_count INTEGER DEFAULT 0, site_name TEXT)`,
+				`CREATE TABLE moz_historyvisits (id INTEGER PRIMARY KEY, from_visit INTEGER, place_id INTEGER, visit_date INTEGER)`,
+				`INSERT INTO moz_places VALUES (1, 'https://example.com/', 'moc.elpmaxe.', 1, 'Example')`,
+				`INSERT INTO moz_historyvisits VALUES (1, 0, 1, 1640995200000000)`,
+			},
+			wantNotice: "schema version 90 is outside the tested versions 30-78; missing optional columns: moz_places.title",
+			wantCount:  1,
+		},
+		{
+			name: "missing_required_column",
+			statements: []string{
+				`PRAGMA user_version = 90`,
+				`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR)`,
+				`CREATE TABLE moz_historyvisits (id INTEGER PRIMARY KEY, place_id INTEGER, visited_at INTEGER)`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "places.sqlite")
+			db, err := sql.Open("sqlite3", path)
+			if err != nil {
+				t.Fatalf("Failed to create places.sqlite: %v", err)
+			}
+			for _, stmt := range tt.statements {
+				if _, err := db.Exec(stmt); err != nil {
+					t.Fatalf("Failed to set up places.sqlite: %v", err)
+				}
+			}
+			db.Close()
+
+			profile := common.Profile{ID: "test-profile", Path: dir, BrowserType: "firefox", BrowserVariant: "firefox"}
+			entries, err := FindHistoryMatching(profile, common.Cursor{}, tt.filter)
+			if tt.wantErr {
+				if err == nil || status.Classify(err) != status.ClassSchemaMismatch {
+					t.Fatalf("FindHistoryMatching() error = %v, want a schema mismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindHistoryMatching() returned error: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("FindHistoryMatching() returned %d entries, want 1", len(entries))
+			}
+			entry := entries[0]
+			if entry.URL != "https://example.com/" || entry.Title != tt.wantTitle || entry.VisitCount != tt.wantCount {
+				t.Errorf("entry url, title, count = %q, %q, %d, want https://example.com/, %q, %d",
+					entry.URL, entry.Title, entry.VisitCount, tt.wantTitle, tt.wantCount)
+			}
+
+			var notice *status.SchemaNotice
+			notices := status.SchemaNotices()
+			for i := range notices {
+				if notices[i].Path == path {
+					notice = &notices[i]
+				}
+			}
+			switch {
+			case tt.wantNotice == "" && notice != nil:
+				t.Errorf("unexpected schema notice %q", notice.Message)
+			case tt.wantNotice != "" && (notice == nil || notice.Message != tt.wantNotice):
+				t.Errorf("schema notice = %v, want %q", notice, tt.wantNotice)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package firefox
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// loginsFile is the JSON file holding saved passwords
+const loginsFile = "logins.json"
+
+// savedLogin is the unencrypted part of an entry of logins.json
+type savedLogin struct {
+	Hostname     string `json:"hostname"`
+	TimeCreated  int64  `json:"timeCreated"`
+	TimeLastUsed int64  `json:"timeLastUsed"`
+	TimesUsed    int    `json:"timesUsed"`
+}
+
+// FindLogins reads the sites a profile saved passwords for and when they
+// were used. The encrypted usernames and passwords are never read. A
+// profile without logins.json has no logins.
+func FindLogins(profile common.Profile) ([]common.Login, error) {
+	path := filepath.Join(profile.Path, loginsFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := common.ReadProfileFile(path, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Logins []savedLogin `json:"logins"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	logins := make([]common.Login, 0, len(file.Logins))
+	for _, l := range file.Logins {
+		logins = append(logins, common.Login{
+			URL:       l.Hostname,
+			Created:   common.FromUnixMillis(l.TimeCreated),
+			LastUsed:  common.FromUnixMillis(l.TimeLastUsed),
+			TimesUsed: l.TimesUsed,
+			Source:    loginsFile,
+		})
+	}
+	return logins, nil
+}
@@ -0,0 +1,42 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindLogins(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"nextId": 2, "logins": [
+		{"id": 1, "hostname": "https://mail.example.com", "encryptedUsername": "MDoE", "encryptedPassword": "MDoE",
+			"timeCreated": 1700000000000, "timeLastUsed": 1700000100000, "timePasswordChanged": 1700000000000, "timesUsed": 3}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, loginsFile), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	logins, err := FindLogins(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindLogins() returned error: %v", err)
+	}
+	want := common.Login{
+		URL:       "https://mail.example.com",
+		Created:   common.FromUnixMillis(1700000000000),
+		LastUsed:  common.FromUnixMillis(1700000100000),
+		TimesUsed: 3,
+		Source:    loginsFile,
+	}
+	if len(logins) != 1 || logins[0] != want {
+		t.Errorf("FindLogins() = %+v, want [%+v]", logins, want)
+	}
+
+	t.Run("missing_file", func(t *testing.T) {
+		logins, err := FindLogins(common.Profile{Path: t.TempDir()})
+		if err != nil || len(logins) != 0 {
+			t.Errorf("FindLogins() = %+v, %v; want no logins", logins, err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// permissionsFile is the profile database holding per-site permissions
+const permissionsFile = "permissions.sqlite"
+
+// expireTypeTime marks permissions that expire at their expireTime
+const expireTypeTime = 2
+
+// permissionTypes maps Firefox permission types to shared names
+var permissionTypes = map[string]string{
+	"camera":               common.PermissionCamera,
+	"microphone":           common.PermissionMicrophone,
+	"desktop-notification": common.PermissionNotifications,
+	"geo":                  common.PermissionGeolocation,
+	"clipboard":            common.PermissionClipboard,
+}
+
+// permissionSettings maps nsIPermissionManager values to shared names
+var permissionSettings = map[int]string{
+	1: common.SettingAllow,
+	2: common.SettingBlock,
+	3: common.SettingAsk,
+	8: common.SettingSessionOnly,
+}
+
+// FindPermissions reads the per-site permissions of a profile from
+// permissions.sqlite. Versions before Firefox 42 keyed permissions by host in
+// moz_hosts, read here as the origin. A profile without the database has
+// none.
+func FindPermissions(profile common.Profile) ([]common.SitePermission, error) {
+	permissionsPath := filepath.Join(profile.Path, permissionsFile)
+	if _, err := os.Stat(permissionsPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(permissionsPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_perms", "moz_hosts")
+	if err != nil {
+		return nil, err
+	}
+	table, origin := "moz_perms", "origin"
+	if schema.Tables[table] == nil && schema.Tables["moz_hosts"] != nil {
+		table, origin = "moz_hosts", "host"
+	}
+	if err := schema.Require(table+"."+origin, table+".type", table+".permission"); err != nil {
+		return nil, err
+	}
+	number := func(column string) string {
+		return schema.ColumnOr(table, column, "COALESCE("+column+", 0)", "0")
+	}
+
+	// Times are in milliseconds since the Unix epoch
+	rows, err := db.Query(`
+		SELECT ` + origin + `, type, permission, ` + number("expireType") + `, ` + number("expireTime") + `, ` + number("modificationTime") + `
+		FROM ` + table + `
+		ORDER BY type, ` + origin + `
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []common.SitePermission
+	for rows.Next() {
+		var origin, permissionType string
+		var value, expireType int
+		var expireTime, modificationTime int64
+		if err := rows.Scan(&origin, &permissionType, &value, &expireType, &expireTime, &modificationTime); err != nil {
+			return nil, err
+		}
+
+		permission, ok := permissionTypes[permissionType]
+		if !ok {
+			permission = permissionType
+		}
+		setting, ok := permissionSettings[value]
+		if !ok {
+			setting = strconv.Itoa(value)
+		}
+
+		sitePermission := common.SitePermission{
+			Origin:       origin,
+			Permission:   permission,
+			Setting:      setting,
+			LastModified: common.FromUnixMillis(modificationTime),
+		}
+		if expireType == expireTypeTime {
+			sitePermission.Expiration = common.FromUnixMillis(expireTime)
+		}
+		permissions = append(permissions, sitePermission)
+	}
+	return permissions, rows.Err()
+}
@@ -0,0 +1,67 @@
+package firefox
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindPermissions(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, permissionsFile))
+	if err != nil {
+		t.Fatalf("Failed to create permissions.sqlite: %v", err)
+	}
+	statements := []string{
+		`CREATE TABLE moz_perms (id INTEGER PRIMARY KEY, origin TEXT, type TEXT, permission INTEGER,
+			expireType INTEGER, expireTime INTEGER, modificationTime INTEGER)`,
+		`INSERT INTO moz_perms VALUES (1, 'https://push.example.net', 'desktop-notification', 1, 0, 0, 1700000000000)`,
+		`INSERT INTO moz_perms VALUES (2, 'https://maps.example.com', 'geo', 2, 2, 1800000000000, 1700000000000)`,
+		`INSERT INTO moz_perms VALUES (3, 'https://example.org', 'cookie', 8, 0, 0, 1700000000000)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up permissions.sqlite: %v", err)
+		}
+	}
+	db.Close()
+
+	permissions, err := FindPermissions(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindPermissions() returned error: %v", err)
+	}
+	modified := common.FromUnixMillis(1700000000000)
+	want := []common.SitePermission{
+		{Origin: "https://example.org", Permission: "cookie", Setting: common.SettingSessionOnly, LastModified: modified},
+		{Origin: "https://push.example.net", Permission: common.PermissionNotifications, Setting: common.SettingAllow, LastModified: modified},
+		{Origin: "https://maps.example.com", Permission: common.PermissionGeolocation, Setting: common.SettingBlock, LastModified: modified, Expiration: common.FromUnixMillis(1800000000000)},
+	}
+	if len(permissions) != len(want) {
+		t.Fatalf("FindPermissions() = %+v, want %+v", permissions, want)
+	}
+	for i := range want {
+		if permissions[i] != want[i] {
+			t.Errorf("permission %d = %+v, want %+v", i, permissions[i], want[i])
+		}
+	}
+
+	t.Run("moz_hosts", func(t *testing.T) {
+		dir := t.TempDir()
+		createProfileDB(t, dir, permissionsFile,
+			`CREATE TABLE moz_hosts (id INTEGER PRIMARY KEY, host TEXT, type TEXT, permission INTEGER,
+				expireType INTEGER, expireTime INTEGER, appId INTEGER, isInBrowserElement INTEGER)`,
+			`INSERT INTO moz_hosts VALUES (1, 'maps.example.com', 'geo', 1, 0, 0, 0, 0)`,
+		)
+
+		permissions, err := FindPermissions(common.Profile{Path: dir})
+		if err != nil {
+			t.Fatalf("FindPermissions() returned error: %v", err)
+		}
+		want := common.SitePermission{Origin: "maps.example.com", Permission: common.PermissionGeolocation, Setting: common.SettingAllow}
+		if len(permissions) != 1 || permissions[0] != want {
+			t.Errorf("FindPermissions() = %+v, want [%+v]", permissions, want)
+		}
+	})
+}
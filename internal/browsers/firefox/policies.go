@@ -0,0 +1,104 @@
+package firefox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// linuxPolicyFiles are the policies.json files Firefox reads on Linux: the
+// system-wide file and the distribution directories of common installs
+var linuxPolicyFiles = []string{
+	"/etc/firefox/policies/policies.json",
+	"/usr/lib/firefox/distribution/policies.json",
+	"/usr/lib64/firefox/distribution/policies.json",
+	"/usr/lib/firefox-esr/distribution/policies.json",
+	"/opt/firefox/distribution/policies.json",
+}
+
+// PolicyFile reads a Firefox policies.json file. Firefox policies are always
+// enforced, so every policy has the managed scope.
+type PolicyFile struct {
+	// Path is the policies.json file
+	Path string
+}
+
+// Browser returns the browser the file configures
+func (f PolicyFile) Browser() string {
+	return "firefox"
+}
+
+// Location returns the policies.json file
+func (f PolicyFile) Location() string {
+	return f.Path
+}
+
+// Policies reads the policies in the file; a missing file has none
+func (f PolicyFile) Policies() ([]common.Policy, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Policies map[string]json.RawMessage `json:"policies"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, &os.PathError{Op: "parse", Path: f.Path, Err: err}
+	}
+
+	names := make([]string, 0, len(document.Policies))
+	for name := range document.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var policies []common.Policy
+	for _, name := range names {
+		policies = append(policies, common.Policy{
+			Browser: f.Browser(),
+			Name:    name,
+			Value:   policyText(document.Policies[name]),
+			Scope:   common.PolicyManaged,
+			Source:  f.Path,
+		})
+	}
+	return policies, nil
+}
+
+// PolicySources returns the Firefox policy sources on this platform. Only
+// Linux policies.json files are read so far.
+func PolicySources() []common.PolicySource {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	var sources []common.PolicySource
+	for _, path := range linuxPolicyFiles {
+		sources = append(sources, PolicyFile{Path: path})
+	}
+	return sources
+}
+
+// policyText formats a policy value: strings unquoted, anything else as
+// compact JSON
+func policyText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if err := json.Compact(&b, raw); err != nil {
+		return strings.TrimSpace(string(raw))
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestPolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	content := `{"policies": {
+		"DisableTelemetry": true,
+		"DNSOverHTTPS": {"Enabled": false, "Locked": true},
+		"Homepage": {"URL": "https://intranet.example.com/"},
+		"SearchEngines": {"Default": "DuckDuckGo"}
+	}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	policies, err := PolicyFile{Path: path}.Policies()
+	if err != nil {
+		t.Fatalf("Policies() returned error: %v", err)
+	}
+	want := []common.Policy{
+		{Browser: "firefox", Name: "DNSOverHTTPS", Value: `{"Enabled":false,"Locked":true}`, Scope: common.PolicyManaged, Source: path},
+		{Browser: "firefox", Name: "DisableTelemetry", Value: "true", Scope: common.PolicyManaged, Source: path},
+		{Browser: "firefox", Name: "Homepage", Value: `{"URL":"https://intranet.example.com/"}`, Scope: common.PolicyManaged, Source: path},
+		{Browser: "firefox", Name: "SearchEngines", Value: `{"Default":"DuckDuckGo"}`, Scope: common.PolicyManaged, Source: path},
+	}
+	if !reflect.DeepEqual(policies, want) {
+		t.Errorf("Policies() = %+v, want %+v", policies, want)
+	}
+
+	if policies, err := (PolicyFile{Path: filepath.Join(t.TempDir(), "policies.json")}).Policies(); err != nil || len(policies) != 0 {
+		t.Errorf("Expected no policies for a missing file, got %v, %v", policies, err)
+	}
+}
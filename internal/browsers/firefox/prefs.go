@@ -0,0 +1,351 @@
+package firefox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// prefsFiles are the preference files of a profile. prefs.js is written by
+// Firefox; user.js is written by users or tools and overrides it at startup.
+var prefsFiles = []string{"prefs.js", "user.js"}
+
+// Preference values that are the defaults despite being non-zero
+const (
+	// proxyTypeSystem is network.proxy.type for the system proxy settings
+	proxyTypeSystem = "5"
+
+	// trrModeOff is network.trr.mode for DNS over HTTPS explicitly disabled
+	trrModeOff = "5"
+)
+
+// Pref is a preference set by a user_pref, pref or sticky_pref statement
+type Pref struct {
+	// Name is the preference name
+	Name string
+
+	// Value is the preference value; strings are unescaped
+	Value string
+
+	// Type is one of the common.Preference value type constants
+	Type string
+}
+
+// ParsePrefs parses the preference statements of a prefs.js or user.js file.
+// Comments are skipped, and a malformed statement is skipped up to the next
+// semicolon so one bad line does not hide the others.
+func ParsePrefs(data string) []Pref {
+	p := &prefsParser{data: data}
+	var prefs []Pref
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return prefs
+		}
+		pref, err := p.statement()
+		if err != nil {
+			p.skipStatement()
+			continue
+		}
+		prefs = append(prefs, pref)
+	}
+}
+
+// prefsParser reads preference statements from a prefs file
+type prefsParser struct {
+	data string
+	pos  int
+}
+
+// errSyntax reports a malformed statement
+var errSyntax = errors.New("malformed preference statement")
+
+// statement parses one `name("pref", value);` statement
+func (p *prefsParser) statement() (Pref, error) {
+	start := p.pos
+	for p.pos < len(p.data) && (isLetter(p.data[p.pos]) || p.data[p.pos] == '_') {
+		p.pos++
+	}
+	switch p.data[start:p.pos] {
+	case "user_pref", "pref", "sticky_pref", "lock_pref":
+	default:
+		return Pref{}, errSyntax
+	}
+
+	if !p.expect('(') {
+		return Pref{}, errSyntax
+	}
+	p.skipSpace()
+	name, err := p.quoted()
+	if err != nil {
+		return Pref{}, err
+	}
+	if !p.expect(',') {
+		return Pref{}, errSyntax
+	}
+	p.skipSpace()
+	pref, err := p.value()
+	if err != nil {
+		return Pref{}, err
+	}
+	pref.Name = name
+
+	// Default preference files may pass attributes such as "sticky" or "locked"
+	for p.expect(',') {
+		p.skipSpace()
+		for p.pos < len(p.data) && isLetter(p.data[p.pos]) {
+			p.pos++
+		}
+	}
+	if !p.expect(')') || !p.expect(';') {
+		return Pref{}, errSyntax
+	}
+	return pref, nil
+}
+
+// value parses a string, integer or boolean preference value
+func (p *prefsParser) value() (Pref, error) {
+	if p.pos >= len(p.data) {
+		return Pref{}, errSyntax
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '"' || c == '\'':
+		s, err := p.quoted()
+		return Pref{Value: s, Type: common.PreferenceString}, err
+	case c == '-' || c == '+' || isDigit(c):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.data) && isDigit(p.data[p.pos]) {
+			p.pos++
+		}
+		n, err := strconv.ParseInt(p.data[start:p.pos], 10, 64)
+		if err != nil {
+			return Pref{}, errSyntax
+		}
+		return Pref{Value: strconv.FormatInt(n, 10), Type: common.PreferenceInteger}, nil
+	case strings.HasPrefix(p.data[p.pos:], "true"):
+		p.pos += len("true")
+		return Pref{Value: "true", Type: common.PreferenceBoolean}, nil
+	case strings.HasPrefix(p.data[p.pos:], "false"):
+		p.pos += len("false")
+		return Pref{Value: "false", Type: common.PreferenceBoolean}, nil
+	}
+	return Pref{}, errSyntax
+}
+
+// quoted parses a single- or double-quoted string with JavaScript escapes
+func (p *prefsParser) quoted() (string, error) {
+	if p.pos >= len(p.data) || (p.data[p.pos] != '"' && p.data[p.pos] != '\'') {
+		return "", errSyntax
+	}
+	quote := p.data[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\n':
+			return "", errSyntax
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errSyntax
+}
+
+// escape decodes the escape sequence following a backslash
+func (p *prefsParser) escape(b *strings.Builder) error {
+	if p.pos >= len(p.data) {
+		return errSyntax
+	}
+	c := p.data[p.pos]
+	p.pos++
+
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'x':
+		n, err := p.hex(2)
+		if err != nil {
+			return err
+		}
+		b.WriteRune(rune(n))
+	case 'u':
+		n, err := p.hex(4)
+		if err != nil {
+			return err
+		}
+		r := rune(n)
+		// Characters outside the BMP are written as surrogate pairs
+		if utf16.IsSurrogate(r) && strings.HasPrefix(p.data[p.pos:], `\u`) {
+			p.pos += 2
+			low, err := p.hex(4)
+			if err != nil {
+				return err
+			}
+			r = utf16.DecodeRune(r, rune(low))
+		}
+		b.WriteRune(r)
+	default:
+		// \\, \", \' and any other escaped character stand for themselves
+		b.WriteByte(c)
+	}
+	return nil
+}
+
+// hex parses n hexadecimal digits
+func (p *prefsParser) hex(n int) (uint64, error) {
+	if p.pos+n > len(p.data) {
+		return 0, errSyntax
+	}
+	v, err := strconv.ParseUint(p.data[p.pos:p.pos+n], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid escape", errSyntax)
+	}
+	p.pos += n
+	return v, nil
+}
+
+// expect consumes c after optional whitespace and reports whether it was there
+func (p *prefsParser) expect(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and //, # and /* */ comments
+func (p *prefsParser) skipSpace() {
+	for p.pos < len(p.data) {
+		rest := p.data[p.pos:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n':
+			p.pos++
+		case strings.HasPrefix(rest, "//") || rest[0] == '#':
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				p.pos += end + 1
+			} else {
+				p.pos = len(p.data)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				p.pos += end + 4
+			} else {
+				p.pos = len(p.data)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipStatement skips past the next semicolon or line end
+func (p *prefsParser) skipStatement() {
+	if end := strings.IndexAny(p.data[p.pos:], ";\n"); end >= 0 {
+		p.pos += end + 1
+	} else {
+		p.pos = len(p.data)
+	}
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// prefRisk returns the risk category of a security-relevant preference
+// value, or the empty string
+func prefRisk(name, value string) string {
+	switch {
+	case name == "network.proxy.type":
+		if value != proxyTypeSystem && value != "0" {
+			return common.RiskProxy
+		}
+	case strings.HasPrefix(name, "network.proxy."):
+		if value != "" && value != "0" && value != "false" {
+			return common.RiskProxy
+		}
+	case name == "app.update.auto" || name == "app.update.enabled" || name == "app.update.service.enabled":
+		if value == "false" {
+			return common.RiskUpdatesDisabled
+		}
+	case name == "app.update.disabledForTesting":
+		if value == "true" {
+			return common.RiskUpdatesDisabled
+		}
+	case strings.HasPrefix(name, "browser.safebrowsing.") && strings.HasSuffix(name, ".enabled"):
+		if value == "false" {
+			return common.RiskSafeBrowsingOff
+		}
+	case name == "security.enterprise_roots.enabled":
+		if value == "true" {
+			return common.RiskEnterpriseRoots
+		}
+	case name == "xpinstall.signatures.required":
+		if value == "false" {
+			return common.RiskUnsignedExtensions
+		}
+	case name == "network.trr.mode":
+		if value != "0" && value != trrModeOff {
+			return common.RiskDNSOverHTTPS
+		}
+	case name == "network.trr.uri" || name == "network.trr.custom_uri":
+		if value != "" {
+			return common.RiskDNSOverHTTPS
+		}
+	case name == "browser.startup.homepage":
+		return common.RiskStartupHomepage
+	}
+	return ""
+}
+
+// FindPreferences reads the preferences set in a profile's prefs.js and
+// user.js, flagging security-relevant values with their risk category
+func FindPreferences(profile common.Profile) ([]common.Preference, error) {
+	var preferences []common.Preference
+	for _, file := range prefsFiles {
+		path := filepath.Join(profile.Path, file)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		data, err := common.ReadProfileFile(path, profile.UID)
+		if err != nil {
+			return nil, err
+		}
+		for _, pref := range ParsePrefs(string(data)) {
+			preferences = append(preferences, common.Preference{
+				Key:    pref.Name,
+				Value:  pref.Value,
+				Type:   pref.Type,
+				Source: file,
+				Risk:   prefRisk(pref.Name, pref.Value),
+			})
+		}
+	}
+	return preferences, nil
+}
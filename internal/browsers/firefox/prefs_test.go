@@ -0,0 +1,90 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestParsePrefs(t *testing.T) {
+	data := `// Mozilla User Preferences
+/* Do not edit this file. */
+user_pref("app.update.auto", false);
+user_pref("browser.startup.homepage", "https://example.com/?a=1&b=\"2\"");
+user_pref("network.proxy.type", 1);
+user_pref("offset", -30);
+user_pref("emoji", "😀 \x41 it\'s");
+user_pref("broken", );
+# trailing comment
+pref("locked.default", true, locked);
+user_pref('single', 'quoted');
+`
+
+	got := ParsePrefs(data)
+	want := []Pref{
+		{Name: "app.update.auto", Value: "false", Type: common.PreferenceBoolean},
+		{Name: "browser.startup.homepage", Value: `https://example.com/?a=1&b="2"`, Type: common.PreferenceString},
+		{Name: "network.proxy.type", Value: "1", Type: common.PreferenceInteger},
+		{Name: "offset", Value: "-30", Type: common.PreferenceInteger},
+		{Name: "emoji", Value: "😀 A it's", Type: common.PreferenceString},
+		{Name: "locked.default", Value: "true", Type: common.PreferenceBoolean},
+		{Name: "single", Value: "quoted", Type: common.PreferenceString},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePrefs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPrefRisk(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"network.proxy.type", "1", common.RiskProxy},
+		{"network.proxy.type", "5", ""},
+		{"network.proxy.autoconfig_url", "http://wpad.example/proxy.pac", common.RiskProxy},
+		{"app.update.auto", "false", common.RiskUpdatesDisabled},
+		{"app.update.auto", "true", ""},
+		{"browser.safebrowsing.malware.enabled", "false", common.RiskSafeBrowsingOff},
+		{"security.enterprise_roots.enabled", "true", common.RiskEnterpriseRoots},
+		{"xpinstall.signatures.required", "false", common.RiskUnsignedExtensions},
+		{"network.trr.mode", "3", common.RiskDNSOverHTTPS},
+		{"network.trr.mode", "5", ""},
+		{"network.trr.uri", "https://doh.example/dns-query", common.RiskDNSOverHTTPS},
+		{"browser.startup.homepage", "https://example.com", common.RiskStartupHomepage},
+		{"browser.tabs.warnOnClose", "false", ""},
+	}
+
+	for _, tt := range tests {
+		if got := prefRisk(tt.name, tt.value); got != tt.want {
+			t.Errorf("prefRisk(%q, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFindPreferences(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"prefs.js": `user_pref("xpinstall.signatures.required", true);`,
+		"user.js":  `user_pref("xpinstall.signatures.required", false);`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	preferences, err := FindPreferences(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindPreferences() returned error: %v", err)
+	}
+	want := []common.Preference{
+		{Key: "xpinstall.signatures.required", Value: "true", Type: common.PreferenceBoolean, Source: "prefs.js"},
+		{Key: "xpinstall.signatures.required", Value: "false", Type: common.PreferenceBoolean, Source: "user.js", Risk: common.RiskUnsignedExtensions},
+	}
+	if !reflect.DeepEqual(preferences, want) {
+		t.Errorf("FindPreferences() = %+v, want %+v", preferences, want)
+	}
+}
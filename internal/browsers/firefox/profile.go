@@ -7,52 +7,117 @@ import (
 	"path/filepath"
 
 	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/status"
 
 	"github.com/go-ini/ini"
 )
 
 // FindProfiles discovers all profiles for Firefox browsers
 func FindProfiles() ([]common.Profile, error) {
+	return FindUserProfiles(common.UserSelection{})
+}
+
+// FindUserProfiles discovers the Firefox browser profiles of the selected users
+func FindUserProfiles(selection common.UserSelection) ([]common.Profile, error) {
 	var profiles []common.Profile
 
 	// Get the paths to Firefox browser data directories
-	firefoxPaths := FindFirefoxPaths()
+	firefoxPaths := FindFirefoxUserPaths(selection)
+
+	for _, userPath := range firefoxPaths {
+		profilesDir := userPath.Path
 
-	for _, profilesDir := range firefoxPaths {
 		// Check if the profiles directory exists
 		if _, err := os.Stat(profilesDir); os.IsNotExist(err) {
 			continue
 		}
 
+		// Profiles under a custom path with a configured browser report that browser
+		browser := customPaths.BrowserFor(profilesDir)
+		reportedBrowser := browser
+		if reportedBrowser == "" {
+			reportedBrowser = "firefox"
+		}
+
 		// Read the profiles.ini file
 		profilesIniPath := filepath.Join(profilesDir, "profiles.ini")
 		if _, err := os.Stat(profilesIniPath); os.IsNotExist(err) {
 			// If profiles.ini doesn't exist, try to find profiles in the directory
 			profilesFromDir, err := findProfilesInDirectory(profilesDir)
-			if err == nil {
-				profiles = append(profiles, profilesFromDir...)
+			if err != nil {
+				status.RecordProfileError("", reportedBrowser, userPath.User.Username, userPath.User.UID, profilesDir, err)
+				continue
 			}
+			status.ClearProfileError("", profilesDir)
+			profiles = append(profiles, safeProfiles(withUser(withBrowser(profilesFromDir, browser), userPath.User), userPath.User)...)
 			continue
 		}
 
 		// Parse the profiles.ini file
-		profilesFromIni, err := readProfilesIni(profilesIniPath, profilesDir)
+		profilesFromIni, err := readProfilesIni(profilesIniPath, profilesDir, userPath.User.UID)
 		if err != nil {
+			status.RecordProfileError("", reportedBrowser, userPath.User.Username, userPath.User.UID, profilesIniPath, err)
 			continue
 		}
+		status.ClearProfileError("", profilesIniPath)
 
-		profiles = append(profiles, profilesFromIni...)
+		profiles = append(profiles, safeProfiles(withUser(withBrowser(profilesFromIni, browser), userPath.User), userPath.User)...)
 	}
 
 	return profiles, nil
 }
 
-// readProfilesIni reads profile information from the profiles.ini file
-func readProfilesIni(profilesIniPath, profilesDir string) ([]common.Profile, error) {
+// withBrowser overrides the browser type and variant of profiles when a
+// browser is configured for their custom path
+func withBrowser(profiles []common.Profile, browser string) []common.Profile {
+	if browser == "" {
+		return profiles
+	}
+	for i := range profiles {
+		profiles[i].BrowserType = browser
+		profiles[i].BrowserVariant = browser
+	}
+	return profiles
+}
+
+// withUser records the owning user on profiles
+func withUser(profiles []common.Profile, user common.UserInfo) []common.Profile {
+	for i := range profiles {
+		profiles[i].Username = user.Username
+		profiles[i].UID = user.UID
+		profiles[i].HomeDir = user.HomeDir
+	}
+	return profiles
+}
+
+// safeProfiles drops profiles whose path resolves outside the owner's home,
+// such as absolute or traversing Path= entries in a crafted profiles.ini, and
+// points the rest at their resolved directories
+func safeProfiles(profiles []common.Profile, owner common.UserInfo) []common.Profile {
+	var safe []common.Profile
+	for _, profile := range profiles {
+		resolved, ok := common.SafeProfilePath(profile.BrowserType, profile.Path, owner)
+		if !ok {
+			continue
+		}
+		profile.Path = resolved
+		safe = append(safe, profile)
+	}
+	return safe
+}
+
+// readProfilesIni reads profile information from the profiles.ini file, as
+// the owner with the given UID and without following symlinks
+func readProfilesIni(profilesIniPath, profilesDir, uid string) ([]common.Profile, error) {
 	var profiles []common.Profile
 
+	data, err := common.ReadProfileFile(profilesIniPath, uid)
+	if err != nil {
+		return profiles, err
+	}
+
 	// Load the INI file
-	cfg, err := ini.Load(profilesIniPath)
+	cfg, err := ini.Load(data)
 	if err != nil {
 		return profiles, err
 	}
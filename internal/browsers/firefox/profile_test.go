@@ -0,0 +1,77 @@
+package firefox
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestSafeProfilesFromCraftedIni(t *testing.T) {
+	home := t.TempDir()
+	outside := t.TempDir()
+	profilesDir := filepath.Join(home, ".mozilla", "firefox")
+
+	for _, dir := range []string{"good.default", "linked-target"} {
+		if err := os.MkdirAll(filepath.Join(profilesDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(profilesDir, "escape.default")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(profilesDir, "loop.default"), filepath.Join(profilesDir, "loop.default")); err != nil {
+		t.Fatal(err)
+	}
+
+	ini := strings.Join([]string{
+		"[Profile0]", "Name=good", "IsRelative=1", "Path=good.default", "",
+		"[Profile1]", "Name=absolute", "IsRelative=0", "Path=" + outside, "",
+		"[Profile2]", "Name=traversal", "IsRelative=1", "Path=../../../../etc", "",
+		"[Profile3]", "Name=escape", "IsRelative=1", "Path=escape.default", "",
+		"[Profile4]", "Name=loop", "IsRelative=1", "Path=loop.default", "",
+	}, "\n")
+	iniPath := filepath.Join(profilesDir, "profiles.ini")
+	if err := os.WriteFile(iniPath, []byte(ini), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := readProfilesIni(iniPath, profilesDir, "")
+	if err != nil {
+		t.Fatalf("readProfilesIni() error = %v", err)
+	}
+	if len(profiles) != 5 {
+		t.Fatalf("Expected 5 parsed profiles, got %d", len(profiles))
+	}
+
+	owner := common.UserInfo{Username: "alice", UID: "1000", HomeDir: home}
+	safe := safeProfiles(profiles, owner)
+
+	var names []string
+	for _, profile := range safe {
+		names = append(names, profile.Name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "good" {
+		t.Errorf("safeProfiles() kept %q, want only good", got)
+	}
+}
+
+func TestReadProfilesIniRefusesSymlink(t *testing.T) {
+	profilesDir := t.TempDir()
+	target := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(target, []byte("[Profile0]\nPath=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	iniPath := filepath.Join(profilesDir, "profiles.ini")
+	if err := os.Symlink(target, iniPath); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	if profiles, err := readProfilesIni(iniPath, profilesDir, ""); err == nil {
+		t.Errorf("readProfilesIni() followed a symlinked profiles.ini, got %+v", profiles)
+	}
+}
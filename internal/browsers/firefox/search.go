@@ -0,0 +1,110 @@
+package firefox
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// searchFile is the mozLz4-compressed JSON file holding a profile's search
+// engines
+const searchFile = "search.json.mozlz4"
+
+// searchSettings is the part of search.json holding the engines and the
+// default engine
+type searchSettings struct {
+	Engines  []searchEngine `json:"engines"`
+	MetaData struct {
+		DefaultEngineID string `json:"defaultEngineId"`
+		Current         string `json:"current"`
+	} `json:"metaData"`
+}
+
+// searchEngine is an engine in search.json
+type searchEngine struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"_name"`
+	LoadPath       string      `json:"_loadPath"`
+	IconURL        string      `json:"_iconURL"`
+	DefinedAliases []string    `json:"_definedAliases"`
+	URLs           []searchURL `json:"_urls"`
+	MetaData       struct {
+		Alias string `json:"alias"`
+	} `json:"_metaData"`
+}
+
+// searchURL is one of an engine's URL templates. Results pages have no type
+// or text/html; suggestion URLs have a JSON type.
+type searchURL struct {
+	Template string `json:"template"`
+	Type     string `json:"type"`
+}
+
+// FindSearchEngines reads the search engines of a profile from
+// search.json.mozlz4 and marks the default. A profile without the file has
+// no search engines.
+func FindSearchEngines(profile common.Profile) ([]common.SearchEngine, error) {
+	searchPath := filepath.Join(profile.Path, searchFile)
+	if _, err := os.Stat(searchPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := common.ReadProfileFile(searchPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	data, err = common.DecodeMozLz4(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings searchSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	engines := make([]common.SearchEngine, 0, len(settings.Engines))
+	for _, e := range settings.Engines {
+		engine := common.SearchEngine{
+			Name:       e.Name,
+			Keyword:    e.MetaData.Alias,
+			URL:        e.resultsURL(),
+			FaviconURL: e.IconURL,
+			Source:     searchFile,
+		}
+		if engine.Keyword == "" && len(e.DefinedAliases) > 0 {
+			engine.Keyword = e.DefinedAliases[0]
+		}
+
+		if settings.MetaData.DefaultEngineID != "" {
+			engine.Default = e.ID == settings.MetaData.DefaultEngineID
+		} else {
+			engine.Default = settings.MetaData.Current != "" && e.Name == settings.MetaData.Current
+		}
+		if engine.Default && !e.builtIn() && !common.IsWellKnownSearchURL(engine.URL) {
+			engine.Risk = common.RiskSearchHijack
+		}
+		engines = append(engines, engine)
+	}
+	return engines, nil
+}
+
+// resultsURL returns the engine's search results URL template
+func (e searchEngine) resultsURL() string {
+	for _, u := range e.URLs {
+		if u.Type == "" || u.Type == "text/html" {
+			return u.Template
+		}
+	}
+	return ""
+}
+
+// builtIn reports whether the engine ships with Firefox rather than being
+// added by a user, a web page or an extension
+func (e searchEngine) builtIn() bool {
+	return strings.HasPrefix(e.LoadPath, "[app]") ||
+		strings.HasPrefix(e.LoadPath, "[addon]") && strings.HasSuffix(e.LoadPath, "@search.mozilla.org")
+}
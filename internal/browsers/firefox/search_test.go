@@ -0,0 +1,81 @@
+package firefox
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// writeMozLz4 writes data to path as a mozLz4 file holding a single
+// literals-only LZ4 sequence
+func writeMozLz4(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	out := append([]byte("mozLz40\x00"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[8:], uint32(len(data)))
+	if len(data) < 15 {
+		out = append(out, byte(len(data))<<4)
+	} else {
+		out = append(out, 0xf0)
+		n := len(data) - 15
+		for ; n >= 255; n -= 255 {
+			out = append(out, 255)
+		}
+		out = append(out, byte(n))
+	}
+	out = append(out, data...)
+
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindSearchEngines(t *testing.T) {
+	tests := []struct {
+		name     string
+		metaData string
+		loadPath string
+		wantRisk string
+	}{
+		{"added_default", `{"defaultEngineId":"search-id"}`, "[other]addEngineWithDetails:search@example.biz", common.RiskSearchHijack},
+		{"current_by_name", `{"current":"Search"}`, "[other]addEngineWithDetails:search@example.biz", common.RiskSearchHijack},
+		{"built_in_default", `{"defaultEngineId":"search-id"}`, "[addon]search@search.mozilla.org", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			settings := `{"version":6,"engines":[` +
+				`{"id":"google@search.mozilla.orgdefault","_name":"Google","_loadPath":"[addon]google@search.mozilla.org",` +
+				`"_urls":[{"template":"https://www.google.com/search?q={searchTerms}"}],"_metaData":{}},` +
+				`{"id":"search-id","_name":"Search","_loadPath":"` + tt.loadPath + `","_definedAliases":["@sx"],` +
+				`"_iconURL":"https://search.example.biz/icon.png","_urls":[` +
+				`{"template":"https://search.example.biz/suggest?q={searchTerms}","type":"application/x-suggestions+json"},` +
+				`{"template":"https://search.example.biz/?q={searchTerms}","type":"text/html"}],"_metaData":{}}],` +
+				`"metaData":` + tt.metaData + `}`
+			writeMozLz4(t, filepath.Join(dir, searchFile), []byte(settings))
+
+			engines, err := FindSearchEngines(common.Profile{Path: dir})
+			if err != nil {
+				t.Fatalf("FindSearchEngines() returned error: %v", err)
+			}
+			if len(engines) != 2 {
+				t.Fatalf("Expected two engines, got %+v", engines)
+			}
+			if engines[0].Default || engines[0].Risk != "" {
+				t.Errorf("Unexpected non-default engine: %+v", engines[0])
+			}
+
+			search := engines[1]
+			if !search.Default || search.Risk != tt.wantRisk {
+				t.Errorf("Default = %t, Risk = %q; want true, %q", search.Default, search.Risk, tt.wantRisk)
+			}
+			if search.Keyword != "@sx" || search.URL != "https://search.example.biz/?q={searchTerms}" || search.FaviconURL == "" {
+				t.Errorf("Unexpected engine: %+v", search)
+			}
+		})
+	}
+}
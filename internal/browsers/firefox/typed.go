@@ -0,0 +1,58 @@
+package firefox
+
+import (
+	"os"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// FindTypedInput reads the address bar input history of a profile from
+// places.sqlite: the text users typed and the pages they chose for it.
+// use_count is a weight that decays over time, so it is rounded into hits.
+// Firefox does not record when the text was typed.
+func FindTypedInput(profile common.Profile) ([]common.TypedInput, error) {
+	historyDBPath := getHistoryDBPath(profile.Path)
+	if _, err := os.Stat(historyDBPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := common.OpenDatabase(historyDBPath, profile.UID)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := common.ReadSchema(db, historySchemaQuery, "moz_inputhistory", "moz_places")
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables["moz_inputhistory"] == nil {
+		return nil, nil
+	}
+	if err := schema.Require("moz_inputhistory.place_id", "moz_inputhistory.input", "moz_places.id", "moz_places.url"); err != nil {
+		return nil, err
+	}
+	useCount := schema.ColumnOr("moz_inputhistory", "use_count", "COALESCE(i.use_count, 0)", "0")
+
+	rows, err := db.Query(`
+		SELECT i.input, p.url, ` + schema.ColumnOr("moz_places", "title", "COALESCE(p.title, '')", "''") + `,
+			CAST(ROUND(` + useCount + `) AS INTEGER)
+		FROM moz_inputhistory i
+		JOIN moz_places p ON p.id = i.place_id
+		ORDER BY ` + schema.ColumnOr("moz_inputhistory", "use_count", "i.use_count DESC, ", "") + `i.input
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inputs []common.TypedInput
+	for rows.Next() {
+		input := common.TypedInput{Kind: common.TypedInputHistory, Source: placesFile}
+		if err := rows.Scan(&input.Text, &input.URL, &input.Title, &input.Hits); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, rows.Err()
+}
@@ -0,0 +1,54 @@
+package firefox
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestFindTypedInput(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "places.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create places.sqlite: %v", err)
+	}
+	statements := []string{
+		`CREATE TABLE moz_places (id INTEGER PRIMARY KEY, url LONGVARCHAR, title LONGVARCHAR)`,
+		`CREATE TABLE moz_inputhistory (place_id INTEGER NOT NULL, input LONGVARCHAR NOT NULL,
+			use_count INTEGER, PRIMARY KEY (place_id, input))`,
+		`INSERT INTO moz_places VALUES (1, 'https://mail.example.com/inbox', 'Inbox'), (2, 'https://example.org/', NULL)`,
+		`INSERT INTO moz_inputhistory VALUES (1, 'mail', 2.6), (2, 'exa', 0.4)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up places.sqlite: %v", err)
+		}
+	}
+	db.Close()
+
+	inputs, err := FindTypedInput(common.Profile{Path: dir})
+	if err != nil {
+		t.Fatalf("FindTypedInput() returned error: %v", err)
+	}
+	want := []common.TypedInput{
+		{Kind: common.TypedInputHistory, Text: "mail", URL: "https://mail.example.com/inbox", Title: "Inbox", Hits: 3, Source: "places.sqlite"},
+		{Kind: common.TypedInputHistory, Text: "exa", URL: "https://example.org/", Hits: 0, Source: "places.sqlite"},
+	}
+	if len(inputs) != len(want) {
+		t.Fatalf("FindTypedInput() = %+v, want %+v", inputs, want)
+	}
+	for i := range want {
+		if inputs[i] != want[i] {
+			t.Errorf("inputs[%d] = %+v, want %+v", i, inputs[i], want[i])
+		}
+	}
+
+	t.Run("missing_places_sqlite", func(t *testing.T) {
+		inputs, err := FindTypedInput(common.Profile{Path: t.TempDir()})
+		if err != nil || len(inputs) != 0 {
+			t.Errorf("FindTypedInput() = %+v, %v; want no input", inputs, err)
+		}
+	})
+}
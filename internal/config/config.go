@@ -0,0 +1,394 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KnownBrowsers lists the browser types that can be enabled
+var KnownBrowsers = []string{
+	"chrome", "edge", "chromium", "brave", "vivaldi", "comet",
+	"firefox", "zen", "floorp",
+}
+
+// KnownEngines lists the browser engines custom paths can belong to
+var KnownEngines = []string{"chromium", "firefox"}
+
+// KnownUserSources lists the Linux user sources
+var KnownUserSources = []string{"nss", "passwd", "home_scan"}
+
+// KnownRedactionModes lists the accepted URL redaction modes
+var KnownRedactionModes = []string{"none", "strip_query", "domain_only", "hmac_url", "hmac_domain"}
+
+// KnownFormValueModes lists the accepted form value redaction modes
+var KnownFormValueModes = []string{"keep", "hash", "suppress"}
+
+// KnownLogLevels lists the accepted log levels
+var KnownLogLevels = []string{"debug", "info", "warn", "error"}
+
+// KnownLogFormats lists the accepted log formats
+var KnownLogFormats = []string{"text", "json"}
+
+// Config is the extension configuration file. Both YAML and JSON are accepted.
+type Config struct {
+	// Tables lists the enabled tables; empty enables every table
+	Tables []string `yaml:"tables"`
+
+	// Browsers lists the enabled browser types; empty enables every browser
+	Browsers []string `yaml:"browsers"`
+
+	// CustomPaths adds browser data directories to the built-in ones
+	CustomPaths []CustomPath `yaml:"custom_paths"`
+
+	// Users restricts which users are enumerated
+	Users Users `yaml:"users"`
+
+	// MaxRows caps the rows returned by a single query; 0 means no limit
+	MaxRows int `yaml:"max_rows"`
+
+	// CacheTTL is how long rows are reused for identical queries; 0 disables caching
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+
+	// Log configures where and how much the extension logs
+	Log Log `yaml:"log"`
+
+	// StateDir holds persistent state such as history cursors
+	StateDir string `yaml:"state_dir"`
+
+	// IOCDir holds the indicator lists matched by browser_ioc_matches;
+	// defaults to the ioc directory next to the configuration file
+	IOCDir string `yaml:"ioc_dir"`
+
+	// Events configures the history event collector
+	Events Events `yaml:"events"`
+
+	// Access controls how other users' browser databases are read
+	Access Access `yaml:"access"`
+
+	// Redaction controls how URLs and titles are redacted in every table
+	Redaction Redaction `yaml:"redaction"`
+
+	// Sessions configures how browser_sessions groups visits
+	Sessions Sessions `yaml:"sessions"`
+
+	// Autofill configures which saved form entries browser_autofill reports
+	Autofill Autofill `yaml:"autofill"`
+
+	// Carving configures deleted-record recovery for browser_history_carved
+	Carving Carving `yaml:"carving"`
+
+	// DisplayTimezone is the IANA timezone of display_time columns; empty
+	// uses UTC
+	DisplayTimezone string `yaml:"display_timezone"`
+}
+
+// CustomPath is an additional browser data directory
+type CustomPath struct {
+	// Engine is the browser engine of the directory: chromium or firefox
+	Engine string `yaml:"engine"`
+
+	// Path is the Chromium user data directory or Firefox profiles directory.
+	// A path starting with "~/" is resolved against every user's home.
+	Path string `yaml:"path"`
+
+	// Browser is the browser type reported for profiles found under Path
+	Browser string `yaml:"browser"`
+}
+
+// Users restricts which users are enumerated
+type Users struct {
+	// Include lists the only usernames to enumerate; empty means all users
+	Include []string `yaml:"include"`
+
+	// Exclude lists usernames that are never enumerated
+	Exclude []string `yaml:"exclude"`
+
+	// MinUID is the lowest UID treated as a regular user on Linux; defaults
+	// to UID_MIN from /etc/login.defs
+	MinUID *int `yaml:"min_uid"`
+
+	// MaxUID is the highest UID treated as a regular user on Linux; defaults
+	// to UID_MAX from /etc/login.defs
+	MaxUID *int `yaml:"max_uid"`
+
+	// LoginDefs reads the UID range from /etc/login.defs; defaults to true
+	LoginDefs *bool `yaml:"login_defs"`
+
+	// Sources lists the Linux user sources in order: nss, passwd, home_scan
+	Sources []string `yaml:"sources"`
+
+	// HomeParents are the directories the home_scan source searches
+	HomeParents []string `yaml:"home_parents"`
+}
+
+// Log configures logging
+type Log struct {
+	// Destination is stderr, stdout, syslog or an absolute file path
+	Destination string `yaml:"destination"`
+
+	// Format is text or json
+	Format string `yaml:"format"`
+
+	// Level is one of debug, info, warn or error
+	Level string `yaml:"level"`
+
+	// MaxSizeMB rotates a log file once it reaches this size; 0 disables rotation
+	MaxSizeMB int `yaml:"max_size_mb"`
+
+	// MaxBackups is the number of rotated log files kept
+	MaxBackups int `yaml:"max_backups"`
+}
+
+// Access controls how other users' browser databases are read
+type Access struct {
+	// SnapshotDir is where private database snapshots are created; defaults
+	// to the system temporary directory
+	SnapshotDir string `yaml:"snapshot_dir"`
+
+	// DropPrivileges reads each user's databases as that user when running as root
+	DropPrivileges bool `yaml:"drop_privileges"`
+
+	// AllowedPaths are directories outside users' homes that profile paths
+	// may resolve into, such as relocated profile storage
+	AllowedPaths []string `yaml:"allowed_paths"`
+}
+
+// Redaction is the privacy policy applied to URLs and titles
+type Redaction struct {
+	// Mode is none, strip_query, domain_only, hmac_url or hmac_domain
+	Mode string `yaml:"mode"`
+
+	// DropTitles removes page titles
+	DropTitles bool `yaml:"drop_titles"`
+
+	// KeyFile holds the HMAC key used by the hmac modes
+	KeyFile string `yaml:"key_file"`
+
+	// AllowDomains are domains that are never redacted
+	AllowDomains []string `yaml:"allow_domains"`
+
+	// DenyDomains are domains whose rows are dropped
+	DenyDomains []string `yaml:"deny_domains"`
+
+	// FormValues is keep, hash or suppress, applied to typed form values
+	FormValues string `yaml:"form_values"`
+}
+
+// Autofill configures browser_autofill
+type Autofill struct {
+	// Addresses reports the metadata of saved addresses
+	Addresses bool `yaml:"addresses"`
+}
+
+// Carving configures browser_history_carved
+type Carving struct {
+	// Enabled carves history databases for deleted records; the table is
+	// empty otherwise
+	Enabled bool `yaml:"enabled"`
+}
+
+// Sessions configures browsing session reconstruction
+type Sessions struct {
+	// InactivityGap is the time without visits that ends a session; defaults
+	// to 30 minutes
+	InactivityGap time.Duration `yaml:"inactivity_gap"`
+}
+
+// Events configures the history event collector
+type Events struct {
+	// Enabled starts the collector
+	Enabled bool `yaml:"enabled"`
+
+	// Expiry is how long buffered events are kept
+	Expiry time.Duration `yaml:"expiry"`
+
+	// MaxEvents caps the number of buffered events
+	MaxEvents int `yaml:"max_events"`
+
+	// PollInterval is used when native file watching is unavailable
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// Load reads and validates the configuration file at path. Tables named in
+// the file must be among knownTables.
+func Load(path string, knownTables []string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(knownTables); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Parse decodes a YAML or JSON configuration, rejecting unknown keys
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration for invalid values and reports all
+// problems found
+func (c *Config) Validate(knownTables []string) error {
+	var problems []string
+
+	for _, name := range c.Tables {
+		if !contains(knownTables, name) {
+			problems = append(problems, fmt.Sprintf("tables: unknown table %q (known: %s)", name, strings.Join(knownTables, ", ")))
+		}
+	}
+
+	for _, browser := range c.Browsers {
+		if !contains(KnownBrowsers, strings.ToLower(browser)) {
+			problems = append(problems, fmt.Sprintf("browsers: unknown browser %q (known: %s)", browser, strings.Join(KnownBrowsers, ", ")))
+		}
+	}
+
+	for i, custom := range c.CustomPaths {
+		if !contains(KnownEngines, custom.Engine) {
+			problems = append(problems, fmt.Sprintf("custom_paths[%d].engine: must be one of %s", i, strings.Join(KnownEngines, ", ")))
+		}
+		if custom.Path == "" {
+			problems = append(problems, fmt.Sprintf("custom_paths[%d].path: required", i))
+		} else if !strings.HasPrefix(custom.Path, "~/") && !filepath.IsAbs(custom.Path) {
+			problems = append(problems, fmt.Sprintf("custom_paths[%d].path: must be absolute or start with ~/", i))
+		}
+	}
+
+	if c.Users.MinUID != nil && *c.Users.MinUID < 0 {
+		problems = append(problems, "users.min_uid: must not be negative")
+	}
+
+	if c.Users.MaxUID != nil && c.Users.MinUID != nil && *c.Users.MaxUID < *c.Users.MinUID {
+		problems = append(problems, "users.max_uid: must not be below min_uid")
+	}
+
+	for _, source := range c.Users.Sources {
+		if !contains(KnownUserSources, source) {
+			problems = append(problems, fmt.Sprintf("users.sources: unknown source %q (known: %s)", source, strings.Join(KnownUserSources, ", ")))
+		}
+	}
+
+	for i, parent := range c.Users.HomeParents {
+		if !filepath.IsAbs(parent) {
+			problems = append(problems, fmt.Sprintf("users.home_parents[%d]: must be an absolute path", i))
+		}
+	}
+
+	if c.MaxRows < 0 {
+		problems = append(problems, "max_rows: must not be negative")
+	}
+
+	if c.CacheTTL < 0 {
+		problems = append(problems, "cache_ttl: must not be negative")
+	}
+
+	if c.Log.Level != "" && !contains(KnownLogLevels, c.Log.Level) {
+		problems = append(problems, fmt.Sprintf("log.level: must be one of %s", strings.Join(KnownLogLevels, ", ")))
+	}
+
+	if c.Log.Format != "" && !contains(KnownLogFormats, c.Log.Format) {
+		problems = append(problems, fmt.Sprintf("log.format: must be one of %s", strings.Join(KnownLogFormats, ", ")))
+	}
+
+	switch dest := c.Log.Destination; {
+	case dest == "", dest == "stdout", dest == "stderr", dest == "syslog":
+	case !filepath.IsAbs(dest):
+		problems = append(problems, "log.destination: must be stderr, stdout, syslog or an absolute file path")
+	}
+
+	if c.Log.MaxSizeMB < 0 || c.Log.MaxBackups < 0 {
+		problems = append(problems, "log: max_size_mb and max_backups must not be negative")
+	}
+
+	if c.Access.SnapshotDir != "" && !filepath.IsAbs(c.Access.SnapshotDir) {
+		problems = append(problems, "access.snapshot_dir: must be an absolute path")
+	}
+
+	for i, path := range c.Access.AllowedPaths {
+		if !filepath.IsAbs(path) {
+			problems = append(problems, fmt.Sprintf("access.allowed_paths[%d]: must be an absolute path", i))
+		}
+	}
+
+	if c.Redaction.Mode != "" && !contains(KnownRedactionModes, c.Redaction.Mode) {
+		problems = append(problems, fmt.Sprintf("redaction.mode: must be one of %s", strings.Join(KnownRedactionModes, ", ")))
+	}
+
+	switch {
+	case strings.HasPrefix(c.Redaction.Mode, "hmac_") && c.Redaction.KeyFile == "":
+		problems = append(problems, fmt.Sprintf("redaction.key_file: required for mode %s", c.Redaction.Mode))
+	case c.Redaction.FormValues == "hash" && c.Redaction.KeyFile == "":
+		problems = append(problems, "redaction.key_file: required for form_values hash")
+	case c.Redaction.KeyFile != "" && !filepath.IsAbs(c.Redaction.KeyFile):
+		problems = append(problems, "redaction.key_file: must be an absolute path")
+	}
+
+	if c.Redaction.FormValues != "" && !contains(KnownFormValueModes, c.Redaction.FormValues) {
+		problems = append(problems, fmt.Sprintf("redaction.form_values: must be one of %s", strings.Join(KnownFormValueModes, ", ")))
+	}
+
+	for _, domain := range append(append([]string{}, c.Redaction.AllowDomains...), c.Redaction.DenyDomains...) {
+		if domain == "" || strings.ContainsAny(domain, "/: ") {
+			problems = append(problems, fmt.Sprintf("redaction: invalid domain %q", domain))
+		}
+	}
+
+	if c.StateDir != "" && !filepath.IsAbs(c.StateDir) {
+		problems = append(problems, "state_dir: must be an absolute path")
+	}
+
+	if c.Sessions.InactivityGap < 0 {
+		problems = append(problems, "sessions.inactivity_gap: must not be negative")
+	}
+
+	if c.IOCDir != "" && !filepath.IsAbs(c.IOCDir) {
+		problems = append(problems, "ioc_dir: must be an absolute path")
+	}
+
+	if _, err := time.LoadLocation(c.DisplayTimezone); err != nil {
+		problems = append(problems, fmt.Sprintf("display_timezone: unknown timezone %q", c.DisplayTimezone))
+	}
+
+	if c.Events.Expiry < 0 || c.Events.PollInterval < 0 || c.Events.MaxEvents < 0 {
+		problems = append(problems, "events: expiry, max_events and poll_interval must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// contains reports whether list contains value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTables = []string{"browser_history", "browser_history_new", "browser_history_events"}
+
+func TestLoad(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := `
+tables: [browser_history]
+browsers: [chrome, firefox]
+custom_paths:
+  - engine: chromium
+    path: ~/.config/thorium
+    browser: thorium
+users:
+  include: [alice]
+  exclude: [bob]
+  min_uid: 500
+  login_defs: false
+  sources: [passwd, home_scan]
+  home_parents: [/var/home]
+max_rows: 1000
+cache_ttl: 30s
+log:
+  destination: stderr
+  level: debug
+state_dir: /var/lib/browsers
+events:
+  enabled: true
+  expiry: 1h
+`
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		cfg, err := Load(path, testTables)
+		if err != nil {
+			t.Fatalf("Load() returned error: %v", err)
+		}
+
+		if cfg.CacheTTL != 30*time.Second {
+			t.Errorf("CacheTTL = %v, expected 30s", cfg.CacheTTL)
+		}
+		if cfg.Users.MinUID == nil || *cfg.Users.MinUID != 500 {
+			t.Errorf("Users.MinUID = %v, expected 500", cfg.Users.MinUID)
+		}
+		if cfg.Users.LoginDefs == nil || *cfg.Users.LoginDefs || len(cfg.Users.Sources) != 2 {
+			t.Errorf("Users = %+v", cfg.Users)
+		}
+		if len(cfg.CustomPaths) != 1 || cfg.CustomPaths[0].Browser != "thorium" {
+			t.Errorf("CustomPaths = %+v", cfg.CustomPaths)
+		}
+		if !cfg.Events.Enabled || cfg.Events.Expiry != time.Hour {
+			t.Errorf("Events = %+v", cfg.Events)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		content := `{"browsers": ["edge"], "max_rows": 10, "log": {"level": "warn"}}`
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		cfg, err := Load(path, testTables)
+		if err != nil {
+			t.Fatalf("Load() returned error: %v", err)
+		}
+		if cfg.MaxRows != 10 || cfg.Log.Level != "warn" {
+			t.Errorf("Unexpected config: %+v", cfg)
+		}
+	})
+
+	t.Run("missing_file", func(t *testing.T) {
+		if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), testTables); err == nil {
+			t.Error("Expected error for missing config file")
+		}
+	})
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	if _, err := Parse([]byte("max_row: 10\n")); err == nil {
+		t.Error("Expected error for misspelled key")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	minUID := -1
+	cfg := &Config{
+		Tables:          []string{"browser_cookies"},
+		Browsers:        []string{"netscape"},
+		CustomPaths:     []CustomPath{{Engine: "webkit", Path: "relative/path"}},
+		Users:           Users{MinUID: &minUID, Sources: []string{"ldap"}, HomeParents: []string{"home"}},
+		MaxRows:         -1,
+		Log:             Log{Destination: "logs/ext.log", Level: "trace"},
+		StateDir:        "state",
+		IOCDir:          "ioc",
+		Sessions:        Sessions{InactivityGap: -time.Minute},
+		DisplayTimezone: "Mars/Olympus_Mons",
+		Access:          Access{SnapshotDir: "snapshots", AllowedPaths: []string{"profiles"}},
+		Redaction:       Redaction{Mode: "hmac_url", AllowDomains: []string{"https://example.com/"}, FormValues: "encrypt"},
+	}
+
+	err := cfg.Validate(testTables)
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	for _, field := range []string{"tables", "browsers", "custom_paths[0].engine", "custom_paths[0].path",
+		"users.min_uid", "users.sources", "users.home_parents", "max_rows", "log.level", "log.destination", "state_dir", "ioc_dir", "sessions.inactivity_gap", "display_timezone", "access.snapshot_dir", "access.allowed_paths",
+		"redaction.key_file", "redaction.form_values", "redaction: invalid domain"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Validation error does not mention %s: %v", field, err)
+		}
+	}
+
+	if err := (&Config{}).Validate(testTables); err != nil {
+		t.Errorf("Empty config should be valid, got: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Event is a history entry observed by the collector
+type Event struct {
+	// Time is when the collector saw the entry
+	Time time.Time
+
+	// Entry is the history entry that was added
+	Entry common.HistoryEntry
+}
+
+// Buffer holds events until they expire, like osquery's evented tables
+type Buffer struct {
+	mu        sync.Mutex
+	events    []Event
+	expiry    time.Duration
+	maxEvents int
+	now       func() time.Time
+}
+
+// NewBuffer returns a buffer that keeps events for expiry and holds at most
+// maxEvents, dropping the oldest first. A maxEvents of 0 means no limit.
+func NewBuffer(expiry time.Duration, maxEvents int) *Buffer {
+	return &Buffer{
+		expiry:    expiry,
+		maxEvents: maxEvents,
+		now:       time.Now,
+	}
+}
+
+// Add appends events to the buffer
+func (b *Buffer) Add(events ...Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, events...)
+	if b.maxEvents > 0 && len(b.events) > b.maxEvents {
+		b.events = append([]Event(nil), b.events[len(b.events)-b.maxEvents:]...)
+	}
+}
+
+// Events returns the buffered events that have not yet expired
+func (b *Buffer) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+	return append([]Event(nil), b.events...)
+}
+
+// expire drops events older than the expiry. Events are appended in the
+// order they were seen, so the expired ones are always at the front.
+func (b *Buffer) expire() {
+	cutoff := b.now().Add(-b.expiry)
+
+	i := 0
+	for i < len(b.events) && b.events[i].Time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		b.events = append([]Event(nil), b.events[i:]...)
+	}
+}
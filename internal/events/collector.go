@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/logging"
+	"osquery-extension-browsers/internal/status"
+)
+
+// collectorTable is the table the collected visits are served from, which
+// profile errors are reported against
+const collectorTable = "browser_history_events"
+
+// Options configures a Collector
+type Options struct {
+	// Rescan is how often profiles are rediscovered
+	Rescan time.Duration
+
+	// Debounce is how long to wait after a change before reading the database,
+	// so bursts of writes produce a single read
+	Debounce time.Duration
+}
+
+// DefaultOptions returns the collector options used when none are configured
+func DefaultOptions() Options {
+	return Options{
+		Rescan:   5 * time.Minute,
+		Debounce: 2 * time.Second,
+	}
+}
+
+// watchedProfile tracks the read position of one watched profile
+type watchedProfile struct {
+	source  common.HistorySource
+	profile common.Profile
+	cursor  common.Cursor
+}
+
+// Collector watches every discovered history database and buffers the visits
+// added to it as events
+type Collector struct {
+	sources []common.HistorySource
+	buffer  *Buffer
+	watcher Watcher
+	opts    Options
+
+	mu       sync.Mutex
+	profiles map[string]*watchedProfile
+}
+
+// NewCollector returns a collector that reads history from sources and stores
+// new visits in buffer
+func NewCollector(sources []common.HistorySource, buffer *Buffer, watcher Watcher, opts Options) *Collector {
+	return &Collector{
+		sources:  sources,
+		buffer:   buffer,
+		watcher:  watcher,
+		opts:     opts,
+		profiles: make(map[string]*watchedProfile),
+	}
+}
+
+// Run watches history databases until the context is cancelled
+func (c *Collector) Run(ctx context.Context) {
+	defer c.watcher.Close()
+
+	c.rescan()
+
+	rescan := time.NewTicker(c.opts.Rescan)
+	defer rescan.Stop()
+
+	pending := make(map[string]bool)
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-rescan.C:
+			c.rescan()
+
+		case path, ok := <-c.watcher.Changes():
+			if !ok {
+				return
+			}
+			dir := filepath.Dir(path)
+			if !c.isDatabaseFile(dir, filepath.Base(path)) {
+				continue
+			}
+			pending[dir] = true
+			if debounce == nil {
+				debounce = time.After(c.opts.Debounce)
+			}
+
+		case <-debounce:
+			for dir := range pending {
+				c.collect(dir)
+			}
+			pending = make(map[string]bool)
+			debounce = nil
+		}
+	}
+}
+
+// isDatabaseFile reports whether name is a history database file of the
+// profile watched at dir
+func (c *Collector) isDatabaseFile(dir, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	watched, ok := c.profiles[dir]
+	if !ok {
+		return false
+	}
+	for _, file := range watched.source.DatabaseFiles {
+		if file == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rescan discovers profiles, starts watching new ones and stops watching
+// profiles that no longer exist. New profiles start at their current end so
+// existing history is not reported as events.
+func (c *Collector) rescan() {
+	seen := make(map[string]bool)
+
+	for _, source := range c.sources {
+		profiles, err := source.FindProfiles(common.UserSelection{})
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			seen[profile.Path] = true
+
+			c.mu.Lock()
+			_, known := c.profiles[profile.Path]
+			c.mu.Unlock()
+			if known {
+				continue
+			}
+
+			entries, err := source.FindHistorySince(profile, common.Cursor{})
+			if err != nil {
+				slog.Warn("Failed to read history", append(logging.ProfileArgs(profile), "error", err)...)
+				status.RecordProfileError(collectorTable, profile.BrowserType, profile.Username, profile.UID, profile.Path, err)
+				continue
+			}
+
+			if err := c.watcher.Add(profile.Path); err != nil {
+				slog.Warn("Failed to watch profile", append(logging.ProfileArgs(profile), "error", err)...)
+				continue
+			}
+
+			c.mu.Lock()
+			c.profiles[profile.Path] = &watchedProfile{
+				source:  source,
+				profile: profile,
+				cursor:  common.Cursor{}.Advance(entries),
+			}
+			c.mu.Unlock()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for dir := range c.profiles {
+		if !seen[dir] {
+			c.watcher.Remove(dir)
+			delete(c.profiles, dir)
+		}
+	}
+}
+
+// collect reads the visits added to the profile watched at dir
+func (c *Collector) collect(dir string) {
+	c.mu.Lock()
+	watched, ok := c.profiles[dir]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	entries, err := watched.source.FindHistorySince(watched.profile, watched.cursor)
+	if err != nil {
+		slog.Warn("Failed to read new history", append(logging.ProfileArgs(watched.profile), "error", err)...)
+		status.RecordProfileError(collectorTable, watched.profile.BrowserType, watched.profile.Username, watched.profile.UID, watched.profile.Path, err)
+		return
+	}
+	status.ClearProfileError(collectorTable, watched.profile.Path)
+	if len(entries) == 0 {
+		return
+	}
+
+	now := time.Now()
+	events := make([]Event, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		events = append(events, Event{Time: now, Entry: entries[i]})
+	}
+	c.buffer.Add(events...)
+
+	c.mu.Lock()
+	watched.cursor = watched.cursor.Advance(entries)
+	c.mu.Unlock()
+}
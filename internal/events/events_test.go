@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestBuffer(t *testing.T) {
+	t.Run("expires_old_events", func(t *testing.T) {
+		now := time.Unix(1640995200, 0)
+		buffer := NewBuffer(time.Hour, 0)
+		buffer.now = func() time.Time { return now }
+
+		buffer.Add(
+			Event{Time: now.Add(-2 * time.Hour), Entry: common.HistoryEntry{VisitID: 1}},
+			Event{Time: now.Add(-time.Minute), Entry: common.HistoryEntry{VisitID: 2}},
+		)
+
+		events := buffer.Events()
+		if len(events) != 1 || events[0].Entry.VisitID != 2 {
+			t.Errorf("Expected only the unexpired event, got %+v", events)
+		}
+	})
+
+	t.Run("drops_oldest_over_limit", func(t *testing.T) {
+		buffer := NewBuffer(time.Hour, 2)
+		now := time.Now()
+		for i := int64(1); i <= 3; i++ {
+			buffer.Add(Event{Time: now, Entry: common.HistoryEntry{VisitID: i}})
+		}
+
+		events := buffer.Events()
+		if len(events) != 2 || events[0].Entry.VisitID != 2 || events[1].Entry.VisitID != 3 {
+			t.Errorf("Expected the two newest events, got %+v", events)
+		}
+	})
+}
+
+// testWatchers returns the watchers available on this platform
+func testWatchers() map[string]Watcher {
+	watchers := map[string]Watcher{
+		"poll": NewPollWatcher(20 * time.Millisecond),
+	}
+	if native, err := newNativeWatcher(); err == nil {
+		watchers["native"] = native
+	}
+	return watchers
+}
+
+func TestWatcherReportsChanges(t *testing.T) {
+	for name, watcher := range testWatchers() {
+		t.Run(name, func(t *testing.T) {
+			defer watcher.Close()
+
+			dir := t.TempDir()
+			if err := watcher.Add(dir); err != nil {
+				t.Fatalf("Add() returned error: %v", err)
+			}
+
+			path := filepath.Join(dir, "places.sqlite-wal")
+			if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			timeout := time.After(5 * time.Second)
+			for {
+				select {
+				case changed := <-watcher.Changes():
+					if changed == path {
+						return
+					}
+				case <-timeout:
+					t.Fatalf("No change reported for %s", path)
+				}
+			}
+		})
+	}
+}
+
+// fakeSource is a history source backed by an in-memory list of visits
+type fakeSource struct {
+	mu      sync.Mutex
+	profile common.Profile
+	entries []common.HistoryEntry
+}
+
+func (f *fakeSource) add(entry common.HistoryEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, entry)
+}
+
+func (f *fakeSource) historySource() common.HistorySource {
+	return common.HistorySource{
+		Engine: "Fake",
+		FindProfiles: func(common.UserSelection) ([]common.Profile, error) {
+			return []common.Profile{f.profile}, nil
+		},
+		FindHistorySince: func(_ common.Profile, cursor common.Cursor) ([]common.HistoryEntry, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var entries []common.HistoryEntry
+			for _, entry := range f.entries {
+				if entry.VisitID > cursor.VisitID {
+					entries = append(entries, entry)
+				}
+			}
+			return entries, nil
+		},
+		DatabaseFiles: []string{"History"},
+	}
+}
+
+func TestCollectorBuffersNewVisits(t *testing.T) {
+	dir := t.TempDir()
+	source := &fakeSource{profile: common.Profile{ID: "Default", Path: dir}}
+	source.add(common.HistoryEntry{VisitID: 1, URL: "https://existing.example/"})
+
+	buffer := NewBuffer(time.Hour, 0)
+	collector := NewCollector([]common.HistorySource{source.historySource()}, buffer,
+		NewPollWatcher(20*time.Millisecond), Options{Rescan: time.Hour, Debounce: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go collector.Run(ctx)
+
+	// Wait for the initial scan to register the profile
+	deadline := time.Now().Add(5 * time.Second)
+	for !collector.isDatabaseFile(dir, "History") {
+		if time.Now().After(deadline) {
+			t.Fatal("Profile was never watched")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	source.add(common.HistoryEntry{VisitID: 2, URL: "https://new.example/"})
+	if err := os.WriteFile(filepath.Join(dir, "History"), []byte("changed"), 0600); err != nil {
+		t.Fatalf("Failed to write History: %v", err)
+	}
+
+	for {
+		events := buffer.Events()
+		if len(events) > 0 {
+			if len(events) != 1 || events[0].Entry.URL != "https://new.example/" {
+				t.Errorf("Expected only the new visit, got %+v", events)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("New visit was never buffered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
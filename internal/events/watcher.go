@@ -0,0 +1,163 @@
+package events
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Watcher reports changes to files within a set of watched directories
+type Watcher interface {
+	// Add starts watching a directory
+	Add(dir string) error
+
+	// Remove stops watching a directory
+	Remove(dir string) error
+
+	// Changes returns the channel that receives the paths of changed files
+	Changes() <-chan string
+
+	// Close stops the watcher and closes the changes channel
+	Close() error
+}
+
+// NewWatcher returns the native filesystem watcher for the platform, falling
+// back to polling every pollInterval when no native watcher is available
+func NewWatcher(pollInterval time.Duration) Watcher {
+	watcher, err := newNativeWatcher()
+	if err == nil {
+		return watcher
+	}
+
+	slog.Warn("Native file watching unavailable, polling instead", "interval", pollInterval, "error", err)
+	return NewPollWatcher(pollInterval)
+}
+
+// fileState is the part of a file's metadata compared between polls
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// pollWatcher detects changes by comparing directory listings between polls
+type pollWatcher struct {
+	mu       sync.Mutex
+	dirs     map[string]map[string]fileState
+	changes  chan string
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPollWatcher returns a watcher that compares file modification times and
+// sizes every interval
+func NewPollWatcher(interval time.Duration) Watcher {
+	w := &pollWatcher{
+		dirs:    make(map[string]map[string]fileState),
+		changes: make(chan string, 64),
+		done:    make(chan struct{}),
+	}
+	go w.run(interval)
+	return w
+}
+
+// Add starts watching a directory
+func (w *pollWatcher) Add(dir string) error {
+	states, err := snapshotDir(dir)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirs[dir] = states
+	return nil
+}
+
+// Remove stops watching a directory
+func (w *pollWatcher) Remove(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.dirs, dir)
+	return nil
+}
+
+// Changes returns the channel that receives the paths of changed files
+func (w *pollWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+// Close stops the watcher and closes the changes channel
+func (w *pollWatcher) Close() error {
+	w.stopOnce.Do(func() { close(w.done) })
+	return nil
+}
+
+// run polls the watched directories until the watcher is closed
+func (w *pollWatcher) run(interval time.Duration) {
+	defer close(w.changes)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			for _, path := range w.poll() {
+				select {
+				case w.changes <- path:
+				case <-w.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// poll returns the files that changed since the previous poll
+func (w *pollWatcher) poll() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var changed []string
+	for dir, previous := range w.dirs {
+		current, err := snapshotDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for name, state := range current {
+			if old, ok := previous[name]; !ok || old != state {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+		w.dirs[dir] = current
+	}
+
+	return changed
+}
+
+// snapshotDir records the state of every regular file in a directory
+func snapshotDir(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		states[entry.Name()] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return states, nil
+}
@@ -0,0 +1,134 @@
+//go:build linux
+
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask selects the events that indicate a file was written or replaced
+const inotifyMask = unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_MOVED_TO
+
+// inotifyWatcher reports changes using Linux inotify
+type inotifyWatcher struct {
+	fd       int
+	file     *os.File
+	mu       sync.Mutex
+	dirs     map[int]string
+	wds      map[string]int
+	changes  chan string
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newNativeWatcher returns an inotify based watcher
+func newNativeWatcher() (Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	// A non-blocking descriptor wrapped in os.File uses the runtime poller,
+	// so Close unblocks the pending read. File.Fd is avoided as it would
+	// switch the descriptor back to blocking mode.
+	w := &inotifyWatcher{
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		dirs:    make(map[int]string),
+		wds:     make(map[string]int),
+		changes: make(chan string, 64),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Add starts watching a directory
+func (w *inotifyWatcher) Add(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wd, err := unix.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	w.dirs[wd] = dir
+	w.wds[dir] = wd
+	return nil
+}
+
+// Remove stops watching a directory
+func (w *inotifyWatcher) Remove(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wd, ok := w.wds[dir]
+	if !ok {
+		return nil
+	}
+	delete(w.wds, dir)
+	delete(w.dirs, wd)
+
+	if _, err := unix.InotifyRmWatch(w.fd, uint32(wd)); err != nil {
+		return &os.PathError{Op: "inotify_rm_watch", Path: dir, Err: err}
+	}
+	return nil
+}
+
+// Changes returns the channel that receives the paths of changed files
+func (w *inotifyWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+// Close stops the watcher and closes the changes channel
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.stopOnce.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+// run reads inotify events until the descriptor is closed
+func (w *inotifyWatcher) run() {
+	defer close(w.changes)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			if nameEnd > n {
+				break
+			}
+			name := strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00")
+			offset = nameEnd
+
+			w.mu.Lock()
+			dir, ok := w.dirs[int(event.Wd)]
+			w.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+
+			select {
+			case w.changes <- filepath.Join(dir, name):
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
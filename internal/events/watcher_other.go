@@ -0,0 +1,11 @@
+//go:build !linux
+
+package events
+
+import "errors"
+
+// newNativeWatcher reports that no native watcher is implemented, so the
+// polling watcher is used
+func newNativeWatcher() (Watcher, error) {
+	return nil, errors.New("native file watching is only implemented on Linux")
+}
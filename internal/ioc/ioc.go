@@ -0,0 +1,374 @@
+package ioc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Indicator types
+const (
+	// TypeDomain matches a domain and all of its subdomains
+	TypeDomain = "domain"
+
+	// TypeURLPrefix matches URLs starting with the value
+	TypeURLPrefix = "url"
+
+	// TypeRegex matches URLs against a regular expression
+	TypeRegex = "regex"
+
+	// TypeSHA256 matches the SHA256 of a downloaded file
+	TypeSHA256 = "sha256"
+)
+
+// Indicator is a single entry of an indicator list
+type Indicator struct {
+	// Type is one of the Type constants
+	Type string
+
+	// Value is the domain, URL prefix, expression or hash
+	Value string
+
+	// Source is the name of the list file the indicator came from
+	Source string
+}
+
+// sha256Regex matches a hex SHA256 digest
+var sha256Regex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Parse reads an indicator list with one indicator per line. A line may name
+// its type with a "domain:", "url:", "regex:" or "sha256:" prefix; otherwise
+// 64 hex digits are a hash, values containing "://" a URL prefix and anything
+// else a domain. Blank lines and lines starting with '#' are ignored.
+func Parse(r io.Reader, source string) ([]Indicator, error) {
+	var indicators []Indicator
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		indicator := Indicator{Value: line, Source: source}
+		if prefix, value, ok := strings.Cut(line, ":"); ok && isType(prefix) {
+			indicator.Type, indicator.Value = prefix, strings.TrimSpace(value)
+		} else {
+			indicator.Type = detectType(line)
+		}
+
+		switch indicator.Type {
+		case TypeDomain:
+			indicator.Value = strings.TrimSuffix(strings.ToLower(indicator.Value), ".")
+		case TypeURLPrefix:
+			indicator.Value = strings.ToLower(indicator.Value)
+		case TypeSHA256:
+			if !sha256Regex.MatchString(indicator.Value) {
+				return nil, fmt.Errorf("%s:%d: invalid sha256 %q", source, lineNumber, indicator.Value)
+			}
+			indicator.Value = strings.ToLower(indicator.Value)
+		case TypeRegex:
+			if _, err := regexp.Compile(indicator.Value); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", source, lineNumber, err)
+			}
+		}
+		if indicator.Value == "" {
+			return nil, fmt.Errorf("%s:%d: empty %s indicator", source, lineNumber, indicator.Type)
+		}
+		indicators = append(indicators, indicator)
+	}
+
+	return indicators, scanner.Err()
+}
+
+// isType reports whether prefix names an indicator type
+func isType(prefix string) bool {
+	switch prefix {
+	case TypeDomain, TypeURLPrefix, TypeRegex, TypeSHA256:
+		return true
+	}
+	return false
+}
+
+// detectType guesses the type of an indicator given without a prefix
+func detectType(value string) string {
+	switch {
+	case sha256Regex.MatchString(value):
+		return TypeSHA256
+	case strings.Contains(value, "://"):
+		return TypeURLPrefix
+	default:
+		return TypeDomain
+	}
+}
+
+// Matcher matches artifacts against a set of indicators. Domains are held in
+// a trie of reversed labels and URL prefixes in a byte trie, so matching a URL
+// costs one walk per trie regardless of the number of indicators. Regular
+// expressions are combined into a single expression and only evaluated one
+// by one for URLs that match the combination.
+type Matcher struct {
+	domains  *labelNode
+	prefixes *byteNode
+	combined *regexp.Regexp
+	regexes  []compiledRegex
+	hashes   map[string][]Indicator
+	count    int
+}
+
+// labelNode is a node of the domain trie, keyed by DNS label from the TLD down
+type labelNode struct {
+	children   map[string]*labelNode
+	indicators []Indicator
+}
+
+// byteNode is a node of the URL prefix trie
+type byteNode struct {
+	children   map[byte]*byteNode
+	indicators []Indicator
+}
+
+// compiledRegex is a regex indicator with its compiled expression
+type compiledRegex struct {
+	indicator Indicator
+	re        *regexp.Regexp
+}
+
+// NewMatcher builds a matcher for the given indicators
+func NewMatcher(indicators []Indicator) (*Matcher, error) {
+	m := &Matcher{
+		domains:  &labelNode{},
+		prefixes: &byteNode{},
+		hashes:   make(map[string][]Indicator),
+		count:    len(indicators),
+	}
+
+	var expressions []string
+	for _, indicator := range indicators {
+		switch indicator.Type {
+		case TypeDomain:
+			m.domains.insert(indicator)
+		case TypeURLPrefix:
+			m.prefixes.insert(indicator)
+		case TypeSHA256:
+			m.hashes[indicator.Value] = append(m.hashes[indicator.Value], indicator)
+		case TypeRegex:
+			re, err := regexp.Compile(indicator.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", indicator.Source, err)
+			}
+			m.regexes = append(m.regexes, compiledRegex{indicator: indicator, re: re})
+			expressions = append(expressions, "(?:"+indicator.Value+")")
+		default:
+			return nil, fmt.Errorf("%s: unknown indicator type %q", indicator.Source, indicator.Type)
+		}
+	}
+
+	if len(expressions) > 0 {
+		combined, err := regexp.Compile(strings.Join(expressions, "|"))
+		if err != nil {
+			return nil, err
+		}
+		m.combined = combined
+	}
+	return m, nil
+}
+
+// Len returns the number of indicators in the matcher
+func (m *Matcher) Len() int {
+	return m.count
+}
+
+// insert adds a domain indicator to the trie
+func (n *labelNode) insert(indicator Indicator) {
+	labels := strings.Split(indicator.Value, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		child, ok := n.children[labels[i]]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[string]*labelNode)
+			}
+			child = &labelNode{}
+			n.children[labels[i]] = child
+		}
+		n = child
+	}
+	n.indicators = append(n.indicators, indicator)
+}
+
+// match returns the domain indicators covering host
+func (n *labelNode) match(host string) []Indicator {
+	var matched []Indicator
+	labels := strings.Split(host, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		n = n.children[labels[i]]
+		if n == nil {
+			break
+		}
+		matched = append(matched, n.indicators...)
+	}
+	return matched
+}
+
+// insert adds a URL prefix indicator to the trie
+func (n *byteNode) insert(indicator Indicator) {
+	for i := 0; i < len(indicator.Value); i++ {
+		child, ok := n.children[indicator.Value[i]]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[byte]*byteNode)
+			}
+			child = &byteNode{}
+			n.children[indicator.Value[i]] = child
+		}
+		n = child
+	}
+	n.indicators = append(n.indicators, indicator)
+}
+
+// match returns the URL prefix indicators url starts with
+func (n *byteNode) match(url string) []Indicator {
+	var matched []Indicator
+	for i := 0; i < len(url); i++ {
+		n = n.children[url[i]]
+		if n == nil {
+			break
+		}
+		matched = append(matched, n.indicators...)
+	}
+	return matched
+}
+
+// MatchURL returns the domain, URL prefix and regex indicators matching url
+func (m *Matcher) MatchURL(url string) []Indicator {
+	var matched []Indicator
+	if host := common.ParseURL(url).Host; host != "" {
+		matched = append(matched, m.domains.match(host)...)
+	}
+	matched = append(matched, m.prefixes.match(strings.ToLower(url))...)
+
+	return append(matched, m.MatchText(url)...)
+}
+
+// MatchText returns the regex indicators matching a value that is not a
+// URL, such as a download's path
+func (m *Matcher) MatchText(value string) []Indicator {
+	if m.combined == nil || !m.combined.MatchString(value) {
+		return nil
+	}
+	var matched []Indicator
+	for _, r := range m.regexes {
+		if r.re.MatchString(value) {
+			matched = append(matched, r.indicator)
+		}
+	}
+	return matched
+}
+
+// MatchSHA256 returns the hash indicators matching a hex SHA256 digest
+func (m *Matcher) MatchSHA256(digest string) []Indicator {
+	return m.hashes[strings.ToLower(digest)]
+}
+
+// Load reads every indicator list in dir. Hidden files and subdirectories are
+// skipped, and a list that fails to parse is logged and skipped so one bad
+// file does not disable the others.
+func Load(dir string) (*Matcher, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return loadEntries(dir, entries)
+}
+
+// loadEntries reads the indicator lists among the entries listed from dir
+func loadEntries(dir string, entries []os.DirEntry) (*Matcher, error) {
+	var indicators []Indicator
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		list, err := loadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			slog.Warn("Skipping indicator list", "path", filepath.Join(dir, entry.Name()), "error", err)
+			continue
+		}
+		indicators = append(indicators, list...)
+	}
+
+	return NewMatcher(indicators)
+}
+
+// loadFile parses the indicator list at path
+func loadFile(path string) ([]Indicator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file, filepath.Base(path))
+}
+
+// Loader keeps the matcher for a directory and rebuilds it when the lists
+// in the directory change
+type Loader struct {
+	mu        sync.Mutex
+	dir       string
+	signature string
+	matcher   *Matcher
+}
+
+// Matcher returns the matcher for the lists in dir, reloading them if any
+// file was added, removed or modified since the previous call. The directory
+// is listed once and the listing both signs and loads the lists.
+func (l *Loader) Matcher(dir string) (*Matcher, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	signature := dirSignature(dir, entries)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.matcher != nil && l.dir == dir && l.signature == signature {
+		return l.matcher, nil
+	}
+
+	matcher, err := loadEntries(dir, entries)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("Loaded indicator lists", "dir", dir, "indicators", matcher.Len())
+	l.dir, l.signature, l.matcher = dir, signature, matcher
+	return matcher, nil
+}
+
+// dirSignature summarizes the names, sizes and modification times of the
+// files listed from dir. A file that cannot be stat'ed is signed with its
+// error, so the lists are reloaded once it can be read again.
+func dirSignature(dir string, entries []os.DirEntry) string {
+	var parts []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			slog.Debug("Failed to stat indicator list", "path", filepath.Join(dir, entry.Name()), "error", err)
+			parts = append(parts, fmt.Sprintf("%s/error/%v", entry.Name(), err))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s/%d/%s", entry.Name(), info.Size(), info.ModTime().Format(time.RFC3339Nano)))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,156 @@
+package ioc
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testList = `# Phishing kit
+evil.example
+domain:Tracker.Example.NET.
+https://cdn.example.org/payload/
+url:http://10.0.0.5/
+regex:(?i)/wp-admin/.*\.php$
+` + "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n"
+
+func TestParse(t *testing.T) {
+	indicators, err := Parse(strings.NewReader(testList), "phishing.txt")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Indicator{
+		{TypeDomain, "evil.example", "phishing.txt"},
+		{TypeDomain, "tracker.example.net", "phishing.txt"},
+		{TypeURLPrefix, "https://cdn.example.org/payload/", "phishing.txt"},
+		{TypeURLPrefix, "http://10.0.0.5/", "phishing.txt"},
+		{TypeRegex, `(?i)/wp-admin/.*\.php$`, "phishing.txt"},
+		{TypeSHA256, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "phishing.txt"},
+	}
+	if len(indicators) != len(want) {
+		t.Fatalf("Parse() returned %d indicators, want %d: %+v", len(indicators), len(want), indicators)
+	}
+	for i := range want {
+		if indicators[i] != want[i] {
+			t.Errorf("indicator %d = %+v, want %+v", i, indicators[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"regex:([", "sha256:abc", "domain:"} {
+		if _, err := Parse(strings.NewReader(bad), "bad.txt"); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestMatchURL(t *testing.T) {
+	indicators, err := Parse(strings.NewReader(testList), "phishing.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher, err := NewMatcher(indicators)
+	if err != nil {
+		t.Fatalf("NewMatcher() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://evil.example/login", []string{"evil.example"}},
+		{"https://login.EVIL.example/", []string{"evil.example"}},
+		{"https://notevil.example/", nil},
+		{"https://a.tracker.example.net/px.gif", []string{"tracker.example.net"}},
+		{"https://cdn.example.org/payload/stage2.bin", []string{"https://cdn.example.org/payload/"}},
+		{"https://cdn.example.org/other", nil},
+		{"http://10.0.0.5/wp-admin/setup.PHP", []string{"http://10.0.0.5/", `(?i)/wp-admin/.*\.php$`}},
+		{"about:blank", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			var got []string
+			for _, indicator := range matcher.MatchURL(tt.url) {
+				got = append(got, indicator.Value)
+			}
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("MatchURL(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	if got := matcher.MatchSHA256("E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"); len(got) != 1 {
+		t.Errorf("MatchSHA256() = %v, want one indicator", got)
+	}
+	if got := matcher.MatchText("/home/alice/Downloads/wp-admin/x.php"); len(got) != 1 || got[0].Type != TypeRegex {
+		t.Errorf("MatchText() = %v, want the regex indicator", got)
+	}
+	if got := matcher.MatchText("evil.example"); len(got) != 0 {
+		t.Errorf("MatchText() = %v, want only regexes to match text", got)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "evil.example\n")
+	write("broken.txt", "regex:([\n")
+	write(".hidden", "hidden.example\n")
+
+	loader := &Loader{}
+	matcher, err := loader.Matcher(dir)
+	if err != nil {
+		t.Fatalf("Matcher() error = %v", err)
+	}
+	if matcher.Len() != 1 {
+		t.Errorf("Expected broken and hidden lists to be skipped, got %d indicators", matcher.Len())
+	}
+
+	if again, _ := loader.Matcher(dir); again != matcher {
+		t.Error("Expected an unchanged directory to reuse the matcher")
+	}
+
+	write("b.txt", "bad.example\n")
+	later := time.Now().Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "b.txt"), later, later)
+	reloaded, err := loader.Matcher(dir)
+	if err != nil {
+		t.Fatalf("Matcher() error = %v", err)
+	}
+	if reloaded.Len() != 2 {
+		t.Errorf("Expected the added list to be loaded, got %d indicators", reloaded.Len())
+	}
+}
+
+func TestDirSignatureRecordsStatErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("evil.example\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := dirSignature(dir, entries)
+
+	// A list removed after the directory was read can no longer be stat'ed
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	after := dirSignature(dir, entries)
+	if after == before || !strings.Contains(after, "a.txt/error/") {
+		t.Errorf("dirSignature() = %q, want the stat failure of a.txt recorded", after)
+	}
+}
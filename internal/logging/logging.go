@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Config configures the extension's logger
+type Config struct {
+	// Destination is stderr, stdout, syslog or an absolute file path
+	Destination string
+
+	// Format is text or json
+	Format string
+
+	// Level is debug, info, warn or error
+	Level string
+
+	// MaxSizeMB rotates a log file once it reaches this size; 0 disables rotation
+	MaxSizeMB int
+
+	// MaxBackups is the number of rotated log files kept
+	MaxBackups int
+}
+
+// level is shared by every handler so it can change without replacing them
+var level = new(slog.LevelVar)
+
+// ParseLevel converts a level name into a slog level. An empty name is info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// Configure installs a default slog logger for cfg. Messages written with the
+// standard log package are routed through it at info level. The returned
+// closer releases the new logger's output once it is replaced.
+func Configure(cfg Config) (io.Closer, error) {
+	lvl, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	handler, closer, err := newHandler(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	level.Set(lvl)
+	slog.SetDefault(slog.New(handler))
+	return closer, nil
+}
+
+// newHandler creates the handler and output for cfg
+func newHandler(cfg Config) (slog.Handler, io.Closer, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	newFormatHandler := func(w io.Writer) (slog.Handler, error) {
+		switch cfg.Format {
+		case "", "text":
+			return slog.NewTextHandler(w, opts), nil
+		case "json":
+			return slog.NewJSONHandler(w, opts), nil
+		default:
+			return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+		}
+	}
+
+	var output io.Writer
+	var closer io.Closer = nopCloser{}
+	switch cfg.Destination {
+	case "", "stderr":
+		output = os.Stderr
+	case "stdout":
+		output = os.Stdout
+	case "syslog":
+		writer, err := dialSyslog()
+		if err != nil {
+			return nil, nil, err
+		}
+		inner, err := newFormatHandler(writer)
+		if err != nil {
+			writer.Close()
+			return nil, nil, err
+		}
+		return &syslogHandler{inner: inner, writer: writer}, writer, nil
+	default:
+		file, err := openRotatingFile(cfg.Destination, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		output = file
+		closer = file
+	}
+
+	handler, err := newFormatHandler(output)
+	if err != nil {
+		closer.Close()
+		return nil, nil, err
+	}
+	return handler, closer, nil
+}
+
+// ProfileArgs returns the attributes identifying a profile in log records
+func ProfileArgs(profile common.Profile) []any {
+	return []any{
+		"browser", profile.BrowserType,
+		"profile", profile.ID,
+		"path", profile.Path,
+		"user", profile.Username,
+	}
+}
+
+// nopCloser is the closer of outputs the logger does not own
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogSockets are the local syslog sockets tried in order
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogFacilityDaemon is the syslog facility used for every message
+const syslogFacilityDaemon = 3
+
+// syslogWriter sends each write as one message to the local syslog socket
+type syslogWriter struct {
+	mu       sync.Mutex
+	path     string
+	conn     net.Conn
+	tag      string
+	severity int
+}
+
+// dialSyslog connects to the first available local syslog socket
+func dialSyslog() (*syslogWriter, error) {
+	var errs []error
+	for _, path := range syslogSockets {
+		conn, err := dialSyslogSocket(path)
+		if err == nil {
+			return &syslogWriter{path: path, conn: conn, tag: filepath.Base(os.Args[0])}, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("connecting to syslog: %w", errors.Join(errs...))
+}
+
+// dialSyslogSocket connects to a syslog socket, which may be datagram or stream
+func dialSyslogSocket(path string) (net.Conn, error) {
+	conn, err := net.Dial("unixgram", path)
+	if err == nil {
+		return conn, nil
+	}
+	return net.Dial("unix", path)
+}
+
+// Write sends p as a single syslog message at the current severity,
+// reconnecting once if the socket was closed by the syslog daemon
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	msg := fmt.Sprintf("<%d>%s %s[%d]: %s\n",
+		syslogFacilityDaemon*8+w.severity,
+		time.Now().Format(time.Stamp), w.tag, os.Getpid(),
+		strings.TrimRight(string(p), "\n"))
+
+	if _, err := w.conn.Write([]byte(msg)); err != nil {
+		conn, dialErr := dialSyslogSocket(w.path)
+		if dialErr != nil {
+			return 0, err
+		}
+		w.conn.Close()
+		w.conn = conn
+		if _, err := w.conn.Write([]byte(msg)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close closes the syslog connection
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.Close()
+}
+
+// syslogSeverity maps a slog level to a syslog severity
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// syslogHandler formats records with an inner handler and sends them to
+// syslog with the severity of the record's level
+type syslogHandler struct {
+	inner  slog.Handler
+	writer *syslogWriter
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.writer.mu.Lock()
+	defer h.writer.mu.Unlock()
+
+	h.writer.severity = syslogSeverity(record.Level)
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{inner: h.inner.WithAttrs(attrs), writer: h.writer}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{inner: h.inner.WithGroup(name), writer: h.writer}
+}
@@ -0,0 +1,410 @@
+package redaction
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// Redaction modes applied to URLs
+const (
+	// ModeNone leaves URLs unchanged
+	ModeNone = "none"
+
+	// ModeStripQuery removes query strings and fragments
+	ModeStripQuery = "strip_query"
+
+	// ModeDomainOnly keeps only the registrable domain
+	ModeDomainOnly = "domain_only"
+
+	// ModeHMACURL replaces URLs with a keyed HMAC-SHA256 of the full URL
+	ModeHMACURL = "hmac_url"
+
+	// ModeHMACDomain replaces URLs with a keyed HMAC-SHA256 of the domain
+	ModeHMACDomain = "hmac_domain"
+)
+
+// KnownModes lists the accepted redaction modes
+var KnownModes = []string{ModeNone, ModeStripQuery, ModeDomainOnly, ModeHMACURL, ModeHMACDomain}
+
+// Redaction modes applied to typed form values
+const (
+	// ValuesKeep leaves form values unchanged
+	ValuesKeep = "keep"
+
+	// ValuesHash replaces form values with a keyed HMAC-SHA256, so a known
+	// value can still be looked for by hashing it with the same key
+	ValuesHash = "hash"
+
+	// ValuesSuppress empties form values
+	ValuesSuppress = "suppress"
+)
+
+// KnownValueModes lists the accepted form value redaction modes
+var KnownValueModes = []string{ValuesKeep, ValuesHash, ValuesSuppress}
+
+// ValueColumn holds a value typed into a form
+const ValueColumn = "field_value"
+
+// TypedColumn holds text typed into the address bar, which often spells out
+// the URL it led to. Its query is stripped under strip_query; it is cleared
+// along with the URL under the domain modes, or hashed under hmac_url.
+const TypedColumn = "typed_text"
+
+// Column records the redaction applied to each row with a URL
+const Column = "redaction"
+
+// Values of Column besides the applied mode
+const (
+	// Allowed marks rows on allowlisted domains, which are never redacted
+	Allowed = "allowed"
+
+	// DroppedTitle is appended when the title was removed
+	DroppedTitle = "drop_titles"
+)
+
+// urlColumns are the columns derived from a row's URL
+var urlColumns = []string{"url", "scheme", "host", "port", "domain", "path", "query", "fragment"}
+
+// aggregateURLColumns are the URL columns of rows without a url column of
+// their own, such as sessions summarizing several visits, site permissions
+// or favicon pages
+var aggregateURLColumns = []string{"entry_url", "exit_url", "origin", "page_url", "icon_url"}
+
+// aggregateDomainsColumn lists the comma-separated domains of an aggregate row
+const aggregateDomainsColumn = "top_domains"
+
+// Policy describes how rows are redacted before they leave the extension.
+// The zero value leaves rows unchanged apart from recording "none".
+type Policy struct {
+	// Mode is one of the Mode constants; empty means ModeNone
+	Mode string
+
+	// DropTitles removes page titles
+	DropTitles bool
+
+	// Key is the HMAC key used by the hmac modes
+	Key []byte
+
+	// AllowDomains are domains whose rows are never redacted
+	AllowDomains []string
+
+	// DenyDomains are domains whose rows are dropped entirely
+	DenyDomains []string
+
+	// Values is one of the Values constants applied to typed form values;
+	// empty means ValuesKeep
+	Values string
+}
+
+// Validate checks that the policy can be applied
+func (p Policy) Validate() error {
+	switch p.Mode {
+	case "", ModeNone, ModeStripQuery, ModeDomainOnly:
+	case ModeHMACURL, ModeHMACDomain:
+		if len(p.Key) == 0 {
+			return fmt.Errorf("redaction mode %s requires a key", p.Mode)
+		}
+	default:
+		return fmt.Errorf("unknown redaction mode %q", p.Mode)
+	}
+
+	switch p.Values {
+	case "", ValuesKeep, ValuesSuppress:
+	case ValuesHash:
+		if len(p.Key) == 0 {
+			return fmt.Errorf("form value redaction %s requires a key", p.Values)
+		}
+	default:
+		return fmt.Errorf("unknown form value redaction %q", p.Values)
+	}
+	return nil
+}
+
+// Apply redacts the rows that carry URL columns in place, drops rows on
+// denied domains and records the redaction applied to each remaining row.
+// Rows without URL or form value columns are returned unchanged.
+func (p Policy) Apply(rows []map[string]string) []map[string]string {
+	kept := rows[:0]
+	for _, row := range rows {
+		if _, ok := row[ValueColumn]; ok {
+			p.redactValue(row)
+			kept = append(kept, row)
+			continue
+		}
+
+		rawURL, ok := row["url"]
+		if !ok {
+			p.redactAggregate(row)
+			kept = append(kept, row)
+			continue
+		}
+
+		parts := common.ParseURL(rawURL)
+		switch {
+		case matchesDomain(p.DenyDomains, parts):
+			continue
+		case matchesDomain(p.AllowDomains, parts):
+			row[Column] = Allowed
+		default:
+			row[Column] = p.redact(row, rawURL, parts)
+		}
+		kept = append(kept, row)
+	}
+	return kept
+}
+
+// redact rewrites a row's URL columns and title, returning the applied policy
+func (p Policy) redact(row map[string]string, rawURL string, parts common.URLParts) string {
+	mode := p.Mode
+	if mode == "" {
+		mode = ModeNone
+	}
+
+	switch mode {
+	case ModeStripQuery:
+		set(row, "url", stripQuery(rawURL))
+		set(row, TypedColumn, stripQuery(row[TypedColumn]))
+		set(row, "query", "")
+		set(row, "fragment", "")
+	case ModeDomainOnly:
+		clearURL(row)
+		set(row, "url", parts.Domain)
+		set(row, "scheme", parts.Scheme)
+		set(row, "domain", parts.Domain)
+	case ModeHMACURL:
+		typed := row[TypedColumn]
+		clearURL(row)
+		if typed != "" {
+			set(row, TypedColumn, p.hmac(typed))
+		}
+		set(row, "url", p.hmac(rawURL))
+		set(row, "scheme", parts.Scheme)
+		set(row, "host", p.hmac(parts.Host))
+		set(row, "domain", p.hmac(parts.Domain))
+	case ModeHMACDomain:
+		clearURL(row)
+		set(row, "url", p.hmac(parts.Domain))
+		set(row, "scheme", parts.Scheme)
+		set(row, "domain", p.hmac(parts.Domain))
+	}
+
+	applied := []string{mode}
+	if p.DropTitles {
+		if _, ok := row["title"]; ok {
+			row["title"] = ""
+			if mode == ModeNone {
+				applied = nil
+			}
+			applied = append(applied, DroppedTitle)
+		}
+	}
+
+	// A summary describes the page by its title or URL, so it is rebuilt
+	// from their redacted values
+	if _, ok := row["summary"]; ok && applied[0] != ModeNone {
+		row["summary"] = row["title"]
+		if row["summary"] == "" {
+			row["summary"] = row["url"]
+		}
+	}
+	return strings.Join(applied, ",")
+}
+
+// redactAggregate redacts the URL and domain columns of a row without a url
+// column. Visits and sites on denied domains are expected to have been left
+// out with Denied; denied domains are also dropped from the listed domains.
+func (p Policy) redactAggregate(row map[string]string) {
+	found := false
+	for _, column := range aggregateURLColumns {
+		if value, ok := row[column]; ok {
+			row[column] = p.RedactURL(value)
+			found = true
+		}
+	}
+	if !found {
+		return
+	}
+
+	if domains := row[aggregateDomainsColumn]; domains != "" {
+		hash := p.Mode == ModeHMACURL || p.Mode == ModeHMACDomain
+		var kept []string
+		for _, domain := range strings.Split(domains, ",") {
+			parts := common.URLParts{Host: domain, Domain: domain}
+			switch {
+			case matchesDomain(p.DenyDomains, parts):
+				continue
+			case hash && !matchesDomain(p.AllowDomains, parts):
+				domain = p.hmac(domain)
+			}
+			kept = append(kept, domain)
+		}
+		row[aggregateDomainsColumn] = strings.Join(kept, ",")
+	}
+
+	row[Column] = p.AppliedMode()
+}
+
+// redactValue redacts the typed form value of a row
+func (p Policy) redactValue(row map[string]string) {
+	mode := p.Values
+	if mode == "" {
+		mode = ValuesKeep
+	}
+
+	switch mode {
+	case ValuesHash:
+		row[ValueColumn] = p.hmac(row[ValueColumn])
+	case ValuesSuppress:
+		row[ValueColumn] = ""
+	}
+	row[Column] = mode
+}
+
+// Denied reports whether rawURL, or a Chromium site pattern, is on a denied
+// domain
+func (p Policy) Denied(rawURL string) bool {
+	return matchesDomain(p.DenyDomains, common.ParseURL(patternURL(rawURL)))
+}
+
+// RedactURL returns rawURL, or a Chromium site pattern, as the policy reports
+// it in a column of its own
+func (p Policy) RedactURL(rawURL string) string {
+	parts := common.ParseURL(patternURL(rawURL))
+	if matchesDomain(p.AllowDomains, parts) {
+		return rawURL
+	}
+
+	switch p.Mode {
+	case ModeStripQuery:
+		return stripQuery(rawURL)
+	case ModeDomainOnly:
+		return parts.Domain
+	case ModeHMACURL:
+		return p.hmac(rawURL)
+	case ModeHMACDomain:
+		return p.hmac(parts.Domain)
+	}
+	return rawURL
+}
+
+// settingURLToken matches a URL or host in a setting value listing several,
+// such as space separated DoH templates, proxy rules separated by ';' or
+// Firefox homepages separated by '|'
+var settingURLToken = regexp.MustCompile(`[^\s;,|]+`)
+
+// RedactURLs returns a setting value holding URLs or hosts, such as a
+// homepage, startup URLs or a proxy server, as the policy reports it. JSON
+// lists are redacted element by element and other values token by token, so
+// proxy rules like https=proxy:3128;ftp=proxy:21 keep their shape.
+func (p Policy) RedactURLs(value string) string {
+	var list []string
+	if strings.HasPrefix(value, "[") && json.Unmarshal([]byte(value), &list) == nil {
+		for i, rawURL := range list {
+			list[i] = p.redactSettingURL(rawURL)
+		}
+		var b bytes.Buffer
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(list); err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	return settingURLToken.ReplaceAllStringFunc(value, func(token string) string {
+		if scheme, rest, ok := strings.Cut(token, "="); ok && !strings.ContainsAny(scheme, ":/?") {
+			return scheme + "=" + p.redactSettingURL(rest)
+		}
+		return p.redactSettingURL(token)
+	})
+}
+
+// redactSettingURL redacts one URL of a setting; a host without a scheme is
+// redacted as a web URL and reported without the scheme again
+func (p Policy) redactSettingURL(rawURL string) string {
+	if strings.Contains(rawURL, "://") {
+		return p.RedactURL(rawURL)
+	}
+	return strings.TrimPrefix(p.RedactURL("http://"+rawURL), "http://")
+}
+
+// AppliedMode returns the mode recorded in Column for a row redacted with
+// RedactURL or RedactURLs
+func (p Policy) AppliedMode() string {
+	if p.Mode == "" {
+		return ModeNone
+	}
+	return p.Mode
+}
+
+// patternURL turns a Chromium content settings pattern such as
+// https://[*.]example.com:443 or [*.]example.org into a URL ParseURL
+// understands, dropping the subdomain wildcard and assuming https when the
+// pattern has no scheme. Other values are returned unchanged.
+func patternURL(pattern string) string {
+	url := strings.Replace(pattern, "[*.]", "", 1)
+	if strings.Contains(url, "://") {
+		return url
+	}
+	host, _, _ := strings.Cut(url, "/")
+	name, port, hasPort := strings.Cut(host, ":")
+	if name == "" || name == "*" || hasPort && strings.Trim(port, "0123456789") != "" {
+		return pattern
+	}
+	return "https://" + url
+}
+
+// stripQuery removes the query string and fragment from a URL
+func stripQuery(rawURL string) string {
+	url, _, _ := strings.Cut(rawURL, "#")
+	url, _, _ = strings.Cut(url, "?")
+	return url
+}
+
+// hmac returns the hex HMAC-SHA256 of value, or the empty string for an
+// empty value so missing hosts stay recognizable
+func (p Policy) hmac(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchesDomain reports whether the URL's host is one of domains or a
+// subdomain of one
+func matchesDomain(domains []string, parts common.URLParts) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if domain == "" {
+			continue
+		}
+		if parts.Host == domain || parts.Domain == domain || strings.HasSuffix(parts.Host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// clearURL empties the URL columns present in row
+func clearURL(row map[string]string) {
+	for _, column := range urlColumns {
+		set(row, column, "")
+	}
+	set(row, TypedColumn, "")
+}
+
+// set replaces a column's value if the row has that column
+func set(row map[string]string, column, value string) {
+	if _, ok := row[column]; ok {
+		row[column] = value
+	}
+}
@@ -0,0 +1,291 @@
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// historyRow returns a row with the URL columns of the history tables
+func historyRow() map[string]string {
+	return map[string]string{
+		"url":      "https://mail.example.com/inbox?id=42#msg",
+		"title":    "Inbox (3)",
+		"scheme":   "https",
+		"host":     "mail.example.com",
+		"port":     "443",
+		"domain":   "example.com",
+		"path":     "/inbox",
+		"query":    "id=42",
+		"fragment": "msg",
+	}
+}
+
+func mac(key, value string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   map[string]string
+	}{
+		{
+			name:   "zero_policy",
+			policy: Policy{},
+			want:   map[string]string{"url": "https://mail.example.com/inbox?id=42#msg", "title": "Inbox (3)", "query": "id=42", Column: "none"},
+		},
+		{
+			name:   "strip_query",
+			policy: Policy{Mode: ModeStripQuery},
+			want:   map[string]string{"url": "https://mail.example.com/inbox", "host": "mail.example.com", "path": "/inbox", "query": "", "fragment": "", Column: "strip_query"},
+		},
+		{
+			name:   "domain_only_without_titles",
+			policy: Policy{Mode: ModeDomainOnly, DropTitles: true},
+			want:   map[string]string{"url": "example.com", "title": "", "host": "", "port": "", "path": "", "domain": "example.com", Column: "domain_only,drop_titles"},
+		},
+		{
+			name:   "hmac_url",
+			policy: Policy{Mode: ModeHMACURL, Key: []byte("secret")},
+			want: map[string]string{"url": mac("secret", "https://mail.example.com/inbox?id=42#msg"), "scheme": "https",
+				"host": mac("secret", "mail.example.com"), "domain": mac("secret", "example.com"), "path": "", Column: "hmac_url"},
+		},
+		{
+			name:   "hmac_domain",
+			policy: Policy{Mode: ModeHMACDomain, Key: []byte("secret")},
+			want:   map[string]string{"url": mac("secret", "example.com"), "host": "", "domain": mac("secret", "example.com"), Column: "hmac_domain"},
+		},
+		{
+			name:   "titles_only",
+			policy: Policy{DropTitles: true},
+			want:   map[string]string{"url": "https://mail.example.com/inbox?id=42#msg", "title": "", Column: "drop_titles"},
+		},
+		{
+			name:   "allowlisted_domain",
+			policy: Policy{Mode: ModeDomainOnly, DropTitles: true, AllowDomains: []string{"Example.com"}},
+			want:   map[string]string{"url": "https://mail.example.com/inbox?id=42#msg", "title": "Inbox (3)", Column: Allowed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := tt.policy.Apply([]map[string]string{historyRow()})
+			if len(rows) != 1 {
+				t.Fatalf("Apply() returned %d rows, want 1", len(rows))
+			}
+			for column, want := range tt.want {
+				if got := rows[0][column]; got != want {
+					t.Errorf("%s = %q, want %q", column, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyRebuildsSummary(t *testing.T) {
+	row := historyRow()
+	row["summary"] = row["title"]
+
+	rows := Policy{Mode: ModeDomainOnly, DropTitles: true}.Apply([]map[string]string{row})
+	if got := rows[0]["summary"]; got != "example.com" {
+		t.Errorf("summary = %q, want the redacted URL", got)
+	}
+}
+
+func TestApplyTypedText(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		typed  string
+		want   string
+	}{
+		{"strip_query", Policy{Mode: ModeStripQuery}, "mail.exa", "mail.exa"},
+		{"strip_query_typed_query", Policy{Mode: ModeStripQuery}, "mail.example.com/?token=42#top", "mail.example.com/"},
+		{"domain_only", Policy{Mode: ModeDomainOnly}, "mail.exa", ""},
+		{"hmac_url", Policy{Mode: ModeHMACURL, Key: []byte("secret")}, "mail.exa", mac("secret", "mail.exa")},
+		{"hmac_domain", Policy{Mode: ModeHMACDomain, Key: []byte("secret")}, "mail.exa", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := historyRow()
+			row[TypedColumn] = tt.typed
+			rows := tt.policy.Apply([]map[string]string{row})
+			if got := rows[0][TypedColumn]; got != tt.want {
+				t.Errorf("%s = %q, want %q", TypedColumn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyAggregateRows(t *testing.T) {
+	session := map[string]string{
+		"entry_url":   "https://mail.example.com/inbox?id=42",
+		"exit_url":    "https://intranet.corp/wiki",
+		"top_domains": "example.com,corp",
+	}
+	policy := Policy{Mode: ModeHMACDomain, Key: []byte("secret"), AllowDomains: []string{"corp"}}
+
+	rows := policy.Apply([]map[string]string{session})
+	want := map[string]string{
+		"entry_url":   mac("secret", "example.com"),
+		"exit_url":    "https://intranet.corp/wiki",
+		"top_domains": mac("secret", "example.com") + ",corp",
+		Column:        ModeHMACDomain,
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("Apply() = %v, want %v", rows[0], want)
+	}
+
+	permission := map[string]string{"origin": "https://meet.example.com:443", "permission": "camera"}
+	rows = Policy{Mode: ModeDomainOnly}.Apply([]map[string]string{permission})
+	if rows[0]["origin"] != "example.com" || rows[0][Column] != ModeDomainOnly {
+		t.Errorf("Apply() = %v, want the origin reduced to its domain", rows[0])
+	}
+
+	favicon := map[string]string{"page_url": "https://mail.example.com/inbox?id=42", "icon_url": "https://cdn.example.net/icon.png"}
+	rows = Policy{Mode: ModeStripQuery}.Apply([]map[string]string{favicon})
+	if rows[0]["page_url"] != "https://mail.example.com/inbox" || rows[0]["icon_url"] != "https://cdn.example.net/icon.png" {
+		t.Errorf("Apply() = %v, want the page query stripped", rows[0])
+	}
+
+	session = map[string]string{"entry_url": "https://news.example.org/", "top_domains": "example.org,example.com,corp"}
+	rows = Policy{Mode: ModeStripQuery, DenyDomains: []string{"example.com"}}.Apply([]map[string]string{session})
+	if rows[0]["top_domains"] != "example.org,corp" {
+		t.Errorf("top_domains = %q, want the denied domain dropped", rows[0]["top_domains"])
+	}
+
+	deny := Policy{DenyDomains: []string{"example.com"}}
+	if !deny.Denied("https://x.example.com/") || deny.Denied("https://example.org/") {
+		t.Error("Expected Denied to match denied domains and their subdomains only")
+	}
+	if !deny.Denied("https://[*.]example.com:443") || !deny.Denied("[*.]example.com") || deny.Denied("[*.]example.org") {
+		t.Error("Expected Denied to match Chromium site patterns on denied domains")
+	}
+
+	pattern := map[string]string{"origin": "[*.]example.com", "permission": "popups"}
+	rows = Policy{Mode: ModeHMACDomain, Key: []byte("secret")}.Apply([]map[string]string{pattern})
+	if rows[0]["origin"] != mac("secret", "example.com") {
+		t.Errorf("Apply() = %v, want the pattern's domain hashed", rows[0])
+	}
+}
+
+func TestRedactURLs(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		value  string
+		want   string
+	}{
+		{
+			name:   "homepage",
+			policy: Policy{Mode: ModeStripQuery},
+			value:  "https://start.example.com/?user=alice",
+			want:   "https://start.example.com/",
+		},
+		{
+			name:   "startup_urls",
+			policy: Policy{Mode: ModeDomainOnly},
+			value:  `["https://mail.example.com/inbox","https://intranet.corp/wiki?page=1&x=2"]`,
+			want:   `["example.com","intranet.corp"]`,
+		},
+		{
+			name:   "proxy_rules",
+			policy: Policy{Mode: ModeHMACDomain, Key: []byte("secret")},
+			value:  "https=proxy.example.net:3128;ftp=ftp.example.org:21",
+			want:   "https=" + mac("secret", "example.net") + ";ftp=" + mac("secret", "example.org"),
+		},
+		{
+			name:   "proxy_host_kept_by_allowlist",
+			policy: Policy{Mode: ModeDomainOnly, AllowDomains: []string{"corp"}},
+			value:  "proxy.corp:8080",
+			want:   "proxy.corp:8080",
+		},
+		{
+			name:   "doh_templates",
+			policy: Policy{Mode: ModeDomainOnly},
+			value:  "https://dns.example.com/dns-query https://doh.example.net/q",
+			want:   "example.com example.net",
+		},
+		{
+			name:  "unredacted",
+			value: "https=proxy.example.net:3128",
+			want:  "https=proxy.example.net:3128",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.RedactURLs(tt.value); got != tt.want {
+				t.Errorf("RedactURLs(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDeniedAndUnrelatedRows(t *testing.T) {
+	policy := Policy{Mode: ModeStripQuery, DenyDomains: []string{"example.com"}}
+	status := map[string]string{"kind": "generation", "path": "/home/alice"}
+
+	rows := policy.Apply([]map[string]string{historyRow(), status})
+	if !reflect.DeepEqual(rows, []map[string]string{status}) {
+		t.Errorf("Apply() = %v, want only the row without a url", rows)
+	}
+}
+
+func TestApplyFormValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		wantValue string
+		wantMode  string
+	}{
+		{"keep", Policy{Mode: ModeHMACURL, Key: []byte("k")}, "E12345", ValuesKeep},
+		{"hash", Policy{Values: ValuesHash, Key: []byte("k")}, mac("k", "E12345"), ValuesHash},
+		{"suppress", Policy{Values: ValuesSuppress}, "", ValuesSuppress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := map[string]string{"field_name": "employee_id", ValueColumn: "E12345"}
+			rows := tt.policy.Apply([]map[string]string{row})
+			if len(rows) != 1 || row[ValueColumn] != tt.wantValue || row[Column] != tt.wantMode {
+				t.Errorf("Apply() = %v, want value %q redacted as %s", rows, tt.wantValue, tt.wantMode)
+			}
+			if row["field_name"] != "employee_id" {
+				t.Errorf("Expected the field name to be kept, got %q", row["field_name"])
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"zero", Policy{}, false},
+		{"strip_query", Policy{Mode: ModeStripQuery}, false},
+		{"hmac_with_key", Policy{Mode: ModeHMACDomain, Key: []byte("k")}, false},
+		{"hmac_without_key", Policy{Mode: ModeHMACURL}, true},
+		{"unknown_mode", Policy{Mode: "scramble"}, true},
+		{"hash_values_with_key", Policy{Values: ValuesHash, Key: []byte("k")}, false},
+		{"hash_values_without_key", Policy{Values: ValuesHash}, true},
+		{"unknown_values", Policy{Values: "scramble"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
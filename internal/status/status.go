@@ -0,0 +1,261 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error classes reported for profile errors
+const (
+	ClassPermissionDenied = "permission_denied"
+	ClassLocked           = "locked"
+	ClassCorrupt          = "corrupt"
+	ClassSchemaMismatch   = "schema_mismatch"
+	ClassMissing          = "missing"
+	ClassRefused          = "refused"
+	ClassUnknown          = "unknown"
+)
+
+// ErrRefused marks paths that were deliberately not read, such as profile
+// paths resolving outside their owner's home
+var ErrRefused = errors.New("refused unsafe path")
+
+// Generation describes the most recent generation of a table, either as a
+// whole or for the profiles of one browser
+type Generation struct {
+	// Table is the name of the generated table
+	Table string
+
+	// Browser is the browser type, empty for the table as a whole
+	Browser string
+
+	// Time is when the generation started
+	Time time.Time
+
+	// Duration is how long the generation took
+	Duration time.Duration
+
+	// Rows is the number of rows emitted
+	Rows int
+
+	// Error is the message of the error the generation failed with, if any
+	Error string
+}
+
+// ProfileError is the most recent error a table hit reading a browser
+// profile file
+type ProfileError struct {
+	// Table is the table that read the file, empty for profile discovery
+	Table string
+
+	// Browser is the browser type of the profile
+	Browser string
+
+	// Username and UID identify the owner of the profile, if known
+	Username string
+	UID      string
+
+	// Path is the file or directory that could not be read
+	Path string
+
+	// Class is one of the Class constants
+	Class string
+
+	// Message is the error message
+	Message string
+
+	// Time is when the error occurred
+	Time time.Time
+}
+
+// SchemaNotice reports a database whose schema version the extension was
+// not tested with. It is read with the best matching query rather than
+// failing.
+type SchemaNotice struct {
+	// Browser is the browser type of the profile
+	Browser string
+
+	// Username and UID identify the owner of the profile, if known
+	Username string
+	UID      string
+
+	// Path is the database file
+	Path string
+
+	// Version is the schema version the database records
+	Version int
+
+	// Message describes the version and the columns that were not found
+	Message string
+
+	// Time is when the schema was inspected
+	Time time.Time
+}
+
+// registry holds the reported generations, profile errors and schema notices
+type registry struct {
+	mu          sync.Mutex
+	generations map[[2]string]Generation
+	errors      map[[2]string]ProfileError
+	schemas     map[string]SchemaNotice
+}
+
+var reported = &registry{
+	generations: make(map[[2]string]Generation),
+	errors:      make(map[[2]string]ProfileError),
+	schemas:     make(map[string]SchemaNotice),
+}
+
+// RecordGeneration stores g as the latest generation of its table and browser
+func RecordGeneration(g Generation) {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+	reported.generations[[2]string{g.Table, g.Browser}] = g
+}
+
+// RecordProfileError stores err as the latest error table hit reading path,
+// which belongs to the given browser and user. Errors are kept per table so
+// one table reading a file does not clear another table's error.
+func RecordProfileError(table, browser, username, uid, path string, err error) {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+	reported.errors[[2]string{table, path}] = ProfileError{
+		Table:    table,
+		Browser:  browser,
+		Username: username,
+		UID:      uid,
+		Path:     path,
+		Class:    Classify(err),
+		Message:  err.Error(),
+		Time:     time.Now(),
+	}
+}
+
+// ClearProfileError forgets the errors table recorded for path, and for the
+// files within it, once path reads successfully
+func ClearProfileError(table, path string) {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+	within := strings.TrimSuffix(path, string(filepath.Separator)) + string(filepath.Separator)
+	for key := range reported.errors {
+		if key[0] == table && (key[1] == path || strings.HasPrefix(key[1], within)) {
+			delete(reported.errors, key)
+		}
+	}
+}
+
+// Generations returns the latest generations ordered by table and browser
+func Generations() []Generation {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+
+	generations := make([]Generation, 0, len(reported.generations))
+	for _, g := range reported.generations {
+		generations = append(generations, g)
+	}
+	sort.Slice(generations, func(i, j int) bool {
+		if generations[i].Table != generations[j].Table {
+			return generations[i].Table < generations[j].Table
+		}
+		return generations[i].Browser < generations[j].Browser
+	})
+	return generations
+}
+
+// ProfileErrors returns the outstanding profile errors ordered by path and
+// table
+func ProfileErrors() []ProfileError {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+
+	profileErrors := make([]ProfileError, 0, len(reported.errors))
+	for _, e := range reported.errors {
+		profileErrors = append(profileErrors, e)
+	}
+	sort.Slice(profileErrors, func(i, j int) bool {
+		if profileErrors[i].Path != profileErrors[j].Path {
+			return profileErrors[i].Path < profileErrors[j].Path
+		}
+		return profileErrors[i].Table < profileErrors[j].Table
+	})
+	return profileErrors
+}
+
+// RecordSchemaNotice stores n as the latest notice for its database
+func RecordSchemaNotice(n SchemaNotice) {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+	reported.schemas[n.Path] = n
+}
+
+// ClearSchemaNotice forgets the notice recorded for path once its schema
+// version is known
+func ClearSchemaNotice(path string) {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+	delete(reported.schemas, path)
+}
+
+// SchemaNotices returns the outstanding schema notices ordered by path
+func SchemaNotices() []SchemaNotice {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+
+	notices := make([]SchemaNotice, 0, len(reported.schemas))
+	for _, n := range reported.schemas {
+		notices = append(notices, n)
+	}
+	sort.Slice(notices, func(i, j int) bool {
+		return notices[i].Path < notices[j].Path
+	})
+	return notices
+}
+
+// reset forgets everything reported
+func reset() {
+	reported.mu.Lock()
+	defer reported.mu.Unlock()
+	reported.generations = make(map[[2]string]Generation)
+	reported.errors = make(map[[2]string]ProfileError)
+	reported.schemas = make(map[string]SchemaNotice)
+}
+
+// Classify maps an error reading a profile to one of the Class constants
+func Classify(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrRefused):
+		return ClassRefused
+	case errors.Is(err, fs.ErrPermission):
+		return ClassPermissionDenied
+	case errors.Is(err, fs.ErrNotExist):
+		return ClassMissing
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ClassCorrupt
+	}
+
+	// SQLite errors are matched by message so the classification does not
+	// depend on the cgo-only sqlite3 error types
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "no such table"), strings.Contains(message, "no such column"):
+		return ClassSchemaMismatch
+	case strings.Contains(message, "database is locked"), strings.Contains(message, "database table is locked"):
+		return ClassLocked
+	case strings.Contains(message, "malformed"), strings.Contains(message, "not a database"):
+		return ClassCorrupt
+	case strings.Contains(message, "permission denied"), strings.Contains(message, "authorization denied"):
+		return ClassPermissionDenied
+	case strings.Contains(message, "unable to open database file"):
+		return ClassMissing
+	}
+
+	return ClassUnknown
+}
@@ -0,0 +1,114 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	_, missingErr := os.Open(filepath.Join(t.TempDir(), "History"))
+	var syntaxErr error = &json.SyntaxError{}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"missing_file", missingErr, ClassMissing},
+		{"permission", fmt.Errorf("reading: %w", os.ErrPermission), ClassPermissionDenied},
+		{"sqlite_corrupt", errors.New("database disk image is malformed"), ClassCorrupt},
+		{"sqlite_not_a_database", errors.New("file is not a database"), ClassCorrupt},
+		{"json_syntax", fmt.Errorf("preferences: %w", syntaxErr), ClassCorrupt},
+		{"schema", errors.New("no such table: visits"), ClassSchemaMismatch},
+		{"locked_message", errors.New("database is locked"), ClassLocked},
+		{"other", errors.New("something else"), ClassUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	defer reset()
+	reset()
+
+	t.Run("generations_keep_latest_per_table_and_browser", func(t *testing.T) {
+		RecordGeneration(Generation{Table: "browser_history", Rows: 1})
+		RecordGeneration(Generation{Table: "browser_history", Browser: "chrome", Rows: 2})
+		RecordGeneration(Generation{Table: "browser_history", Rows: 3})
+
+		generations := Generations()
+		if len(generations) != 2 {
+			t.Fatalf("Expected 2 generations, got %d", len(generations))
+		}
+		if generations[0].Browser != "" || generations[0].Rows != 3 {
+			t.Errorf("Expected latest table generation first, got %+v", generations[0])
+		}
+	})
+
+	t.Run("profile_errors_clear_on_success", func(t *testing.T) {
+		RecordProfileError("browser_history", "chrome", "alice", "1000", "/home/alice/.config/google-chrome/Default", os.ErrPermission)
+		RecordProfileError("", "firefox", "bob", "1001", "/home/bob/.mozilla/firefox/profiles.ini", errors.New("bad ini"))
+
+		profileErrors := ProfileErrors()
+		if len(profileErrors) != 2 {
+			t.Fatalf("Expected 2 profile errors, got %d", len(profileErrors))
+		}
+		if profileErrors[0].Class != ClassPermissionDenied {
+			t.Errorf("Expected %s, got %s", ClassPermissionDenied, profileErrors[0].Class)
+		}
+
+		ClearProfileError("browser_history", "/home/alice/.config/google-chrome/Default")
+		if profileErrors := ProfileErrors(); len(profileErrors) != 1 || profileErrors[0].Browser != "firefox" {
+			t.Errorf("Expected only the firefox error to remain, got %+v", profileErrors)
+		}
+		ClearProfileError("", "/home/bob/.mozilla/firefox/profiles.ini")
+	})
+
+	t.Run("profile_errors_are_kept_per_table", func(t *testing.T) {
+		const profile = "/home/alice/.config/google-chrome/Default"
+		RecordProfileError("browser_autofill", "chrome", "alice", "1000", profile+"/Web Data", errors.New("database is locked"))
+		RecordProfileError("browser_favicon_pages", "chrome", "alice", "1000", profile+"/Favicons", os.ErrPermission)
+
+		// A successful read of the profile by another table leaves both errors
+		ClearProfileError("browser_history", profile)
+		if profileErrors := ProfileErrors(); len(profileErrors) != 2 {
+			t.Fatalf("Expected 2 profile errors, got %+v", profileErrors)
+		}
+
+		// A successful read by the table clears its errors for files in the profile
+		ClearProfileError("browser_autofill", profile)
+		profileErrors := ProfileErrors()
+		if len(profileErrors) != 1 || profileErrors[0].Table != "browser_favicon_pages" {
+			t.Errorf("Expected only the favicon error to remain, got %+v", profileErrors)
+		}
+		ClearProfileError("browser_favicon_pages", profile+"-other")
+		if profileErrors := ProfileErrors(); len(profileErrors) != 1 {
+			t.Errorf("Expected a sibling directory to leave the error, got %+v", profileErrors)
+		}
+	})
+
+	t.Run("schema_notices_clear_once_known", func(t *testing.T) {
+		RecordSchemaNotice(SchemaNotice{Browser: "chrome", Path: "/home/alice/.config/google-chrome/Default/History", Version: 99})
+		RecordSchemaNotice(SchemaNotice{Browser: "chrome", Path: "/home/alice/.config/google-chrome/Default/History", Version: 100})
+
+		notices := SchemaNotices()
+		if len(notices) != 1 || notices[0].Version != 100 {
+			t.Fatalf("Expected the latest notice only, got %+v", notices)
+		}
+
+		ClearSchemaNotice("/home/alice/.config/google-chrome/Default/History")
+		if notices := SchemaNotices(); len(notices) != 0 {
+			t.Errorf("Expected no notices, got %+v", notices)
+		}
+	})
+}
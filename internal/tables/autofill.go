@@ -0,0 +1,102 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// BrowserAutofillTablePlugin creates a table plugin listing the values typed
+// into forms and saved by each profile's autofill, with the metadata of saved
+// cards and, if enabled, saved addresses. Values follow the configured form
+// value redaction. Constraints on uid or username limit enumeration to the
+// matching users.
+func BrowserAutofillTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("kind"),
+		table.TextColumn("field_name"),
+		table.TextColumn(redaction.ValueColumn),
+		table.BigIntColumn("created_time"),
+		table.TextColumn("created_datetime"),
+		table.TextColumn("created_display_time"),
+		table.BigIntColumn("last_used_time"),
+		table.TextColumn("last_used_datetime"),
+		table.TextColumn("last_used_display_time"),
+		table.IntegerColumn("times_used"),
+		table.TextColumn("card_network"),
+		table.TextColumn("card_expiration"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectAutofill(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_autofill", columns, gen, alwaysStateless)
+}
+
+// collectAutofill returns the saved form entries of every discovered profile
+// of the selected users
+func collectAutofill(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	opts := common.AutofillOptions{Addresses: currentSettings().AutofillAddresses}
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindAutofill == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			entries, err := source.FindAutofill(profile, opts)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			for _, entry := range entries {
+				results = append(results, autofillRow(profile, entry))
+			}
+			stats.observe(profile.BrowserType, started, len(entries))
+		}
+	}
+
+	return results
+}
+
+// autofillRow converts a saved form entry into a table row
+func autofillRow(profile common.Profile, entry common.AutofillEntry) map[string]string {
+	row := map[string]string{
+		"kind":                entry.Kind,
+		"field_name":          entry.FieldName,
+		redaction.ValueColumn: entry.Value,
+		"times_used":          strconv.Itoa(entry.TimesUsed),
+		"card_network":        entry.CardNetwork,
+		"card_expiration":     entry.CardExpiration,
+		"username":            profile.Username,
+		"uid":                 profile.UID,
+		"browser_type":        profile.BrowserType,
+		"profile":             profile.ID,
+	}
+	setTime(row, "created_time", "created_datetime", "created_display_time", entry.Created)
+	setTime(row, "last_used_time", "last_used_datetime", "last_used_display_time", entry.LastUsed)
+	return row
+}
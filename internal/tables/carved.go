@@ -0,0 +1,97 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// BrowserHistoryCarvedTablePlugin creates a table plugin listing deleted
+// history records recovered from the free space of each profile's history
+// database. Carving reads every page of the database, so the table is empty
+// unless carving is enabled in the settings. Constraints on uid or username
+// limit enumeration to the matching users.
+func BrowserHistoryCarvedTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("url"),
+		table.TextColumn("title"),
+		table.IntegerColumn("visit_count"),
+		table.BigIntColumn("last_visit_time"),
+		table.TextColumn("last_visit_datetime"),
+		table.TextColumn("last_visit_display_time"),
+		table.DoubleColumn("confidence"),
+		table.TextColumn("region"),
+		table.IntegerColumn("page"),
+		table.BigIntColumn("file_offset"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		if !currentSettings().Carving {
+			return nil, nil
+		}
+		return collectCarvedHistory(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_history_carved", columns, gen, alwaysStateless)
+}
+
+// collectCarvedHistory returns the deleted history records recovered from
+// every discovered profile of the selected users
+func collectCarvedHistory(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.CarveHistory == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			entries, err := source.CarveHistory(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			for _, entry := range entries {
+				row := map[string]string{
+					"url":          entry.URL,
+					"title":        entry.Title,
+					"visit_count":  strconv.Itoa(entry.VisitCount),
+					"confidence":   strconv.FormatFloat(entry.Confidence, 'f', 2, 64),
+					"region":       entry.Region,
+					"page":         strconv.Itoa(entry.Page),
+					"file_offset":  strconv.FormatInt(entry.Offset, 10),
+					"username":     profile.Username,
+					"uid":          profile.UID,
+					"browser_type": profile.BrowserType,
+					"profile":      profile.ID,
+				}
+				setTime(row, "last_visit_time", "last_visit_datetime", "last_visit_display_time", entry.LastVisit)
+				results = append(results, row)
+			}
+			stats.observe(profile.BrowserType, started, len(entries))
+		}
+	}
+
+	return results
+}
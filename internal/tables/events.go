@@ -0,0 +1,66 @@
+package tables
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/events"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// BrowserHistoryEventsTablePlugin creates a table plugin exposing the visits
+// buffered by an events collector. The time column is when the visit was
+// seen, matching osquery's evented tables. Constraints on uid or username
+// select the events of matching users.
+func BrowserHistoryEventsTablePlugin(buffer *events.Buffer) *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.BigIntColumn("time"),
+		table.TextColumn("datetime"),
+		table.TextColumn("display_time"),
+		table.BigIntColumn("visit_time"),
+		table.TextColumn("visit_datetime"),
+		table.TextColumn("visit_display_time"),
+		table.TextColumn("title"),
+		table.TextColumn("url"),
+		table.TextColumn("profile"),
+		table.TextColumn("browser_type"),
+		table.BigIntColumn("visit_id"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		var results []map[string]string
+		if buffer == nil {
+			return results, nil
+		}
+
+		selection := userSelectionFromContext(queryContext)
+		for _, event := range buffer.Events() {
+			entry := event.Entry
+			if !selection.Matches(common.UserInfo{Username: entry.Username, UID: entry.UID}) {
+				continue
+			}
+			row := map[string]string{
+				"title":        entry.Title,
+				"url":          entry.URL,
+				"profile":      entry.ProfileID,
+				"browser_type": entry.BrowserType,
+				"visit_id":     strconv.FormatInt(entry.VisitID, 10),
+				"username":     entry.Username,
+				"uid":          entry.UID,
+			}
+			setTime(row, "time", "datetime", "display_time", event.Time)
+			setTime(row, "visit_time", "visit_datetime", "visit_display_time", entry.VisitTime)
+			results = append(results, row)
+		}
+
+		return results, nil
+	}
+
+	return newPlugin("browser_history_events", columns, gen, nil)
+}
@@ -0,0 +1,95 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// BrowserFaviconPagesTablePlugin creates a table plugin listing the pages
+// recorded in each profile's favicon database. Favicons survive clearing
+// history, so in_history = 0 surfaces pages whose visits were likely
+// deleted. Constraints on uid or username limit enumeration to the matching
+// users.
+func BrowserFaviconPagesTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("page_url"),
+		table.TextColumn("icon_url"),
+		table.BigIntColumn("last_updated_time"),
+		table.TextColumn("last_updated_datetime"),
+		table.TextColumn("last_updated_display_time"),
+		table.IntegerColumn("in_history"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectFaviconPages(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_favicon_pages", columns, gen, alwaysStateless)
+}
+
+// collectFaviconPages returns the favicon pages of every discovered profile
+// of the selected users. Pages on denied domains are left out.
+func collectFaviconPages(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	policy := currentSettings().Redaction
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindFaviconPages == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			pages, err := source.FindFaviconPages(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			rows := 0
+			for _, page := range pages {
+				if policy.Denied(page.PageURL) {
+					continue
+				}
+				inHistory := "0"
+				if page.InHistory {
+					inHistory = "1"
+				}
+				row := map[string]string{
+					"page_url":     page.PageURL,
+					"icon_url":     page.IconURL,
+					"in_history":   inHistory,
+					"username":     profile.Username,
+					"uid":          profile.UID,
+					"browser_type": profile.BrowserType,
+					"profile":      profile.ID,
+				}
+				setTime(row, "last_updated_time", "last_updated_datetime", "last_updated_display_time", page.LastUpdated)
+				results = append(results, row)
+				rows++
+			}
+			stats.observe(profile.BrowserType, started, rows)
+		}
+	}
+
+	return results
+}
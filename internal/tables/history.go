@@ -0,0 +1,281 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/chromium"
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/browsers/firefox"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// defaultCursorName is the cursor used by browser_history_new when the query
+// does not name one with a since_cursor constraint
+const defaultCursorName = "default"
+
+// historySources lists the browser engines history is collected from
+var historySources = []common.HistorySource{
+	chromium.HistorySource,
+	firefox.HistorySource,
+}
+
+// HistorySources returns the browser engines history is collected from,
+// limited to the profiles of enabled browsers
+func HistorySources() []common.HistorySource {
+	sources := make([]common.HistorySource, len(historySources))
+	for i, source := range historySources {
+		findProfiles := source.FindProfiles
+		source.FindProfiles = func(selection common.UserSelection) ([]common.Profile, error) {
+			return enabledProfiles(findProfiles(selection))
+		}
+		sources[i] = source
+	}
+	return sources
+}
+
+// historyColumns returns the columns shared by the history tables
+func historyColumns() []table.ColumnDefinition {
+	return []table.ColumnDefinition{
+		table.BigIntColumn("time"),
+		table.TextColumn("datetime"),
+		table.TextColumn("display_time"),
+		table.TextColumn("title"),
+		table.IntegerColumn("visit_count"),
+		table.TextColumn("url"),
+		table.TextColumn("scheme"),
+		table.TextColumn("host"),
+		table.IntegerColumn("port"),
+		table.TextColumn("domain"),
+		table.TextColumn("path"),
+		table.TextColumn("query"),
+		table.TextColumn("fragment"),
+		table.TextColumn("url_type"),
+		table.TextColumn("profile"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("browser_variant"),
+		table.BigIntColumn("visit_id"),
+		table.BigIntColumn("visit_duration_ms"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("since_cursor"),
+		table.TextColumn(redaction.Column),
+	}
+}
+
+// BrowserHistoryTablePlugin creates a table plugin for browser history.
+// A since_cursor = 'name' constraint limits the result to visits added since
+// the previous query using the same cursor name. Constraints on uid or
+// username limit enumeration to the matching users, and constraints on
+// scheme, host or domain are pushed down into each browser's database query.
+func BrowserHistoryTablePlugin(cursors *common.CursorStore) *table.Plugin {
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		selection := userSelectionFromContext(queryContext)
+		name, ok := cursorNameFromContext(queryContext)
+		if !ok {
+			return collectHistory(ctx, selection, urlFilterFromContext(queryContext)), nil
+		}
+		return collectHistorySince(ctx, cursors, name, selection)
+	}
+
+	return newPlugin("browser_history", historyColumns(), gen, withoutCursor)
+}
+
+// BrowserHistoryNewTablePlugin creates a table plugin that only returns visits
+// added since its previous query. The cursor is named by a since_cursor
+// constraint and defaults to "default".
+func BrowserHistoryNewTablePlugin(cursors *common.CursorStore) *table.Plugin {
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		name, ok := cursorNameFromContext(queryContext)
+		if !ok {
+			name = defaultCursorName
+		}
+		return collectHistorySince(ctx, cursors, name, userSelectionFromContext(queryContext))
+	}
+
+	return newPlugin("browser_history_new", historyColumns(), gen, nil)
+}
+
+// cursorNameFromContext returns the cursor named by a since_cursor = constraint
+func cursorNameFromContext(queryContext table.QueryContext) (string, bool) {
+	constraints, ok := queryContext.Constraints["since_cursor"]
+	if !ok {
+		return "", false
+	}
+
+	for _, constraint := range constraints.Constraints {
+		if constraint.Operator == table.OperatorEquals {
+			return constraint.Expression, true
+		}
+	}
+
+	return "", false
+}
+
+// userSelectionFromContext returns the users selected by uid = and username =
+// constraints, matching how osquery's per-user tables prune enumeration
+func userSelectionFromContext(queryContext table.QueryContext) common.UserSelection {
+	return common.UserSelection{
+		Usernames: equalsExpressions(queryContext, "username"),
+		UIDs:      equalsExpressions(queryContext, "uid"),
+	}
+}
+
+// equalsExpressions returns the values a column is constrained to be equal to
+func equalsExpressions(queryContext table.QueryContext, column string) []string {
+	var values []string
+	for _, constraint := range queryContext.Constraints[column].Constraints {
+		if constraint.Operator == table.OperatorEquals {
+			values = append(values, constraint.Expression)
+		}
+	}
+	return values
+}
+
+// urlFilterFromContext returns the URLs selected by scheme =, host = and
+// domain = constraints
+func urlFilterFromContext(queryContext table.QueryContext) common.URLFilter {
+	lower := func(values []string) []string {
+		for i, value := range values {
+			values[i] = strings.ToLower(value)
+		}
+		return values
+	}
+	return common.URLFilter{
+		Schemes: lower(equalsExpressions(queryContext, "scheme")),
+		Hosts:   lower(equalsExpressions(queryContext, "host")),
+		Domains: lower(equalsExpressions(queryContext, "domain")),
+	}
+}
+
+// withoutCursor reports whether a query leaves history cursors untouched
+func withoutCursor(queryContext table.QueryContext) bool {
+	_, ok := cursorNameFromContext(queryContext)
+	return !ok
+}
+
+// collectHistory returns the history of every discovered profile of the
+// selected users, limited to URLs matching the filter
+func collectHistory(ctx context.Context, selection common.UserSelection, filter common.URLFilter) []map[string]string {
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			historyEntries, err := source.FindHistoryMatching(profile, common.Cursor{}, filter)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			rows := 0
+			for _, entry := range historyEntries {
+				parts := common.ParseURL(entry.URL)
+				if !filter.Matches(parts) {
+					continue
+				}
+				results = append(results, historyRow(entry, parts, ""))
+				rows++
+			}
+			stats.observe(profile.BrowserType, started, rows)
+		}
+	}
+
+	return results
+}
+
+// collectHistorySince returns the visits added to every discovered profile of
+// the selected users since the previous query using the named cursor, and
+// advances the cursor for those profiles. URL constraints are not pushed down
+// so the cursor never skips visits a later query would select.
+func collectHistorySince(ctx context.Context, cursors *common.CursorStore, name string, selection common.UserSelection) ([]map[string]string, error) {
+	if cursors == nil {
+		return nil, fmt.Errorf("since_cursor requires a state directory")
+	}
+
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	err := cursors.Update(name, func(marks map[string]common.Cursor) (map[string]common.Cursor, error) {
+		for _, source := range HistorySources() {
+			profiles, err := source.FindProfiles(selection)
+			if err != nil {
+				slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+				continue
+			}
+
+			for _, profile := range profiles {
+				started := time.Now()
+				key := cursorKey(profile)
+				historyEntries, err := source.FindHistorySince(profile, marks[key])
+				if err != nil {
+					stats.reportProfileError(profile, err)
+					stats.observe(profile.BrowserType, started, 0)
+					continue
+				}
+				stats.clearProfileError(profile)
+
+				marks[key] = marks[key].Advance(historyEntries)
+				for _, entry := range historyEntries {
+					results = append(results, historyRow(entry, common.ParseURL(entry.URL), name))
+				}
+				stats.observe(profile.BrowserType, started, len(historyEntries))
+			}
+		}
+		return marks, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// cursorKey identifies the history database of a profile within a cursor
+func cursorKey(profile common.Profile) string {
+	return profile.BrowserType + ":" + profile.Path
+}
+
+// historyRow converts a history entry and its parsed URL into a table row
+func historyRow(entry common.HistoryEntry, parts common.URLParts, cursorName string) map[string]string {
+	row := map[string]string{
+		"url":             entry.URL,
+		"scheme":          parts.Scheme,
+		"host":            parts.Host,
+		"port":            parts.Port,
+		"domain":          parts.Domain,
+		"path":            parts.Path,
+		"query":           parts.Query,
+		"fragment":        parts.Fragment,
+		"url_type":        parts.Type,
+		"title":           entry.Title,
+		"visit_count":     strconv.Itoa(entry.VisitCount),
+		"profile":         entry.ProfileID,
+		"browser_type":    entry.BrowserType,
+		"browser_variant": entry.BrowserVariant,
+		"visit_id":        strconv.FormatInt(entry.VisitID, 10),
+		"username":        entry.Username,
+		"uid":             entry.UID,
+		"since_cursor":    cursorName,
+	}
+	if entry.VisitDuration > 0 {
+		row["visit_duration_ms"] = strconv.FormatInt(entry.VisitDuration.Milliseconds(), 10)
+	}
+	setTime(row, "time", "datetime", "display_time", entry.VisitTime)
+	return row
+}
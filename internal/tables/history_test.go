@@ -0,0 +1,78 @@
+package tables
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestUserSelectionFromContext(t *testing.T) {
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"uid": {Constraints: []table.Constraint{
+				{Operator: table.OperatorEquals, Expression: "1000"},
+				{Operator: table.OperatorGreaterThan, Expression: "500"},
+			}},
+			"username": {Constraints: []table.Constraint{
+				{Operator: table.OperatorEquals, Expression: "alice"},
+			}},
+		},
+	}
+
+	want := common.UserSelection{Usernames: []string{"alice"}, UIDs: []string{"1000"}}
+	if got := userSelectionFromContext(queryContext); !reflect.DeepEqual(got, want) {
+		t.Errorf("userSelectionFromContext() = %+v, want %+v", got, want)
+	}
+
+	if got := userSelectionFromContext(table.QueryContext{}); !got.IsZero() {
+		t.Errorf("Expected an unconstrained query to select every user, got %+v", got)
+	}
+}
+
+func TestURLFilterFromContext(t *testing.T) {
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"domain": {Constraints: []table.Constraint{
+				{Operator: table.OperatorEquals, Expression: "Example.COM"},
+			}},
+			"scheme": {Constraints: []table.Constraint{
+				{Operator: table.OperatorLike, Expression: "http%"},
+			}},
+		},
+	}
+
+	want := common.URLFilter{Domains: []string{"example.com"}}
+	if got := urlFilterFromContext(queryContext); !reflect.DeepEqual(got, want) {
+		t.Errorf("urlFilterFromContext() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHistoryRow(t *testing.T) {
+	entry := common.HistoryEntry{
+		URL:           "https://example.com/",
+		VisitID:       7,
+		VisitCount:    42,
+		VisitDuration: 1500 * time.Millisecond,
+	}
+	row := historyRow(entry, common.ParseURL(entry.URL), "")
+	for column, want := range map[string]string{"visit_count": "42", "visit_id": "7", "visit_duration_ms": "1500"} {
+		if row[column] != want {
+			t.Errorf("historyRow()[%q] = %q, want %q", column, row[column], want)
+		}
+	}
+
+	// osquery drops keys the table does not declare
+	declared := make(map[string]bool)
+	for _, column := range historyColumns() {
+		declared[column.Name] = true
+	}
+	for column := range row {
+		if !declared[column] {
+			t.Errorf("historyRow() sets %q, which historyColumns() does not declare", column)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/ioc"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// Artifact types reported by browser_ioc_matches
+const (
+	artifactHistory   = "history"
+	artifactDownload  = "download"
+	artifactBookmark  = "bookmark"
+	artifactExtension = "extension"
+)
+
+// indicators loads the indicator lists of the configured directory
+var indicators = &ioc.Loader{}
+
+// BrowserIOCMatchesTablePlugin creates a table plugin matching the history,
+// downloads, bookmarks and extensions of every discovered profile against
+// the indicator lists in the configured IOC directory. Each row pairs a
+// matched artifact with the indicator and list that matched it. Constraints
+// on uid or username limit enumeration to the matching users.
+func BrowserIOCMatchesTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.BigIntColumn("time"),
+		table.TextColumn("datetime"),
+		table.TextColumn("display_time"),
+		table.TextColumn("artifact_type"),
+		table.TextColumn("url"),
+		table.TextColumn("title"),
+		table.TextColumn("target_path"),
+		table.TextColumn("sha256"),
+		table.TextColumn("indicator"),
+		table.TextColumn("indicator_type"),
+		table.TextColumn("source_list"),
+		table.TextColumn("profile"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		dir := currentSettings().IOCDir
+		if dir == "" {
+			return nil, fmt.Errorf("browser_ioc_matches requires an ioc_dir")
+		}
+		matcher, err := indicators.Matcher(dir)
+		if err != nil {
+			return nil, fmt.Errorf("loading indicator lists: %w", err)
+		}
+		if matcher.Len() == 0 {
+			return nil, nil
+		}
+		return matchArtifacts(ctx, matcher, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_ioc_matches", columns, gen, alwaysStateless)
+}
+
+// iocArtifact is a timestamped artifact matched against the indicators
+type iocArtifact struct {
+	artifactType string
+	time         time.Time
+	url          string
+	title        string
+	targetPath   string
+	sha256       string
+}
+
+// matches returns the indicators matching the artifact, each once
+func (a iocArtifact) matches(matcher *ioc.Matcher) []ioc.Indicator {
+	var matched []ioc.Indicator
+	if a.url != "" {
+		matched = append(matched, matcher.MatchURL(a.url)...)
+	}
+	if a.targetPath != "" {
+		matched = append(matched, matcher.MatchText(a.targetPath)...)
+	}
+	if a.sha256 != "" {
+		matched = append(matched, matcher.MatchSHA256(a.sha256)...)
+	}
+
+	seen := make(map[ioc.Indicator]bool, len(matched))
+	unique := matched[:0]
+	for _, indicator := range matched {
+		if !seen[indicator] {
+			seen[indicator] = true
+			unique = append(unique, indicator)
+		}
+	}
+	return unique
+}
+
+// profileArtifacts reads the artifacts of a profile that indicators are
+// matched against. Errors are reported per reader, so a failing database
+// does not hide the other artifacts; ok is false if any reader failed.
+func profileArtifacts(stats *generationStats, source common.HistorySource, profile common.Profile) (artifacts []iocArtifact, ok bool) {
+	ok = true
+	failed := func(err error) bool {
+		if err != nil {
+			stats.reportProfileError(profile, err)
+			ok = false
+		}
+		return err != nil
+	}
+
+	if entries, err := source.FindHistorySince(profile, common.Cursor{}); !failed(err) {
+		for _, entry := range entries {
+			artifacts = append(artifacts, iocArtifact{artifactType: artifactHistory, time: entry.VisitTime, url: entry.URL, title: entry.Title})
+		}
+	}
+	if source.FindDownloads != nil {
+		if downloads, err := source.FindDownloads(profile); !failed(err) {
+			for _, d := range downloads {
+				artifacts = append(artifacts, iocArtifact{
+					artifactType: artifactDownload,
+					time:         d.StartTime,
+					url:          d.URL,
+					title:        filepath.Base(d.TargetPath),
+					targetPath:   d.TargetPath,
+					sha256:       d.SHA256,
+				})
+			}
+		}
+	}
+	if source.FindBookmarks != nil {
+		if bookmarks, err := source.FindBookmarks(profile); !failed(err) {
+			for _, b := range bookmarks {
+				artifacts = append(artifacts, iocArtifact{artifactType: artifactBookmark, time: b.Added, url: b.URL, title: b.Title})
+			}
+		}
+	}
+	if source.FindExtensions != nil {
+		if extensions, err := source.FindExtensions(profile); !failed(err) {
+			for _, e := range extensions {
+				artifacts = append(artifacts, iocArtifact{
+					artifactType: artifactExtension,
+					time:         e.Installed,
+					url:          e.URL,
+					title:        e.Name,
+					targetPath:   e.Path,
+				})
+			}
+		}
+	}
+	return artifacts, ok
+}
+
+// matchArtifacts returns a row for every indicator matching an artifact of
+// the selected users
+func matchArtifacts(ctx context.Context, matcher *ioc.Matcher, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			artifacts, ok := profileArtifacts(stats, source, profile)
+			if ok {
+				stats.clearProfileError(profile)
+			}
+
+			rows := 0
+			for _, artifact := range artifacts {
+				for _, indicator := range artifact.matches(matcher) {
+					row := map[string]string{
+						"artifact_type":  artifact.artifactType,
+						"url":            artifact.url,
+						"title":          artifact.title,
+						"target_path":    artifact.targetPath,
+						"sha256":         artifact.sha256,
+						"indicator":      indicator.Value,
+						"indicator_type": indicator.Type,
+						"source_list":    indicator.Source,
+						"profile":        profile.ID,
+						"browser_type":   profile.BrowserType,
+						"username":       profile.Username,
+						"uid":            profile.UID,
+					}
+					setTime(row, "time", "datetime", "display_time", artifact.time)
+					results = append(results, row)
+					rows++
+				}
+			}
+			stats.observe(profile.BrowserType, started, rows)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,66 @@
+package tables
+
+import (
+	"strings"
+	"testing"
+
+	"osquery-extension-browsers/internal/ioc"
+)
+
+func TestIOCArtifactMatches(t *testing.T) {
+	indicators, err := ioc.Parse(strings.NewReader(`example.org
+regex:(?i)\.sh$
+e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+`), "list.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher, err := ioc.NewMatcher(indicators)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		artifact iocArtifact
+		want     []string
+	}{
+		{
+			name: "download_by_url_path_and_hash",
+			artifact: iocArtifact{
+				artifactType: artifactDownload,
+				url:          "https://cdn.example.org/tool.sh",
+				targetPath:   "/home/alice/Downloads/tool.sh",
+				sha256:       "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			},
+			want: []string{ioc.TypeDomain, ioc.TypeRegex, ioc.TypeSHA256},
+		},
+		{
+			name:     "download_by_path_only",
+			artifact: iocArtifact{artifactType: artifactDownload, url: "https://example.net/a", targetPath: "/tmp/run.SH"},
+			want:     []string{ioc.TypeRegex},
+		},
+		{
+			name:     "extension_by_update_url",
+			artifact: iocArtifact{artifactType: artifactExtension, url: "https://ext.example.org/update", targetPath: "/ext/aaaa"},
+			want:     []string{ioc.TypeDomain},
+		},
+		{
+			name:     "no_match",
+			artifact: iocArtifact{artifactType: artifactBookmark, url: "https://example.com/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := tt.artifact.matches(matcher)
+			var got []string
+			for _, indicator := range matched {
+				got = append(got, indicator.Type)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("matches() types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
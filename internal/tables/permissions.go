@@ -0,0 +1,94 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// BrowserSitePermissionsTablePlugin creates a table plugin listing the
+// permissions, such as camera, microphone and notifications, that users
+// granted or denied to sites in each profile. Constraints on uid or username
+// limit enumeration to the matching users.
+func BrowserSitePermissionsTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("origin"),
+		table.TextColumn("permission"),
+		table.TextColumn("setting"),
+		table.BigIntColumn("last_modified_time"),
+		table.TextColumn("last_modified_datetime"),
+		table.TextColumn("last_modified_display_time"),
+		table.BigIntColumn("expiration_time"),
+		table.TextColumn("expiration_datetime"),
+		table.TextColumn("expiration_display_time"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectPermissions(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_site_permissions", columns, gen, alwaysStateless)
+}
+
+// collectPermissions returns the site permissions of every discovered
+// profile of the selected users. Sites on denied domains are left out.
+func collectPermissions(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	policy := currentSettings().Redaction
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindPermissions == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			permissions, err := source.FindPermissions(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			rows := 0
+			for _, permission := range permissions {
+				if policy.Denied(permission.Origin) {
+					continue
+				}
+				row := map[string]string{
+					"origin":       permission.Origin,
+					"permission":   permission.Permission,
+					"setting":      permission.Setting,
+					"username":     profile.Username,
+					"uid":          profile.UID,
+					"browser_type": profile.BrowserType,
+					"profile":      profile.ID,
+				}
+				setTime(row, "last_modified_time", "last_modified_datetime", "last_modified_display_time", permission.LastModified)
+				setTime(row, "expiration_time", "expiration_datetime", "expiration_display_time", permission.Expiration)
+				results = append(results, row)
+				rows++
+			}
+			stats.observe(profile.BrowserType, started, rows)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,37 @@
+package tables
+
+import (
+	"context"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+func TestCollectPermissionsMatchesSitePatterns(t *testing.T) {
+	defer func(saved []common.HistorySource) { historySources = saved }(historySources)
+	defer Configure(Settings{})
+	Configure(Settings{Redaction: redaction.Policy{Mode: redaction.ModeDomainOnly, DenyDomains: []string{"example.org"}}})
+
+	historySources = []common.HistorySource{{
+		Engine: "Test",
+		FindProfiles: func(common.UserSelection) ([]common.Profile, error) {
+			return []common.Profile{{ID: "Default", BrowserType: "chrome"}}, nil
+		},
+		FindPermissions: func(common.Profile) ([]common.SitePermission, error) {
+			return []common.SitePermission{
+				{Origin: "https://[*.]example.com:443", Permission: common.PermissionCamera, Setting: common.SettingAllow},
+				{Origin: "[*.]example.org", Permission: "popups", Setting: common.SettingBlock},
+				{Origin: "https://[*.]tracker.example.org:443", Permission: common.PermissionNotifications, Setting: common.SettingBlock},
+			}, nil
+		},
+	}}
+
+	rows := currentSettings().Redaction.Apply(collectPermissions(context.Background(), common.UserSelection{}))
+	if len(rows) != 1 {
+		t.Fatalf("collectPermissions() = %v, want only the site outside the denied domain", rows)
+	}
+	if rows[0]["origin"] != "example.com" || rows[0][redaction.Column] != redaction.ModeDomainOnly {
+		t.Errorf("origin = %q (%q), want example.com (%s)", rows[0]["origin"], rows[0][redaction.Column], redaction.ModeDomainOnly)
+	}
+}
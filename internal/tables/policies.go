@@ -0,0 +1,72 @@
+package tables
+
+import (
+	"context"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/chromium"
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/browsers/firefox"
+	"osquery-extension-browsers/internal/status"
+)
+
+// policySources returns the enterprise policy sources of every engine
+var policySources = func() []common.PolicySource {
+	return append(chromium.PolicySources(), firefox.PolicySources()...)
+}
+
+// BrowserPoliciesTablePlugin creates a table plugin listing the enterprise
+// policies configured for each browser on the host, with the scope and the
+// file they were read from. Unreadable sources are reported in
+// browser_extension_status.
+func BrowserPoliciesTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("browser_type"),
+		table.TextColumn("name"),
+		table.TextColumn("value"),
+		table.TextColumn("scope"),
+		table.TextColumn("source_path"),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectPolicies(ctx), nil
+	}
+
+	return newPlugin("browser_policies", columns, gen, alwaysStateless)
+}
+
+// collectPolicies returns the policies of every enabled browser
+func collectPolicies(ctx context.Context) []map[string]string {
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range policySources() {
+		if !browserEnabled(source.Browser()) {
+			continue
+		}
+
+		started := time.Now()
+		policies, err := source.Policies()
+		if err != nil {
+			status.RecordProfileError(stats.tableName(), source.Browser(), "", "", source.Location(), err)
+			stats.observe(source.Browser(), started, 0)
+			continue
+		}
+		status.ClearProfileError(stats.tableName(), source.Location())
+
+		for _, policy := range policies {
+			results = append(results, map[string]string{
+				"browser_type": policy.Browser,
+				"name":         policy.Name,
+				"value":        policy.Value,
+				"scope":        policy.Scope,
+				"source_path":  policy.Source,
+			})
+		}
+		stats.observe(source.Browser(), started, len(policies))
+	}
+
+	return results
+}
@@ -0,0 +1,108 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// urlRisks are the risk categories of preferences whose values are URLs or
+// hosts
+var urlRisks = map[string]bool{
+	common.RiskStartupHomepage: true,
+	common.RiskProxy:           true,
+	common.RiskDNSOverHTTPS:    true,
+}
+
+// holdsURLs reports whether a preference's value is URLs or hosts the URL
+// redaction policy applies to: a string flagged as a homepage, proxy or DoH
+// resolver, or any string that parses as a URL with a host
+func holdsURLs(preference common.Preference) bool {
+	if preference.Type != common.PreferenceString || preference.Value == "" {
+		return false
+	}
+	return urlRisks[preference.Risk] || common.ParseURL(preference.Value).Host != ""
+}
+
+// BrowserPreferencesTablePlugin creates a table plugin listing the
+// preferences set in each profile's preference files, currently Firefox's
+// prefs.js and user.js. Security-relevant values are flagged in the risk
+// column. Values holding URLs follow the configured URL redaction.
+// Constraints on uid or username limit enumeration to the matching users.
+func BrowserPreferencesTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("key"),
+		table.TextColumn("value"),
+		table.TextColumn("type"),
+		table.TextColumn("source"),
+		table.TextColumn("risk"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectPreferences(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_preferences", columns, gen, alwaysStateless)
+}
+
+// collectPreferences returns the preferences of every discovered profile of
+// the selected users
+func collectPreferences(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	policy := currentSettings().Redaction
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindPreferences == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			preferences, err := source.FindPreferences(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			for _, preference := range preferences {
+				row := map[string]string{
+					"key":          preference.Key,
+					"value":        preference.Value,
+					"type":         preference.Type,
+					"source":       preference.Source,
+					"risk":         preference.Risk,
+					"username":     profile.Username,
+					"uid":          profile.UID,
+					"browser_type": profile.BrowserType,
+					"profile":      profile.ID,
+				}
+				if holdsURLs(preference) {
+					row["value"] = policy.RedactURLs(preference.Value)
+					row[redaction.Column] = policy.AppliedMode()
+				}
+				results = append(results, row)
+			}
+			stats.observe(profile.BrowserType, started, len(preferences))
+		}
+	}
+
+	return results
+}
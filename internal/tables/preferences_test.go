@@ -0,0 +1,52 @@
+package tables
+
+import (
+	"context"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+func TestCollectPreferencesRedactsURLs(t *testing.T) {
+	defer func(saved []common.HistorySource) { historySources = saved }(historySources)
+	defer Configure(Settings{})
+	Configure(Settings{Redaction: redaction.Policy{Mode: redaction.ModeDomainOnly}})
+
+	historySources = []common.HistorySource{{
+		Engine: "Test",
+		FindProfiles: func(common.UserSelection) ([]common.Profile, error) {
+			return []common.Profile{{ID: "default-release", BrowserType: "firefox"}}, nil
+		},
+		FindPreferences: func(common.Profile) ([]common.Preference, error) {
+			return []common.Preference{
+				{Key: "browser.startup.homepage", Value: "https://start.example.com/?u=1|https://mail.example.org/",
+					Type: common.PreferenceString, Risk: common.RiskStartupHomepage},
+				{Key: "network.proxy.http", Value: "proxy.example.net", Type: common.PreferenceString, Risk: common.RiskProxy},
+				{Key: "network.proxy.type", Value: "1", Type: "int", Risk: common.RiskProxy},
+				{Key: "network.trr.uri", Value: "https://doh.example.com/dns-query", Type: common.PreferenceString, Risk: common.RiskDNSOverHTTPS},
+				{Key: "extensions.webservice.discoverURL", Value: "https://discovery.example.com/", Type: common.PreferenceString},
+				{Key: "browser.download.dir", Value: "/home/alice/Downloads", Type: common.PreferenceString},
+			}, nil
+		},
+	}}
+
+	rows := collectPreferences(context.Background(), common.UserSelection{})
+	want := map[string][2]string{
+		"browser.startup.homepage":          {"example.com|example.org", redaction.ModeDomainOnly},
+		"network.proxy.http":                {"example.net", redaction.ModeDomainOnly},
+		"network.proxy.type":                {"1", ""},
+		"network.trr.uri":                   {"example.com", redaction.ModeDomainOnly},
+		"extensions.webservice.discoverURL": {"example.com", redaction.ModeDomainOnly},
+		"browser.download.dir":              {"/home/alice/Downloads", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("collectPreferences() = %v, want %d rows", rows, len(want))
+	}
+	for _, row := range rows {
+		w := want[row["key"]]
+		if row["value"] != w[0] || row[redaction.Column] != w[1] {
+			t.Errorf("%s = %q (%q), want %q (%q)", row["key"], row["value"], row[redaction.Column], w[0], w[1])
+		}
+	}
+}
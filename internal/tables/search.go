@@ -0,0 +1,99 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+// BrowserSearchEnginesTablePlugin creates a table plugin listing the search
+// engines configured in each profile, marking the default and flagging a
+// default that points away from well-known search engines. Constraints on
+// uid or username limit enumeration to the matching users.
+func BrowserSearchEnginesTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("name"),
+		table.TextColumn("keyword"),
+		table.TextColumn("url"),
+		table.TextColumn("favicon_url"),
+		table.IntegerColumn("is_default"),
+		table.BigIntColumn("created_time"),
+		table.TextColumn("created_datetime"),
+		table.TextColumn("created_display_time"),
+		table.BigIntColumn("last_modified_time"),
+		table.TextColumn("last_modified_datetime"),
+		table.TextColumn("last_modified_display_time"),
+		table.TextColumn("source"),
+		table.TextColumn("risk"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectSearchEngines(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_search_engines", columns, gen, alwaysStateless)
+}
+
+// collectSearchEngines returns the search engines of every discovered
+// profile of the selected users
+func collectSearchEngines(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindSearchEngines == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			engines, err := source.FindSearchEngines(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			for _, engine := range engines {
+				isDefault := 0
+				if engine.Default {
+					isDefault = 1
+				}
+				row := map[string]string{
+					"name":         engine.Name,
+					"keyword":      engine.Keyword,
+					"url":          engine.URL,
+					"favicon_url":  engine.FaviconURL,
+					"is_default":   strconv.Itoa(isDefault),
+					"source":       engine.Source,
+					"risk":         engine.Risk,
+					"username":     profile.Username,
+					"uid":          profile.UID,
+					"browser_type": profile.BrowserType,
+					"profile":      profile.ID,
+				}
+				setTime(row, "created_time", "created_datetime", "created_display_time", engine.Created)
+				setTime(row, "last_modified_time", "last_modified_datetime", "last_modified_display_time", engine.LastModified)
+				results = append(results, row)
+			}
+			stats.observe(profile.BrowserType, started, len(engines))
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,104 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// urlSettings are the security settings whose values are URLs or hosts, so
+// the URL redaction policy applies to them
+var urlSettings = map[string]bool{
+	"proxy_server":             true,
+	"proxy_pac_url":            true,
+	"homepage":                 true,
+	"startup_urls":             true,
+	"default_search_url":       true,
+	"dns_over_https_templates": true,
+}
+
+// BrowserSecuritySettingsTablePlugin creates a table plugin listing the
+// security-relevant settings of each Chromium profile, such as Safe Browsing,
+// proxy, startup pages and the default search engine, with the integrity of
+// tracked preferences. Values holding URLs follow the configured URL
+// redaction. Constraints on uid or username limit enumeration to the
+// matching users.
+func BrowserSecuritySettingsTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("setting"),
+		table.TextColumn("value"),
+		table.TextColumn("pref_path"),
+		table.TextColumn("source"),
+		table.TextColumn("mac_status"),
+		table.TextColumn("risk"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectSecuritySettings(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_security_settings", columns, gen, alwaysStateless)
+}
+
+// collectSecuritySettings returns the security settings of every discovered
+// profile of the selected users
+func collectSecuritySettings(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	policy := currentSettings().Redaction
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindSecuritySettings == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			settings, err := source.FindSecuritySettings(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			for _, setting := range settings {
+				row := map[string]string{
+					"setting":      setting.Setting,
+					"value":        setting.Value,
+					"pref_path":    setting.Path,
+					"source":       setting.Source,
+					"mac_status":   setting.MACStatus,
+					"risk":         setting.Risk,
+					"username":     profile.Username,
+					"uid":          profile.UID,
+					"browser_type": profile.BrowserType,
+					"profile":      profile.ID,
+				}
+				if urlSettings[setting.Setting] {
+					row["value"] = policy.RedactURLs(setting.Value)
+					row[redaction.Column] = policy.AppliedMode()
+				}
+				results = append(results, row)
+			}
+			stats.observe(profile.BrowserType, started, len(settings))
+		}
+	}
+
+	return results
+}
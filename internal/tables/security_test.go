@@ -0,0 +1,47 @@
+package tables
+
+import (
+	"context"
+	"testing"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+func TestCollectSecuritySettingsRedactsURLs(t *testing.T) {
+	defer func(saved []common.HistorySource) { historySources = saved }(historySources)
+	defer Configure(Settings{})
+	Configure(Settings{Redaction: redaction.Policy{Mode: redaction.ModeDomainOnly}})
+
+	historySources = []common.HistorySource{{
+		Engine: "Test",
+		FindProfiles: func(common.UserSelection) ([]common.Profile, error) {
+			return []common.Profile{{ID: "Default", BrowserType: "chrome"}}, nil
+		},
+		FindSecuritySettings: func(common.Profile) ([]common.SecuritySetting, error) {
+			return []common.SecuritySetting{
+				{Setting: "homepage", Value: "https://start.example.com/?user=alice", Risk: common.RiskStartupHomepage},
+				{Setting: "proxy_server", Value: "proxy.example.net:3128"},
+				{Setting: "startup_urls", Value: `["https://mail.example.org/inbox"]`},
+				{Setting: "proxy_mode", Value: "fixed_servers", Risk: common.RiskProxy},
+			}, nil
+		},
+	}}
+
+	rows := collectSecuritySettings(context.Background(), common.UserSelection{})
+	want := map[string][2]string{
+		"homepage":     {"example.com", redaction.ModeDomainOnly},
+		"proxy_server": {"example.net", redaction.ModeDomainOnly},
+		"startup_urls": {`["example.org"]`, redaction.ModeDomainOnly},
+		"proxy_mode":   {"fixed_servers", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("collectSecuritySettings() = %v, want %d rows", rows, len(want))
+	}
+	for _, row := range rows {
+		w := want[row["setting"]]
+		if row["value"] != w[0] || row[redaction.Column] != w[1] {
+			t.Errorf("%s = %q (%q), want %q (%q)", row["setting"], row["value"], row[redaction.Column], w[0], w[1])
+		}
+	}
+}
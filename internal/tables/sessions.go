@@ -0,0 +1,195 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// DefaultSessionGap is the inactivity after which a new browsing session starts
+const DefaultSessionGap = 30 * time.Minute
+
+// topDomainCount is the number of domains reported in top_domains
+const topDomainCount = 3
+
+// session is a run of visits in one profile
+type session struct {
+	visits []common.HistoryEntry
+	ids    map[int64]bool
+}
+
+// start returns the time of the session's first visit
+func (s *session) start() time.Time {
+	return s.visits[0].VisitTime
+}
+
+// end returns the time of the session's last visit
+func (s *session) end() time.Time {
+	return s.visits[len(s.visits)-1].VisitTime
+}
+
+// add appends a visit to the session
+func (s *session) add(entry common.HistoryEntry) {
+	s.visits = append(s.visits, entry)
+	s.ids[entry.VisitID] = true
+}
+
+// sessionize groups the visits of one profile into sessions. A visit starts a
+// new session when it follows the previous visit by more than gap, unless it
+// was navigated from a visit of the current session.
+func sessionize(entries []common.HistoryEntry, gap time.Duration) []*session {
+	sorted := append([]common.HistoryEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].VisitTime.Equal(sorted[j].VisitTime) {
+			return sorted[i].VisitTime.Before(sorted[j].VisitTime)
+		}
+		return sorted[i].VisitID < sorted[j].VisitID
+	})
+
+	var sessions []*session
+	var current *session
+	for _, entry := range sorted {
+		continues := current != nil &&
+			(entry.VisitTime.Sub(current.end()) <= gap || current.ids[entry.FromVisitID])
+		if !continues {
+			current = &session{ids: make(map[int64]bool)}
+			sessions = append(sessions, current)
+		}
+		current.add(entry)
+	}
+	return sessions
+}
+
+// domains returns the number of distinct registrable domains visited in the
+// session and the most visited ones, most visited first
+func (s *session) domains(top int) (int, []string) {
+	counts := make(map[string]int)
+	for _, visit := range s.visits {
+		if domain := common.ParseURL(visit.URL).Domain; domain != "" {
+			counts[domain]++
+		}
+	}
+
+	domains := make([]string, 0, len(counts))
+	for domain := range counts {
+		domains = append(domains, domain)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if counts[domains[i]] != counts[domains[j]] {
+			return counts[domains[i]] > counts[domains[j]]
+		}
+		return domains[i] < domains[j]
+	})
+
+	if len(domains) > top {
+		return len(counts), domains[:top]
+	}
+	return len(counts), domains
+}
+
+// BrowserSessionsTablePlugin creates a table plugin grouping the visits of
+// each profile into browsing sessions separated by the configured inactivity
+// gap. Constraints on uid or username limit enumeration to the matching users.
+func BrowserSessionsTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.BigIntColumn("session_id"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.BigIntColumn("start_time"),
+		table.BigIntColumn("end_time"),
+		table.TextColumn("start_datetime"),
+		table.TextColumn("end_datetime"),
+		table.TextColumn("start_display_time"),
+		table.TextColumn("end_display_time"),
+		table.BigIntColumn("duration_seconds"),
+		table.IntegerColumn("visit_count"),
+		table.IntegerColumn("distinct_domains"),
+		table.TextColumn("top_domains"),
+		table.TextColumn("entry_url"),
+		table.TextColumn("exit_url"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectSessions(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_sessions", columns, gen, alwaysStateless)
+}
+
+// collectSessions returns the sessions of every discovered profile of the
+// selected users. Visits on denied domains are left out before grouping.
+func collectSessions(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	s := currentSettings()
+	gap := s.SessionGap
+	if gap <= 0 {
+		gap = DefaultSessionGap
+	}
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			historyEntries, err := source.FindHistorySince(profile, common.Cursor{})
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			var allowed []common.HistoryEntry
+			for _, entry := range historyEntries {
+				if !s.Redaction.Denied(entry.URL) {
+					allowed = append(allowed, entry)
+				}
+			}
+
+			sessions := sessionize(allowed, gap)
+			for _, sess := range sessions {
+				results = append(results, sessionRow(profile, sess))
+			}
+			stats.observe(profile.BrowserType, started, len(sessions))
+		}
+	}
+
+	return results
+}
+
+// sessionRow converts a session into a table row
+func sessionRow(profile common.Profile, sess *session) map[string]string {
+	distinct, top := sess.domains(topDomainCount)
+	row := map[string]string{
+		"session_id":       strconv.FormatInt(sess.visits[0].VisitID, 10),
+		"username":         profile.Username,
+		"uid":              profile.UID,
+		"browser_type":     profile.BrowserType,
+		"profile":          profile.ID,
+		"duration_seconds": strconv.FormatInt(int64(sess.end().Sub(sess.start()).Seconds()), 10),
+		"visit_count":      strconv.Itoa(len(sess.visits)),
+		"distinct_domains": strconv.Itoa(distinct),
+		"top_domains":      strings.Join(top, ","),
+		"entry_url":        sess.visits[0].URL,
+		"exit_url":         sess.visits[len(sess.visits)-1].URL,
+	}
+	setTime(row, "start_time", "start_datetime", "start_display_time", sess.start())
+	setTime(row, "end_time", "end_datetime", "end_display_time", sess.end())
+	return row
+}
@@ -0,0 +1,71 @@
+package tables
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestSessionize(t *testing.T) {
+	base := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	visit := func(id, from int64, minutes int, url string) common.HistoryEntry {
+		return common.HistoryEntry{VisitID: id, FromVisitID: from, VisitTime: base.Add(time.Duration(minutes) * time.Minute), URL: url}
+	}
+
+	entries := []common.HistoryEntry{
+		// Out of order, as browsers return visits most recent first
+		visit(3, 2, 20, "https://docs.example.com/b"),
+		visit(1, 0, 0, "https://mail.example.com/"),
+		visit(2, 1, 5, "https://docs.example.com/a"),
+		// 2h gap, but navigated from visit 3 (a tab left open)
+		visit(4, 3, 140, "https://news.example.org/"),
+		// 2h gap without a referrer starts a new session
+		visit(5, 0, 260, "https://search.example.net/?q=x"),
+		visit(6, 5, 261, "chrome://settings/"),
+	}
+
+	sessions := sessionize(entries, 30*time.Minute)
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	var ids [][]int64
+	for _, s := range sessions {
+		var sessionIDs []int64
+		for _, v := range s.visits {
+			sessionIDs = append(sessionIDs, v.VisitID)
+		}
+		ids = append(ids, sessionIDs)
+	}
+	if want := [][]int64{{1, 2, 3, 4}, {5, 6}}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("sessionize() visits = %v, want %v", ids, want)
+	}
+
+	t.Run("row", func(t *testing.T) {
+		profile := common.Profile{ID: "Default", BrowserType: "chrome", Username: "alice", UID: "1000"}
+		row := sessionRow(profile, sessions[0])
+
+		want := map[string]string{
+			"session_id":       "1",
+			"start_datetime":   "2024-03-01T02:00:00Z",
+			"end_datetime":     "2024-03-01T04:20:00Z",
+			"duration_seconds": "8400",
+			"visit_count":      "4",
+			"distinct_domains": "2",
+			"top_domains":      "example.com,example.org",
+			"entry_url":        "https://mail.example.com/",
+			"exit_url":         "https://news.example.org/",
+		}
+		for column, value := range want {
+			if row[column] != value {
+				t.Errorf("%s = %q, want %q", column, row[column], value)
+			}
+		}
+
+		if distinct, _ := sessions[1].domains(topDomainCount); distinct != 1 {
+			t.Errorf("Expected internal pages to have no domain, got %d distinct domains", distinct)
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// Settings controls how tables are generated and can be replaced at runtime
+type Settings struct {
+	// Tables lists the enabled tables; empty enables every table
+	Tables []string
+
+	// Browsers lists the enabled browser types; empty enables every browser
+	Browsers []string
+
+	// MaxRows caps the rows returned by a single query; 0 means no limit
+	MaxRows int
+
+	// CacheTTL is how long rows are reused for identical queries; 0 disables caching
+	CacheTTL time.Duration
+
+	// Redaction is applied to every row before it leaves the extension
+	Redaction redaction.Policy
+
+	// IOCDir holds the indicator lists matched by browser_ioc_matches
+	IOCDir string
+
+	// SessionGap is the inactivity that separates browsing sessions; 0 uses
+	// DefaultSessionGap
+	SessionGap time.Duration
+
+	// AutofillAddresses adds saved address metadata to browser_autofill
+	AutofillAddresses bool
+
+	// Carving enables deleted-record recovery in browser_history_carved
+	Carving bool
+
+	// DisplayLocation is the timezone of display_time columns; nil uses UTC
+	DisplayLocation *time.Location
+}
+
+var settings atomic.Pointer[Settings]
+
+// Configure replaces the table settings and drops cached results
+func Configure(s Settings) {
+	settings.Store(&s)
+	results.clear()
+}
+
+// currentSettings returns the active table settings
+func currentSettings() Settings {
+	if s := settings.Load(); s != nil {
+		return *s
+	}
+	return Settings{}
+}
+
+// tableEnabled reports whether the named table is enabled
+func tableEnabled(name string) bool {
+	enabled := currentSettings().Tables
+	if len(enabled) == 0 {
+		return true
+	}
+	for _, table := range enabled {
+		if table == name {
+			return true
+		}
+	}
+	return false
+}
+
+// browserEnabled reports whether profiles of the given browser type are read
+func browserEnabled(browserType string) bool {
+	enabled := currentSettings().Browsers
+	if len(enabled) == 0 {
+		return true
+	}
+	for _, browser := range enabled {
+		if strings.EqualFold(browser, browserType) {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledProfiles returns the discovered profiles whose browser is enabled
+func enabledProfiles(profiles []common.Profile, err error) ([]common.Profile, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled []common.Profile
+	for _, profile := range profiles {
+		if browserEnabled(profile.BrowserType) {
+			enabled = append(enabled, profile)
+		}
+	}
+	return enabled, nil
+}
+
+// setTime sets the epoch seconds, UTC RFC3339 and display timezone RFC3339
+// columns of a timestamp. A zero time leaves all three empty.
+func setTime(row map[string]string, epochColumn, utcColumn, displayColumn string, t time.Time) {
+	row[epochColumn] = common.FormatUnix(t)
+	row[utcColumn] = common.FormatRFC3339(t, time.UTC)
+	row[displayColumn] = common.FormatRFC3339(t, currentSettings().DisplayLocation)
+}
+
+// alwaysStateless is the stateless function of tables whose queries never
+// change state
+func alwaysStateless(table.QueryContext) bool {
+	return true
+}
+
+// newPlugin creates a table plugin that honors the table settings, redacts
+// rows and reports each generation to the status table. Rows are
+// only cached and capped for queries that stateless reports true for; queries
+// that advance state such as cursors must return every row they consume. A
+// nil stateless treats every query as stateful.
+func newPlugin(name string, columns []table.ColumnDefinition, gen table.GenerateFunc, stateless func(table.QueryContext) bool) *table.Plugin {
+	wrapped := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		if !tableEnabled(name) {
+			return nil, nil
+		}
+
+		s := currentSettings()
+		key := cacheKey(name, queryContext)
+		isStateless := stateless != nil && stateless(queryContext)
+		useCache := isStateless && s.CacheTTL > 0
+		if useCache {
+			if rows, ok := results.get(key); ok {
+				return rows, nil
+			}
+		}
+
+		started := time.Now()
+		ctx, stats := withGenerationStats(ctx, name)
+		rows, err := gen(ctx, queryContext)
+		if err != nil {
+			stats.record(started, 0, err)
+			return nil, err
+		}
+		rows = s.Redaction.Apply(rows)
+
+		if isStateless && s.MaxRows > 0 && len(rows) > s.MaxRows {
+			rows = rows[:s.MaxRows]
+		}
+		stats.record(started, len(rows), nil)
+
+		if useCache {
+			results.put(key, rows, s.CacheTTL)
+		}
+
+		return rows, nil
+	}
+
+	return table.NewPlugin(name, columns, wrapped)
+}
+
+// cacheKey identifies a query by table name and constraints
+func cacheKey(name string, queryContext table.QueryContext) string {
+	columns := make([]string, 0, len(queryContext.Constraints))
+	for column := range queryContext.Constraints {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, column := range columns {
+		for _, constraint := range queryContext.Constraints[column].Constraints {
+			fmt.Fprintf(&b, "|%s%d%q", column, constraint.Operator, constraint.Expression)
+		}
+	}
+	return b.String()
+}
+
+// cachedRows is a generated result and when it stops being reused
+type cachedRows struct {
+	rows    []map[string]string
+	expires time.Time
+}
+
+// resultCache holds generated rows keyed by query
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedRows
+}
+
+var results = &resultCache{entries: make(map[string]cachedRows)}
+
+// get returns unexpired rows for a query
+func (c *resultCache) get(key string) ([]map[string]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.rows, true
+}
+
+// put stores rows for a query
+func (c *resultCache) put(key string, rows []map[string]string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedRows{rows: rows, expires: time.Now().Add(ttl)}
+}
+
+// clear drops all cached rows
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cachedRows)
+}
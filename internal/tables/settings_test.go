@@ -0,0 +1,223 @@
+package tables
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// generateRows runs a table plugin's generate action without constraints
+func generateRows(t *testing.T, plugin *table.Plugin) []map[string]string {
+	t.Helper()
+
+	response := plugin.Call(context.Background(), map[string]string{"action": "generate", "context": "{}"})
+	if response.Status.Code != 0 {
+		t.Fatalf("generate failed: %s", response.Status.Message)
+	}
+	return response.Response
+}
+
+func TestNewPluginSettings(t *testing.T) {
+	defer Configure(Settings{})
+
+	calls := 0
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		calls++
+		return []map[string]string{{"n": "1"}, {"n": "2"}, {"n": "3"}}, nil
+	}
+	stateless := func(table.QueryContext) bool { return true }
+	columns := []table.ColumnDefinition{table.TextColumn("n")}
+
+	t.Run("disabled_table_returns_no_rows", func(t *testing.T) {
+		Configure(Settings{Tables: []string{"other_table"}})
+		if rows := generateRows(t, newPlugin("test_table", columns, gen, stateless)); len(rows) != 0 {
+			t.Errorf("Expected no rows from disabled table, got %d", len(rows))
+		}
+	})
+
+	t.Run("max_rows_caps_stateless_queries_only", func(t *testing.T) {
+		Configure(Settings{MaxRows: 2})
+		if rows := generateRows(t, newPlugin("test_table", columns, gen, stateless)); len(rows) != 2 {
+			t.Errorf("Expected 2 rows, got %d", len(rows))
+		}
+		if rows := generateRows(t, newPlugin("test_table", columns, gen, nil)); len(rows) != 3 {
+			t.Errorf("Expected stateful query to keep all 3 rows, got %d", len(rows))
+		}
+	})
+
+	t.Run("cache_reuses_rows", func(t *testing.T) {
+		Configure(Settings{CacheTTL: time.Minute})
+		plugin := newPlugin("test_table", columns, gen, stateless)
+
+		calls = 0
+		generateRows(t, plugin)
+		generateRows(t, plugin)
+		if calls != 1 {
+			t.Errorf("Expected one generation with caching, got %d", calls)
+		}
+	})
+}
+
+func TestBrowserEnabled(t *testing.T) {
+	defer Configure(Settings{})
+
+	Configure(Settings{Browsers: []string{"Chrome", "firefox"}})
+	if !browserEnabled("chrome") || !browserEnabled("firefox") {
+		t.Error("Expected configured browsers to be enabled")
+	}
+	if browserEnabled("edge") {
+		t.Error("Expected unconfigured browser to be disabled")
+	}
+}
+
+func TestNewPluginRecordsGeneration(t *testing.T) {
+	defer Configure(Settings{})
+	Configure(Settings{})
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		stats := generationStatsFromContext(ctx)
+		stats.observe("chrome", time.Now(), 2)
+		stats.observe("firefox", time.Now(), 1)
+		return []map[string]string{{"n": "1"}, {"n": "2"}, {"n": "3"}}, nil
+	}
+	generateRows(t, newPlugin("status_test_table", []table.ColumnDefinition{table.TextColumn("n")}, gen, nil))
+
+	want := map[string]string{"": "3", "chrome": "2", "firefox": "1"}
+	got := make(map[string]string)
+	for _, row := range generateRows(t, BrowserExtensionStatusTablePlugin()) {
+		if row["kind"] == "generation" && row["table_name"] == "status_test_table" {
+			got[row["browser_type"]] = row["rows"]
+		}
+	}
+	for browser, rows := range want {
+		if got[browser] != rows {
+			t.Errorf("Expected %s rows for browser %q, got %q", rows, browser, got[browser])
+		}
+	}
+}
+
+func TestNewPluginRecordsProfileErrorsPerTable(t *testing.T) {
+	defer Configure(Settings{})
+	Configure(Settings{})
+
+	profile := common.Profile{Path: t.TempDir(), BrowserType: "chrome"}
+	webData := filepath.Join(profile.Path, "Web Data")
+	failing := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		generationStatsFromContext(ctx).reportProfileError(profile, &fs.PathError{Op: "open", Path: webData, Err: fs.ErrPermission})
+		return nil, nil
+	}
+	succeeding := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		generationStatsFromContext(ctx).clearProfileError(profile)
+		return nil, nil
+	}
+	columns := []table.ColumnDefinition{table.TextColumn("n")}
+	generateRows(t, newPlugin("status_test_failing", columns, failing, nil))
+	generateRows(t, newPlugin("status_test_succeeding", columns, succeeding, nil))
+
+	var found []map[string]string
+	for _, row := range generateRows(t, BrowserExtensionStatusTablePlugin()) {
+		if row["kind"] == "profile_error" && row["path"] == webData {
+			found = append(found, row)
+		}
+	}
+	if len(found) != 1 || found[0]["table_name"] != "status_test_failing" || found[0]["error_class"] != "permission_denied" {
+		t.Fatalf("Expected the Web Data error of status_test_failing to remain, got %+v", found)
+	}
+
+	generateRows(t, newPlugin("status_test_failing", columns, succeeding, nil))
+	for _, row := range generateRows(t, BrowserExtensionStatusTablePlugin()) {
+		if row["kind"] == "profile_error" && row["path"] == webData {
+			t.Errorf("Expected the error to clear once the table reads the profile, got %+v", row)
+		}
+	}
+}
+
+func TestNewPluginRedactsRows(t *testing.T) {
+	defer Configure(Settings{})
+	Configure(Settings{Redaction: redaction.Policy{Mode: redaction.ModeStripQuery, DenyDomains: []string{"blocked.example"}}})
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return []map[string]string{
+			{"url": "https://example.com/search?q=secret", "query": "q=secret"},
+			{"url": "https://www.blocked.example/", "query": ""},
+		}, nil
+	}
+	columns := []table.ColumnDefinition{table.TextColumn("url"), table.TextColumn("query"), table.TextColumn(redaction.Column)}
+
+	rows := generateRows(t, newPlugin("test_table", columns, gen, nil))
+	if len(rows) != 1 {
+		t.Fatalf("Expected the denied row to be dropped, got %d rows", len(rows))
+	}
+	if rows[0]["url"] != "https://example.com/search" || rows[0]["query"] != "" || rows[0][redaction.Column] != redaction.ModeStripQuery {
+		t.Errorf("Expected a stripped URL, got %v", rows[0])
+	}
+}
+
+func TestSetTime(t *testing.T) {
+	defer Configure(Settings{})
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("utc by default", func(t *testing.T) {
+		row := map[string]string{}
+		setTime(row, "time", "datetime", "display_time", at)
+		if row["time"] != "1709296200" || row["datetime"] != "2024-03-01T12:30:00Z" || row["display_time"] != "2024-03-01T12:30:00Z" {
+			t.Errorf("Unexpected time columns: %v", row)
+		}
+	})
+
+	t.Run("display location", func(t *testing.T) {
+		Configure(Settings{DisplayLocation: time.FixedZone("UTC+2", 2*60*60)})
+		row := map[string]string{}
+		setTime(row, "time", "datetime", "display_time", at)
+		if row["datetime"] != "2024-03-01T12:30:00Z" || row["display_time"] != "2024-03-01T14:30:00+02:00" {
+			t.Errorf("Unexpected time columns: %v", row)
+		}
+	})
+
+	t.Run("zero time", func(t *testing.T) {
+		row := map[string]string{}
+		setTime(row, "time", "datetime", "display_time", time.Time{})
+		if row["time"] != "" || row["datetime"] != "" || row["display_time"] != "" {
+			t.Errorf("Expected empty columns for the zero time, got %v", row)
+		}
+	})
+}
+
+func TestPluginsDeclareUIDAsBigInt(t *testing.T) {
+	for _, plugin := range Plugins(Dependencies{}) {
+		for _, column := range plugin.Routes() {
+			if column["name"] == "uid" && column["type"] != string(table.ColumnTypeBigInt) {
+				t.Errorf("%s declares uid as %s, want BIGINT to join with osquery's users table", plugin.Name(), column["type"])
+			}
+		}
+	}
+}
+
+func TestPluginsDeclareTimesAsEpochWithDatetime(t *testing.T) {
+	for _, plugin := range Plugins(Dependencies{}) {
+		types := make(map[string]string)
+		for _, column := range plugin.Routes() {
+			types[column["name"]] = column["type"]
+		}
+		for name, columnType := range types {
+			if name != "time" && !strings.HasSuffix(name, "_time") || strings.HasSuffix(name, "display_time") {
+				continue
+			}
+			if columnType != string(table.ColumnTypeBigInt) {
+				t.Errorf("%s declares %s as %s, want BIGINT epoch seconds", plugin.Name(), name, columnType)
+			}
+			prefix := strings.TrimSuffix(name, "time")
+			if _, ok := types[prefix+"datetime"]; !ok {
+				t.Errorf("%s has %s without %sdatetime", plugin.Name(), name, prefix)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package tables
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/logging"
+	"osquery-extension-browsers/internal/status"
+)
+
+// browserGeneration accumulates the time spent on and rows produced from the
+// profiles of one browser during a generation
+type browserGeneration struct {
+	duration time.Duration
+	rows     int
+}
+
+// generationStats collects per-browser statistics while a table is generated
+type generationStats struct {
+	table    string
+	browsers map[string]*browserGeneration
+}
+
+type generationStatsKey struct{}
+
+// withGenerationStats returns a context carrying fresh generation statistics
+// for the named table
+func withGenerationStats(ctx context.Context, name string) (context.Context, *generationStats) {
+	stats := &generationStats{table: name, browsers: make(map[string]*browserGeneration)}
+	return context.WithValue(ctx, generationStatsKey{}, stats), stats
+}
+
+// generationStatsFromContext returns the statistics of the generation running
+// with ctx, or nil when none are collected
+func generationStatsFromContext(ctx context.Context) *generationStats {
+	stats, _ := ctx.Value(generationStatsKey{}).(*generationStats)
+	return stats
+}
+
+// observe adds the time since started and rows to a browser's totals
+func (s *generationStats) observe(browser string, started time.Time, rows int) {
+	if s == nil {
+		return
+	}
+	b, ok := s.browsers[browser]
+	if !ok {
+		b = &browserGeneration{}
+		s.browsers[browser] = b
+	}
+	b.duration += time.Since(started)
+	b.rows += rows
+}
+
+// tableName returns the table being generated, empty when no statistics are
+// collected
+func (s *generationStats) tableName() string {
+	if s == nil {
+		return ""
+	}
+	return s.table
+}
+
+// record reports the generation of the table and of each browser it read
+func (s *generationStats) record(started time.Time, rows int, err error) {
+	generation := status.Generation{
+		Table:    s.table,
+		Time:     started,
+		Duration: time.Since(started),
+		Rows:     rows,
+	}
+	if err != nil {
+		generation.Error = err.Error()
+	}
+	status.RecordGeneration(generation)
+
+	for browser, b := range s.browsers {
+		status.RecordGeneration(status.Generation{
+			Table:    s.table,
+			Browser:  browser,
+			Time:     started,
+			Duration: b.duration,
+			Rows:     b.rows,
+		})
+	}
+}
+
+// reportProfileError logs and records an error the table hit reading a
+// profile, against the file the error names or else the profile directory
+func (s *generationStats) reportProfileError(profile common.Profile, err error) {
+	path := profile.Path
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && pathErr.Path != "" {
+		path = pathErr.Path
+	}
+
+	slog.Warn("Failed to read profile", append(logging.ProfileArgs(profile), "table", s.tableName(), "path", path, "error", err)...)
+	status.RecordProfileError(s.tableName(), profile.BrowserType, profile.Username, profile.UID, path, err)
+}
+
+// clearProfileError forgets the errors the table recorded for a profile once
+// it reads successfully
+func (s *generationStats) clearProfileError(profile common.Profile) {
+	status.ClearProfileError(s.tableName(), profile.Path)
+}
+
+// BrowserExtensionStatusTablePlugin creates a table plugin reporting the last
+// generation of each table and browser, the profiles that could not be read,
+// and the databases whose schema version is untested. Rows have a kind of
+// 'generation', 'profile_error' or 'schema'.
+func BrowserExtensionStatusTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("kind"),
+		table.TextColumn("table_name"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("path"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.BigIntColumn("time"),
+		table.TextColumn("datetime"),
+		table.TextColumn("display_time"),
+		table.BigIntColumn("duration_ms"),
+		table.BigIntColumn("rows"),
+		table.TextColumn("error_class"),
+		table.TextColumn("message"),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		var results []map[string]string
+
+		for _, g := range status.Generations() {
+			row := map[string]string{
+				"kind":         "generation",
+				"table_name":   g.Table,
+				"browser_type": g.Browser,
+				"path":         "",
+				"username":     "",
+				"uid":          "",
+				"duration_ms":  strconv.FormatInt(g.Duration.Milliseconds(), 10),
+				"rows":         strconv.Itoa(g.Rows),
+				"error_class":  "",
+				"message":      g.Error,
+			}
+			setTime(row, "time", "datetime", "display_time", g.Time)
+			results = append(results, row)
+		}
+
+		for _, e := range status.ProfileErrors() {
+			row := map[string]string{
+				"kind":         "profile_error",
+				"table_name":   e.Table,
+				"browser_type": e.Browser,
+				"path":         e.Path,
+				"username":     e.Username,
+				"uid":          e.UID,
+				"duration_ms":  "",
+				"rows":         "",
+				"error_class":  e.Class,
+				"message":      e.Message,
+			}
+			setTime(row, "time", "datetime", "display_time", e.Time)
+			results = append(results, row)
+		}
+
+		for _, n := range status.SchemaNotices() {
+			row := map[string]string{
+				"kind":         "schema",
+				"table_name":   "",
+				"browser_type": n.Browser,
+				"path":         n.Path,
+				"username":     n.Username,
+				"uid":          n.UID,
+				"duration_ms":  "",
+				"rows":         "",
+				"error_class":  "",
+				"message":      n.Message,
+			}
+			setTime(row, "time", "datetime", "display_time", n.Time)
+			results = append(results, row)
+		}
+
+		return results, nil
+	}
+
+	return newPlugin("browser_extension_status", columns, gen, nil)
+}
@@ -0,0 +1,48 @@
+package tables
+
+import (
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/events"
+)
+
+// Dependencies holds the shared state the tables are built with
+type Dependencies struct {
+	// Cursors stores history cursors; nil disables differential queries
+	Cursors *common.CursorStore
+
+	// Events buffers the visits seen by the history event collector
+	Events *events.Buffer
+}
+
+// Plugins returns every table plugin exposed by the extension
+func Plugins(deps Dependencies) []*table.Plugin {
+	return []*table.Plugin{
+		BrowserHistoryTablePlugin(deps.Cursors),
+		BrowserHistoryNewTablePlugin(deps.Cursors),
+		BrowserHistoryEventsTablePlugin(deps.Events),
+		BrowserIOCMatchesTablePlugin(),
+		BrowserTimelineTablePlugin(),
+		BrowserSessionsTablePlugin(),
+		BrowserAutofillTablePlugin(),
+		BrowserSitePermissionsTablePlugin(),
+		BrowserPreferencesTablePlugin(),
+		BrowserSecuritySettingsTablePlugin(),
+		BrowserPoliciesTablePlugin(),
+		BrowserSearchEnginesTablePlugin(),
+		BrowserTypedInputTablePlugin(),
+		BrowserFaviconPagesTablePlugin(),
+		BrowserHistoryCarvedTablePlugin(),
+		BrowserExtensionStatusTablePlugin(),
+	}
+}
+
+// Names returns the names of every table exposed by the extension
+func Names() []string {
+	var names []string
+	for _, plugin := range Plugins(Dependencies{}) {
+		names = append(names, plugin.Name())
+	}
+	return names
+}
@@ -0,0 +1,398 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// Event types reported by browser_timeline
+const (
+	eventVisit            = "visit"
+	eventDownload         = "download"
+	eventFormUse          = "form"
+	eventBookmark         = "bookmark"
+	eventCookie           = "cookie"
+	eventExtensionInstall = "extension_install"
+	eventLogin            = "login"
+)
+
+// timelineEvent is a timestamped artifact in the timeline
+type timelineEvent struct {
+	time       time.Time
+	eventType  string
+	profile    common.Profile
+	url        string
+	title      string
+	summary    string
+	sourcePath string
+}
+
+// timeRange bounds the events selected by time constraints. A zero bound is
+// open.
+type timeRange struct {
+	after  time.Time
+	before time.Time
+}
+
+// timeRangeFromContext returns the range selected by constraints on an
+// epoch seconds column
+func timeRangeFromContext(queryContext table.QueryContext, column string) timeRange {
+	var r timeRange
+	for _, constraint := range queryContext.Constraints[column].Constraints {
+		seconds, err := strconv.ParseInt(constraint.Expression, 10, 64)
+		if err != nil {
+			continue
+		}
+		at := time.Unix(seconds, 0)
+
+		switch constraint.Operator {
+		case table.OperatorEquals:
+			r.narrow(at, at.Add(time.Second))
+		case table.OperatorGreaterThan:
+			r.narrow(at.Add(time.Second), time.Time{})
+		case table.OperatorGreaterThanOrEquals:
+			r.narrow(at, time.Time{})
+		case table.OperatorLessThan:
+			r.narrow(time.Time{}, at)
+		case table.OperatorLessThanOrEquals:
+			r.narrow(time.Time{}, at.Add(time.Second))
+		}
+	}
+	return r
+}
+
+// narrow intersects the range with [after, before)
+func (r *timeRange) narrow(after, before time.Time) {
+	if !after.IsZero() && after.After(r.after) {
+		r.after = after
+	}
+	if !before.IsZero() && (r.before.IsZero() || before.Before(r.before)) {
+		r.before = before
+	}
+}
+
+// contains reports whether t lies in [after, before)
+func (r timeRange) contains(t time.Time) bool {
+	return !t.Before(r.after) && (r.before.IsZero() || t.Before(r.before))
+}
+
+// cursor returns a cursor selecting history recorded at or after the start
+// of the range, so the lower bound is applied in each browser's SQL
+func (r timeRange) cursor() common.Cursor {
+	if r.after.IsZero() {
+		return common.Cursor{}
+	}
+	return common.Cursor{VisitTime: r.after.Add(-time.Microsecond)}
+}
+
+// timelineSource reads the events of one kind of artifact of a profile. Each
+// event's sourcePath is the file it was read from relative to the profile.
+// A source the engine cannot read returns no events.
+type timelineSource func(source common.HistorySource, profile common.Profile, r timeRange) ([]timelineEvent, error)
+
+// timelineSources produce the events merged into the timeline
+var timelineSources = []timelineSource{
+	visitEvents,
+	downloadEvents,
+	formEvents,
+	bookmarkEvents,
+	cookieEvents,
+	extensionEvents,
+	loginEvents,
+}
+
+// BrowserTimelineTablePlugin creates a table plugin merging every timestamped
+// artifact of the selected users into one stream ordered by time. Constraints
+// on time bound the events read, and constraints on uid or username limit
+// enumeration to the matching users.
+func BrowserTimelineTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.BigIntColumn("time"),
+		table.TextColumn("datetime"),
+		table.TextColumn("display_time"),
+		table.TextColumn("event_type"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn("summary"),
+		table.TextColumn("url"),
+		table.TextColumn("title"),
+		table.TextColumn("source_path"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		selection := userSelectionFromContext(queryContext)
+		r := timeRangeFromContext(queryContext, "time")
+
+		timeline := collectTimeline(ctx, selection, r)
+		sort.SliceStable(timeline, func(i, j int) bool {
+			return timeline[i].time.Before(timeline[j].time)
+		})
+
+		results := make([]map[string]string, 0, len(timeline))
+		for _, event := range timeline {
+			row := map[string]string{
+				"event_type":   event.eventType,
+				"username":     event.profile.Username,
+				"uid":          event.profile.UID,
+				"browser_type": event.profile.BrowserType,
+				"profile":      event.profile.ID,
+				"summary":      event.summary,
+				"url":          event.url,
+				"title":        event.title,
+				"source_path":  event.sourcePath,
+			}
+			setTime(row, "time", "datetime", "display_time", event.time)
+			results = append(results, row)
+		}
+		return results, nil
+	}
+
+	return newPlugin("browser_timeline", columns, gen, alwaysStateless)
+}
+
+// collectTimeline returns the events of the selected users within r. The
+// errors of each source are reported separately, and a profile's errors are
+// cleared only once every source reads it.
+func collectTimeline(ctx context.Context, selection common.UserSelection, r timeRange) []timelineEvent {
+	var timeline []timelineEvent
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			ok := true
+			rows := 0
+			for _, read := range timelineSources {
+				events, err := read(source, profile, r)
+				if err != nil {
+					stats.reportProfileError(profile, err)
+					ok = false
+					continue
+				}
+				for _, event := range events {
+					// Artifacts the browser recorded no time for have no
+					// place in the timeline
+					if event.time.IsZero() || !r.contains(event.time) {
+						continue
+					}
+					event.profile = profile
+					event.sourcePath = filepath.Join(profile.Path, event.sourcePath)
+					timeline = append(timeline, event)
+					rows++
+				}
+			}
+			if ok {
+				stats.clearProfileError(profile)
+			}
+			stats.observe(profile.BrowserType, started, rows)
+		}
+	}
+
+	return timeline
+}
+
+// visitEvents returns the history visits of a profile, reading only those
+// recorded at or after the start of r
+func visitEvents(source common.HistorySource, profile common.Profile, r timeRange) ([]timelineEvent, error) {
+	entries, err := source.FindHistorySince(profile, r.cursor())
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]timelineEvent, 0, len(entries))
+	for _, entry := range entries {
+		summary := entry.Title
+		if summary == "" {
+			summary = entry.URL
+		}
+		events = append(events, timelineEvent{
+			time:       entry.VisitTime,
+			eventType:  eventVisit,
+			url:        entry.URL,
+			title:      entry.Title,
+			summary:    summary,
+			sourcePath: source.DatabaseFiles[0],
+		})
+	}
+	return events, nil
+}
+
+// downloadEvents returns the starts of a profile's downloads
+func downloadEvents(source common.HistorySource, profile common.Profile, _ timeRange) ([]timelineEvent, error) {
+	if source.FindDownloads == nil {
+		return nil, nil
+	}
+	downloads, err := source.FindDownloads(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]timelineEvent, 0, len(downloads))
+	for _, d := range downloads {
+		name := filepath.Base(d.TargetPath)
+		summary := "Downloaded " + name
+		if d.TargetPath == "" {
+			name, summary = "", "Downloaded "+d.URL
+		}
+		events = append(events, timelineEvent{
+			time:       d.StartTime,
+			eventType:  eventDownload,
+			url:        d.URL,
+			title:      name,
+			summary:    summary,
+			sourcePath: d.Source,
+		})
+	}
+	return events, nil
+}
+
+// formEvents returns the last use of each value typed into a form field.
+// The value itself is left out of the timeline.
+func formEvents(source common.HistorySource, profile common.Profile, _ timeRange) ([]timelineEvent, error) {
+	if source.FindAutofill == nil {
+		return nil, nil
+	}
+	entries, err := source.FindAutofill(profile, common.AutofillOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []timelineEvent
+	for _, entry := range entries {
+		if entry.Kind != common.AutofillForm {
+			continue
+		}
+		events = append(events, timelineEvent{
+			time:       entry.LastUsed,
+			eventType:  eventFormUse,
+			title:      entry.FieldName,
+			summary:    "Filled form field " + entry.FieldName,
+			sourcePath: entry.Source,
+		})
+	}
+	return events, nil
+}
+
+// bookmarkEvents returns the additions of a profile's bookmarks
+func bookmarkEvents(source common.HistorySource, profile common.Profile, _ timeRange) ([]timelineEvent, error) {
+	if source.FindBookmarks == nil {
+		return nil, nil
+	}
+	bookmarks, err := source.FindBookmarks(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]timelineEvent, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		name := b.Title
+		if name == "" {
+			name = b.URL
+		}
+		events = append(events, timelineEvent{
+			time:       b.Added,
+			eventType:  eventBookmark,
+			url:        b.URL,
+			title:      b.Title,
+			summary:    "Bookmarked " + name,
+			sourcePath: b.Source,
+		})
+	}
+	return events, nil
+}
+
+// cookieEvents returns the creations of a profile's cookies. The url is the
+// site the cookie is sent to, so domain redaction applies to it.
+func cookieEvents(source common.HistorySource, profile common.Profile, _ timeRange) ([]timelineEvent, error) {
+	if source.FindCookies == nil {
+		return nil, nil
+	}
+	cookies, err := source.FindCookies(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]timelineEvent, 0, len(cookies))
+	for _, c := range cookies {
+		host := strings.TrimPrefix(c.Host, ".")
+		events = append(events, timelineEvent{
+			time:       c.Created,
+			eventType:  eventCookie,
+			url:        "https://" + host + c.Path,
+			title:      c.Name,
+			summary:    "Cookie " + c.Name + " set by " + host,
+			sourcePath: c.Source,
+		})
+	}
+	return events, nil
+}
+
+// extensionEvents returns the installations of a profile's extensions
+func extensionEvents(source common.HistorySource, profile common.Profile, _ timeRange) ([]timelineEvent, error) {
+	if source.FindExtensions == nil {
+		return nil, nil
+	}
+	extensions, err := source.FindExtensions(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]timelineEvent, 0, len(extensions))
+	for _, e := range extensions {
+		name := e.Name
+		if name == "" {
+			name = e.ID
+		}
+		events = append(events, timelineEvent{
+			time:       e.Installed,
+			eventType:  eventExtensionInstall,
+			url:        e.URL,
+			title:      name,
+			summary:    "Installed extension " + name,
+			sourcePath: e.Source,
+		})
+	}
+	return events, nil
+}
+
+// loginEvents returns the last use of each saved login of a profile
+func loginEvents(source common.HistorySource, profile common.Profile, _ timeRange) ([]timelineEvent, error) {
+	if source.FindLogins == nil {
+		return nil, nil
+	}
+	logins, err := source.FindLogins(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]timelineEvent, 0, len(logins))
+	for _, l := range logins {
+		events = append(events, timelineEvent{
+			time:       l.LastUsed,
+			eventType:  eventLogin,
+			url:        l.URL,
+			summary:    "Used saved login for " + l.URL,
+			sourcePath: l.Source,
+		})
+	}
+	return events, nil
+}
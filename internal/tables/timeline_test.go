@@ -0,0 +1,155 @@
+package tables
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+)
+
+func TestTimeRangeFromContext(t *testing.T) {
+	constraint := func(op table.Operator, expr string) table.Constraint {
+		return table.Constraint{Operator: op, Expression: expr}
+	}
+
+	tests := []struct {
+		name        string
+		constraints []table.Constraint
+		in          []int64
+		out         []int64
+	}{
+		{
+			name:        "open",
+			constraints: nil,
+			in:          []int64{0, 1700000000},
+		},
+		{
+			name:        "between",
+			constraints: []table.Constraint{constraint(table.OperatorGreaterThanOrEquals, "100"), constraint(table.OperatorLessThan, "200")},
+			in:          []int64{100, 199},
+			out:         []int64{99, 200},
+		},
+		{
+			name:        "exclusive_lower_inclusive_upper",
+			constraints: []table.Constraint{constraint(table.OperatorGreaterThan, "100"), constraint(table.OperatorLessThanOrEquals, "200")},
+			in:          []int64{101, 200},
+			out:         []int64{100, 201},
+		},
+		{
+			name:        "tightest_bounds_win",
+			constraints: []table.Constraint{constraint(table.OperatorGreaterThan, "100"), constraint(table.OperatorGreaterThan, "150"), constraint(table.OperatorLessThan, "300"), constraint(table.OperatorLessThan, "250")},
+			in:          []int64{151, 249},
+			out:         []int64{150, 250},
+		},
+		{
+			name:        "equals",
+			constraints: []table.Constraint{constraint(table.OperatorEquals, "100")},
+			in:          []int64{100},
+			out:         []int64{99, 101},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryContext := table.QueryContext{Constraints: map[string]table.ConstraintList{
+				"time": {Constraints: tt.constraints},
+			}}
+			r := timeRangeFromContext(queryContext, "time")
+			for _, seconds := range tt.in {
+				if !r.contains(time.Unix(seconds, 0)) {
+					t.Errorf("Expected %d to be in range %+v", seconds, r)
+				}
+			}
+			for _, seconds := range tt.out {
+				if r.contains(time.Unix(seconds, 0)) {
+					t.Errorf("Expected %d to be outside range %+v", seconds, r)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeRangeCursor(t *testing.T) {
+	if cursor := (timeRange{}).cursor(); !cursor.IsZero() {
+		t.Errorf("Expected an open range to read the full history, got %+v", cursor)
+	}
+
+	after := time.Unix(100, 0)
+	cursor := timeRange{after: after}.cursor()
+	if !cursor.VisitTime.Before(after) || cursor.VisitID != 0 {
+		t.Errorf("Expected a time-only cursor just before %v, got %+v", after, cursor)
+	}
+}
+
+func TestCollectTimeline(t *testing.T) {
+	defer func(saved []common.HistorySource) { historySources = saved }(historySources)
+	defer Configure(Settings{})
+	Configure(Settings{})
+
+	at := func(seconds int64) time.Time { return time.Unix(seconds, 0).UTC() }
+	profile := common.Profile{ID: "Default", Path: "/home/alice/.config/chromium/Default", BrowserType: "chromium"}
+	historySources = []common.HistorySource{{
+		Engine:        "Test",
+		DatabaseFiles: []string{"History"},
+		FindProfiles: func(common.UserSelection) ([]common.Profile, error) {
+			return []common.Profile{profile}, nil
+		},
+		FindHistorySince: func(common.Profile, common.Cursor) ([]common.HistoryEntry, error) {
+			return []common.HistoryEntry{{URL: "https://example.com/", Title: "Example", VisitTime: at(100)}}, nil
+		},
+		FindDownloads: func(common.Profile) ([]common.Download, error) {
+			return []common.Download{{URL: "https://cdn.example.org/tool.sh", TargetPath: "/tmp/tool.sh", StartTime: at(200), Source: "History"}}, nil
+		},
+		FindAutofill: func(common.Profile, common.AutofillOptions) ([]common.AutofillEntry, error) {
+			return []common.AutofillEntry{
+				{Kind: common.AutofillForm, FieldName: "q", Value: "secret", LastUsed: at(300), Source: "Web Data"},
+				{Kind: common.AutofillCreditCard, LastUsed: at(310), Source: "Web Data"},
+			}, nil
+		},
+		FindBookmarks: func(common.Profile) ([]common.Bookmark, error) {
+			return []common.Bookmark{{URL: "https://mail.example.com/", Title: "Mail", Added: at(400), Source: "Bookmarks"}}, nil
+		},
+		FindCookies: func(common.Profile) ([]common.Cookie, error) {
+			return []common.Cookie{{Host: ".example.com", Name: "sid", Path: "/", Created: at(500), Source: "Cookies"}}, nil
+		},
+		FindExtensions: func(common.Profile) ([]common.Extension, error) {
+			return []common.Extension{
+				{ID: "aaaa", Name: "Helper", Installed: at(600), Source: "Preferences"},
+				{ID: "bbbb", Name: "Undated", Source: "Preferences"},
+			}, nil
+		},
+		FindLogins: func(common.Profile) ([]common.Login, error) {
+			return nil, errors.New("unreadable")
+		},
+	}}
+
+	timeline := collectTimeline(context.Background(), common.UserSelection{}, timeRange{before: at(600)})
+	want := []struct {
+		eventType, summary, source string
+		seconds                    int64
+	}{
+		{eventVisit, "Example", "History", 100},
+		{eventDownload, "Downloaded tool.sh", "History", 200},
+		{eventFormUse, "Filled form field q", "Web Data", 300},
+		{eventBookmark, "Bookmarked Mail", "Bookmarks", 400},
+		{eventCookie, "Cookie sid set by example.com", "Cookies", 500},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("collectTimeline() = %+v, want %d events", timeline, len(want))
+	}
+	for i, w := range want {
+		event := timeline[i]
+		if event.eventType != w.eventType || event.summary != w.summary || !event.time.Equal(at(w.seconds)) ||
+			event.sourcePath != filepath.Join(profile.Path, w.source) || event.profile.ID != profile.ID {
+			t.Errorf("timeline[%d] = %+v, want %+v", i, event, w)
+		}
+	}
+	if timeline[4].url != "https://example.com/" {
+		t.Errorf("Expected the cookie to link to its site, got %q", timeline[4].url)
+	}
+}
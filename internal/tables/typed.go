@@ -0,0 +1,103 @@
+package tables
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/osquery/osquery-go/plugin/table"
+
+	"osquery-extension-browsers/internal/browsers/common"
+	"osquery-extension-browsers/internal/redaction"
+)
+
+// BrowserTypedInputTablePlugin creates a table plugin listing what users
+// typed into the address bar and the URLs it led to, along with Chromium's
+// top sites. Constraints on uid or username limit enumeration to the
+// matching users.
+func BrowserTypedInputTablePlugin() *table.Plugin {
+	columns := []table.ColumnDefinition{
+		table.TextColumn("kind"),
+		table.TextColumn(redaction.TypedColumn),
+		table.TextColumn("url"),
+		table.TextColumn("title"),
+		table.IntegerColumn("hits"),
+		table.IntegerColumn("misses"),
+		table.IntegerColumn("rank"),
+		table.BigIntColumn("last_used_time"),
+		table.TextColumn("last_used_datetime"),
+		table.TextColumn("last_used_display_time"),
+		table.TextColumn("source"),
+		table.TextColumn("username"),
+		table.BigIntColumn("uid"),
+		table.TextColumn("browser_type"),
+		table.TextColumn("profile"),
+		table.TextColumn(redaction.Column),
+	}
+
+	gen := func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		return collectTypedInput(ctx, userSelectionFromContext(queryContext)), nil
+	}
+
+	return newPlugin("browser_typed_input", columns, gen, alwaysStateless)
+}
+
+// collectTypedInput returns the typed input of every discovered profile of
+// the selected users. Top sites have no typed text and are the only rows
+// with a rank; predictor entries are the only rows with misses.
+func collectTypedInput(ctx context.Context, selection common.UserSelection) []map[string]string {
+	var results []map[string]string
+	stats := generationStatsFromContext(ctx)
+
+	for _, source := range HistorySources() {
+		if source.FindTypedInput == nil {
+			continue
+		}
+		profiles, err := source.FindProfiles(selection)
+		if err != nil {
+			slog.Warn("Failed to find profiles", "engine", source.Engine, "error", err)
+			continue
+		}
+
+		for _, profile := range profiles {
+			started := time.Now()
+			inputs, err := source.FindTypedInput(profile)
+			if err != nil {
+				stats.reportProfileError(profile, err)
+				stats.observe(profile.BrowserType, started, 0)
+				continue
+			}
+			stats.clearProfileError(profile)
+
+			for _, input := range inputs {
+				row := map[string]string{
+					"kind":                input.Kind,
+					redaction.TypedColumn: input.Text,
+					"url":                 input.URL,
+					"title":               input.Title,
+					"hits":                strconv.Itoa(input.Hits),
+					"misses":              "",
+					"rank":                "",
+					"source":              input.Source,
+					"username":            profile.Username,
+					"uid":                 profile.UID,
+					"browser_type":        profile.BrowserType,
+					"profile":             profile.ID,
+				}
+				switch input.Kind {
+				case common.TypedTopSite:
+					row["hits"] = ""
+					row["rank"] = strconv.Itoa(input.Rank)
+				case common.TypedPredictor:
+					row["misses"] = strconv.Itoa(input.Misses)
+				}
+				setTime(row, "last_used_time", "last_used_datetime", "last_used_display_time", input.LastUsed)
+				results = append(results, row)
+			}
+			stats.observe(profile.BrowserType, started, len(inputs))
+		}
+	}
+
+	return results
+}